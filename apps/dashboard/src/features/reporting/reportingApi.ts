@@ -8,13 +8,32 @@ import type {
 
 const apiBaseUrl = import.meta.env.VITE_API_BASE_URL ?? "http://localhost:8080";
 const ingestApiKey = import.meta.env.VITE_INGEST_API_KEY;
+const projectId = import.meta.env.VITE_PROJECT_ID;
+
+// A host login flow stores the OIDC access token here; when present it is
+// preferred over a project API key.
+const accessTokenStorageKey = "retrospec.accessToken";
+
+function readAccessToken(): string | null {
+  try {
+    return window.sessionStorage.getItem(accessTokenStorageKey);
+  } catch {
+    return null;
+  }
+}
 
 export const reportingApi = createApi({
   reducerPath: "reportingApi",
   baseQuery: fetchBaseQuery({
     baseUrl: apiBaseUrl,
     prepareHeaders: (headers) => {
-      if (ingestApiKey) {
+      const accessToken = readAccessToken();
+      if (accessToken) {
+        headers.set("Authorization", `Bearer ${accessToken}`);
+        if (projectId) {
+          headers.set("X-Retrospec-Project", projectId);
+        }
+      } else if (ingestApiKey) {
         headers.set("X-Retrospec-Key", ingestApiKey);
       }
       return headers;
@@ -154,6 +154,7 @@ export function initRetrospec(options: RetrospecInitOptions): RetrospecClient {
     await ingestSession(config.apiBaseUrl, config.apiKey, {
       session: {
         id: state.sessionId,
+        ...(config.userId ? { userId: config.userId } : {}),
         site: config.site,
         route: config.route ?? window.location.pathname,
         startedAt: state.startedAt.toISOString(),
@@ -232,6 +233,7 @@ async function ingestSession(
   payload: {
     session: {
       id: string;
+      userId?: string;
       site: string;
       route: string;
       startedAt: string;
@@ -14,6 +14,7 @@ export interface RetrospecInitOptions {
   apiKey?: string;
   site: string;
   route?: string;
+  userId?: string;
   sampleRate?: number;
   maxEvents?: number;
   autoFlushMs?: number;
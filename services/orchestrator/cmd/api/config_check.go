@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"retrospec/services/orchestrator/internal/api"
+	"retrospec/services/orchestrator/internal/auth"
+	"retrospec/services/orchestrator/internal/config"
+)
+
+const commandUsage = "usage: api [config check [-config file]]"
+
+// runCommand handles subcommands; without one main starts the server.
+func runCommand(args []string, stdout, stderr io.Writer) int {
+	if len(args) < 2 || args[0] != "config" || args[1] != "check" {
+		fmt.Fprintln(stderr, commandUsage)
+		return 2
+	}
+
+	flags := flag.NewFlagSet("config check", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	path := flags.String("config", os.Getenv(config.FileEnv), "YAML or TOML config file")
+	if err := flags.Parse(args[2:]); err != nil {
+		return 2
+	}
+
+	cfg, settings, err := config.Inspect(*path)
+	problems := configProblems(cfg, err)
+
+	table := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	for _, setting := range settings {
+		fmt.Fprintf(table, "%s\t%s\t(%s)\n", setting.Key, setting.Value, setting.Source)
+	}
+	table.Flush()
+
+	if len(problems) > 0 {
+		fmt.Fprintln(stderr, "configuration invalid:")
+		for _, problem := range problems {
+			fmt.Fprintln(stderr, "  - "+problem)
+		}
+		return 1
+	}
+	fmt.Fprintln(stdout, "configuration ok")
+	return 0
+}
+
+// configProblems lists load errors plus the checks main runs before serving,
+// so `config check` fails exactly when startup would.
+func configProblems(cfg config.Config, loadErr error) []string {
+	problems := []string{}
+	var loadErrors config.Errors
+	if errors.As(loadErr, &loadErrors) {
+		for _, err := range loadErrors {
+			problems = append(problems, err.Error())
+		}
+	} else if loadErr != nil {
+		problems = append(problems, loadErr.Error())
+	}
+	if cfg.ListenAddr == "" {
+		// The config file could not be read, so there is nothing more to check.
+		return problems
+	}
+
+	if _, err := auth.ParseSigningKeys(cfg.InternalSigningKeys); err != nil {
+		problems = append(problems, "INTERNAL_SIGNING_KEYS: "+err.Error())
+	}
+	artifactTokenKeys, err := auth.ParseSigningKeys(cfg.ArtifactTokenKeys)
+	if err != nil {
+		problems = append(problems, "ARTIFACT_TOKEN_KEYS: "+err.Error())
+	} else if len(artifactTokenKeys) > 0 && !(cfg.ArtifactTokenActiveKeyID == "" && len(artifactTokenKeys) == 1) {
+		if _, ok := artifactTokenKeys[cfg.ArtifactTokenActiveKeyID]; !ok {
+			problems = append(problems, "ARTIFACT_TOKEN_ACTIVE_KEY_ID: must name a configured key")
+		}
+	}
+	erasureReceiptKeys, err := auth.ParseSigningKeys(cfg.ErasureReceiptKeys)
+	if err != nil {
+		problems = append(problems, "ERASURE_RECEIPT_KEYS: "+err.Error())
+	} else if len(erasureReceiptKeys) > 1 {
+		if _, ok := erasureReceiptKeys[cfg.ErasureReceiptActiveKeyID]; !ok {
+			problems = append(problems, "ERASURE_RECEIPT_ACTIVE_KEY_ID: must name a configured key")
+		}
+	}
+	if _, err := api.ParseTrustedProxies(cfg.TrustedProxyCIDRs); err != nil {
+		problems = append(problems, "TRUSTED_PROXY_CIDRS: "+err.Error())
+	}
+	if _, err := api.ParseRequiredDependencies(cfg.ReadinessRequired); err != nil {
+		problems = append(problems, "READINESS_REQUIRED_DEPENDENCIES: "+err.Error())
+	}
+	return problems
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+
+	"retrospec/services/orchestrator/internal/artifacts"
+	"retrospec/services/orchestrator/internal/store"
+)
+
+type dataKeyRepository struct {
+	db *store.Postgres
+}
+
+func (r dataKeyRepository) ActiveDataKey(ctx context.Context, projectID string) (artifacts.DataKey, error) {
+	key, err := r.db.GetActiveEncryptionDataKey(ctx, projectID)
+	return toArtifactDataKey(key, err)
+}
+
+func (r dataKeyRepository) DataKey(ctx context.Context, keyID string) (artifacts.DataKey, error) {
+	key, err := r.db.GetEncryptionDataKey(ctx, keyID)
+	return toArtifactDataKey(key, err)
+}
+
+func (r dataKeyRepository) CreateDataKey(ctx context.Context, key artifacts.DataKey) (artifacts.DataKey, error) {
+	created, err := r.db.CreateEncryptionDataKey(ctx, store.EncryptionDataKey{
+		ID:          key.ID,
+		ProjectID:   key.ProjectID,
+		MasterKeyID: key.MasterKeyID,
+		WrappedKey:  key.WrappedKey,
+	})
+	return toArtifactDataKey(created, err)
+}
+
+func (r dataKeyRepository) DataKeysForRewrap(
+	ctx context.Context,
+	projectID string,
+	activeMasterKeyID string,
+	limit int,
+) ([]artifacts.DataKey, error) {
+	keys, err := r.db.ListEncryptionDataKeysForRewrap(ctx, projectID, activeMasterKeyID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	converted := make([]artifacts.DataKey, 0, len(keys))
+	for _, key := range keys {
+		item, _ := toArtifactDataKey(key, nil)
+		converted = append(converted, item)
+	}
+	return converted, nil
+}
+
+func (r dataKeyRepository) RewrapDataKey(
+	ctx context.Context,
+	keyID string,
+	fromMasterKeyID string,
+	key artifacts.DataKey,
+) error {
+	return r.db.RewrapEncryptionDataKey(ctx, keyID, fromMasterKeyID, key.MasterKeyID, key.WrappedKey)
+}
+
+func toArtifactDataKey(key store.EncryptionDataKey, err error) (artifacts.DataKey, error) {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return artifacts.DataKey{}, artifacts.ErrDataKeyNotFound
+	}
+	if err != nil {
+		return artifacts.DataKey{}, err
+	}
+	return artifacts.DataKey{
+		ID:          key.ID,
+		ProjectID:   key.ProjectID,
+		MasterKeyID: key.MasterKeyID,
+		WrappedKey:  key.WrappedKey,
+	}, nil
+}
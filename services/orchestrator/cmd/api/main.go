@@ -3,32 +3,59 @@ package main
 import (
 	"context"
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
+	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"retrospec/services/orchestrator/internal/api"
 	"retrospec/services/orchestrator/internal/artifacts"
+	"retrospec/services/orchestrator/internal/auth"
 	"retrospec/services/orchestrator/internal/config"
+	"retrospec/services/orchestrator/internal/logging"
 	"retrospec/services/orchestrator/internal/queue"
 	"retrospec/services/orchestrator/internal/store"
+	"retrospec/services/orchestrator/internal/tracing"
 )
 
 func main() {
-	cfg := config.Load()
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:], os.Stdout, os.Stderr))
+	}
+
+	cfg, settings, err := config.Inspect(os.Getenv(config.FileEnv))
+	if problems := configProblems(cfg, err); len(problems) > 0 {
+		fatal("invalid configuration", slog.Any("problems", problems))
+	}
+	if err := logging.Setup(os.Stderr, cfg.LogLevel, cfg.LogFormat); err != nil {
+		fatal("logging setup failed", logging.Err(err))
+	}
 
 	ctx := context.Background()
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Config{
+		Exporter:     cfg.TracesExporter,
+		OTLPEndpoint: cfg.OTLPTracesEndpoint,
+		ServiceName:  cfg.TracesServiceName,
+		SampleRatio:  cfg.TracesSampleRatio,
+	})
+	if err != nil {
+		fatal("tracing setup failed", logging.Err(err))
+	}
+	if cfg.TracesExporter != tracing.ExporterNone {
+		slog.Info("tracing enabled", slog.String("exporter", cfg.TracesExporter), slog.String("endpoint", cfg.OTLPTracesEndpoint))
+	}
+
 	db, err := store.NewPostgres(ctx, cfg.DatabaseURL)
 	if err != nil {
-		log.Fatalf("database connection failed: %v", err)
+		fatal("database connection failed", logging.Err(err))
 	}
 	defer db.Close()
 
 	replayQueue, err := queue.NewRedisProducer(cfg.RedisAddr, cfg.ReplayQueueName, cfg.AnalysisQueueName)
 	if err != nil {
-		log.Printf("replay queue unavailable (%v), continuing with noop producer", err)
+		slog.Warn("replay queue unavailable, continuing with noop producer", logging.Err(err))
 		replayQueue = nil
 	}
 
@@ -42,7 +69,7 @@ func main() {
 
 	var artifactStore artifacts.Store
 	if cfg.S3Bucket == "" || cfg.S3AccessKey == "" || cfg.S3SecretKey == "" {
-		log.Printf("artifact store disabled: missing s3 credentials or bucket")
+		slog.Warn("artifact store disabled: missing s3 credentials or bucket")
 		artifactStore = artifacts.NewNoopStore()
 	} else {
 		s3Store, err := artifacts.NewS3Store(
@@ -54,7 +81,7 @@ func main() {
 			cfg.S3Bucket,
 		)
 		if err != nil {
-			log.Printf("artifact store unavailable (%v), continuing with noop store", err)
+			slog.Warn("artifact store unavailable, continuing with noop store", logging.Err(err))
 			artifactStore = artifacts.NewNoopStore()
 		} else {
 			if cfg.S3LifecycleEnabled {
@@ -67,39 +94,124 @@ func main() {
 					cfg.S3LifecyclePrefixes,
 				)
 				if err != nil {
-					log.Printf(
-						"unable to apply s3 lifecycle policy bucket=%s days=%d err=%v",
-						cfg.S3Bucket,
-						cfg.S3LifecycleExpirationDays,
-						err,
+					slog.Error("unable to apply s3 lifecycle policy",
+						slog.String("bucket", cfg.S3Bucket),
+						slog.Int("expiration_days", cfg.S3LifecycleExpirationDays),
+						logging.Err(err),
 					)
 				} else {
-					log.Printf(
-						"applied s3 lifecycle policy bucket=%s days=%d prefixes=%v",
-						cfg.S3Bucket,
-						cfg.S3LifecycleExpirationDays,
-						cfg.S3LifecyclePrefixes,
+					slog.Info("applied s3 lifecycle policy",
+						slog.String("bucket", cfg.S3Bucket),
+						slog.Int("expiration_days", cfg.S3LifecycleExpirationDays),
+						slog.Any("prefixes", cfg.S3LifecyclePrefixes),
 					)
 				}
 			}
 			artifactStore = s3Store
+			if cfg.EncryptionEnabled {
+				keyring, err := artifacts.LoadMasterKeyring(
+					cfg.EncryptionKeyringFile,
+					cfg.EncryptionMasterKeyID,
+					cfg.EncryptionMasterKey,
+				)
+				if err != nil {
+					fatal("artifact encryption enabled but master key unavailable", logging.Err(err))
+				}
+				artifactStore = artifacts.NewEncryptedStore(s3Store, keyring, dataKeyRepository{db: db}, cfg.EncryptionAllowPlaintext)
+				slog.Info("artifact encryption enabled",
+					slog.String("active_master_key_id", keyring.ActiveKeyID()),
+					slog.Bool("allow_plaintext_reads", cfg.EncryptionAllowPlaintext),
+				)
+			}
 		}
 	}
 	defer artifactStore.Close()
 
+	var jwtVerifier *auth.JWTVerifier
+	if cfg.JWTIssuer != "" {
+		jwtCtx, cancelJWT := context.WithTimeout(ctx, 10*time.Second)
+		jwtVerifier, err = auth.NewJWTVerifier(jwtCtx, auth.JWTConfig{
+			Issuer:        cfg.JWTIssuer,
+			Audience:      cfg.JWTAudience,
+			JWKSFile:      cfg.JWTJWKSFile,
+			JWKSURL:       cfg.JWTJWKSURL,
+			ProjectsClaim: cfg.JWTProjectsClaim,
+		})
+		cancelJWT()
+		if err != nil {
+			fatal("jwt bearer auth configured but unavailable", logging.Err(err))
+		}
+		slog.Info("jwt bearer auth enabled", slog.String("issuer", cfg.JWTIssuer))
+	}
+
+	internalSigningKeys, err := auth.ParseSigningKeys(cfg.InternalSigningKeys)
+	if err != nil {
+		fatal("invalid INTERNAL_SIGNING_KEYS", logging.Err(err))
+	}
+	if len(internalSigningKeys) > 0 {
+		slog.Info("internal callback signing enabled", slog.Any("key_ids", internalSigningKeys.KeyIDs()))
+	}
+
+	artifactTokenKeys, err := auth.ParseSigningKeys(cfg.ArtifactTokenKeys)
+	if err != nil {
+		fatal("invalid ARTIFACT_TOKEN_KEYS", logging.Err(err))
+	}
+	artifactTokenActiveKeyID := cfg.ArtifactTokenActiveKeyID
+	if artifactTokenActiveKeyID == "" && len(artifactTokenKeys) == 1 {
+		artifactTokenActiveKeyID = artifactTokenKeys.KeyIDs()[0]
+	}
+	if _, ok := artifactTokenKeys[artifactTokenActiveKeyID]; len(artifactTokenKeys) > 0 && !ok {
+		fatal("ARTIFACT_TOKEN_ACTIVE_KEY_ID must name a configured key", slog.Any("key_ids", artifactTokenKeys.KeyIDs()))
+	}
+
+	erasureReceiptKeys, err := auth.ParseSigningKeys(cfg.ErasureReceiptKeys)
+	if err != nil {
+		fatal("invalid ERASURE_RECEIPT_KEYS", logging.Err(err))
+	}
+	erasureReceiptActiveKeyID := cfg.ErasureReceiptActiveKeyID
+	if erasureReceiptActiveKeyID == "" && len(erasureReceiptKeys) == 1 {
+		erasureReceiptActiveKeyID = erasureReceiptKeys.KeyIDs()[0]
+	}
+	if len(erasureReceiptKeys) == 0 {
+		slog.Warn("ERASURE_RECEIPT_KEYS not set: privacy erasure receipts will be unsigned")
+	}
+
+	trustedProxies, err := api.ParseTrustedProxies(cfg.TrustedProxyCIDRs)
+	if err != nil {
+		fatal("invalid TRUSTED_PROXY_CIDRS", logging.Err(err))
+	}
+	requiredDependencies, err := api.ParseRequiredDependencies(cfg.ReadinessRequired)
+	if err != nil {
+		fatal("invalid READINESS_REQUIRED_DEPENDENCIES", logging.Err(err))
+	}
+
+	runtime := runtimeSettings(cfg)
 	handler := api.NewHandler(
 		db,
 		producer,
 		artifactStore,
-		cfg.CORSAllowedOrigins,
-		cfg.InternalAPIKey,
+		runtime.CORSAllowedOrigins,
+		internalSigningKeys,
+		cfg.InternalSignatureSkewSec,
 		cfg.IngestAPIKey,
-		cfg.ClusterPromoteMinSessions,
-		cfg.RateLimitRequestsPerSec,
-		cfg.RateLimitBurst,
-		cfg.ArtifactTokenSecret,
+		cfg.IngestAPIKeyFullAccess,
+		runtime.ClusterPromoteMinSessions,
+		runtime.RateLimits,
+		artifactTokenKeys,
+		artifactTokenActiveKeyID,
+		cfg.ArtifactTokenSingleUse,
 		cfg.ArtifactTokenTTLSeconds,
-		cfg.SessionRetentionDays,
+		erasureReceiptKeys,
+		erasureReceiptActiveKeyID,
+		runtime.SessionRetentionDays,
+		cfg.AdminAPIKey,
+		cfg.APIKeyRotationGraceMinutes,
+		jwtVerifier,
+		cfg.JWTScopes,
+		cfg.ForbidAnonymousReads,
+		trustedProxies,
+		cfg.MetricsMaxProjectLabels,
+		requiredDependencies,
 	)
 	router := handler.Router()
 
@@ -111,18 +223,16 @@ func main() {
 
 	shutdownCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
-	startMaintenanceLoops(
-		shutdownCtx,
-		db,
-		artifactStore,
-		time.Duration(cfg.AutoCleanupIntervalMinutes)*time.Minute,
-		cfg.SessionRetentionDays,
-	)
+	maintenance := startMaintenanceLoops(shutdownCtx, db, artifactStore, maintenanceSettingsFor(cfg))
+
+	reloader := newConfigReloader(settings, handler, maintenance)
+	handler.SetConfigReloader(reloader.reload)
+	go reloader.reloadOnSignal(shutdownCtx)
 
 	go func() {
-		log.Printf("orchestrator listening on %s", cfg.ListenAddr)
+		slog.Info("orchestrator listening", slog.String("addr", cfg.ListenAddr))
 		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
-			log.Fatalf("server failed: %v", err)
+			fatal("server failed", logging.Err(err))
 		}
 	}()
 
@@ -131,6 +241,14 @@ func main() {
 	defer cancel()
 
 	if err := server.Shutdown(ctxTimeout); err != nil {
-		log.Printf("graceful shutdown failed: %v", err)
+		slog.Error("graceful shutdown failed", logging.Err(err))
+	}
+	if err := shutdownTracing(ctxTimeout); err != nil {
+		slog.Error("trace exporter shutdown failed", logging.Err(err))
 	}
 }
+
+func fatal(msg string, attrs ...any) {
+	slog.Error(msg, attrs...)
+	os.Exit(1)
+}
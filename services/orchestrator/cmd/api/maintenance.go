@@ -3,22 +3,79 @@ package main
 import (
 	"context"
 	"errors"
-	"log"
+	"log/slog"
+	"sync"
 	"time"
 
 	"retrospec/services/orchestrator/internal/artifacts"
+	"retrospec/services/orchestrator/internal/logging"
 	"retrospec/services/orchestrator/internal/store"
 )
 
+// maintenanceSettings are the loop intervals and limits a config reload can
+// change.
+type maintenanceSettings struct {
+	cleanupInterval  time.Duration
+	retentionDays    int
+	rewrapInterval   time.Duration
+	keyAuditInterval time.Duration
+	unusedKeyDays    int
+	retentionTagSync time.Duration
+}
+
+// maintenanceLoops runs the background loops and restarts them when their
+// settings change.
+type maintenanceLoops struct {
+	ctx           context.Context
+	db            *store.Postgres
+	artifactStore artifacts.Store
+
+	mu       sync.Mutex
+	settings maintenanceSettings
+	cancel   context.CancelFunc
+}
+
 func startMaintenanceLoops(
 	ctx context.Context,
 	db *store.Postgres,
 	artifactStore artifacts.Store,
-	cleanupInterval time.Duration,
-	retentionDays int,
-) {
-	if cleanupInterval > 0 {
-		go runCleanupLoop(ctx, db, artifactStore, cleanupInterval, retentionDays)
+	settings maintenanceSettings,
+) *maintenanceLoops {
+	loops := &maintenanceLoops{ctx: ctx, db: db, artifactStore: artifactStore}
+	loops.mu.Lock()
+	defer loops.mu.Unlock()
+	loops.start(settings, true)
+	return loops
+}
+
+// apply restarts the loops with new settings. Restarted loops wait a full
+// interval before their next cycle.
+func (m *maintenanceLoops) apply(settings maintenanceSettings) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if settings == m.settings {
+		return
+	}
+	m.cancel()
+	m.start(settings, false)
+}
+
+func (m *maintenanceLoops) start(settings maintenanceSettings, runFirst bool) {
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.settings = settings
+	m.cancel = cancel
+
+	if settings.cleanupInterval > 0 {
+		go runCleanupLoop(ctx, m.db, m.artifactStore, settings.cleanupInterval, settings.retentionDays, runFirst)
+	}
+	if rewrapper, ok := m.artifactStore.(artifacts.DataKeyRewrapper); ok && settings.rewrapInterval > 0 {
+		go runRewrapLoop(ctx, m.db, rewrapper, settings.rewrapInterval, runFirst)
+	}
+	if settings.keyAuditInterval > 0 && settings.unusedKeyDays > 0 {
+		go runKeyAuditLoop(ctx, m.db, settings.keyAuditInterval, settings.unusedKeyDays, runFirst)
+	}
+	if holder, ok := m.artifactStore.(artifacts.RetentionHolder); ok && settings.retentionTagSync > 0 {
+		go runRetentionTagLoop(ctx, m.db, holder, settings.retentionTagSync, runFirst)
 	}
 }
 
@@ -28,8 +85,11 @@ func runCleanupLoop(
 	artifactStore artifacts.Store,
 	interval time.Duration,
 	retentionDays int,
+	runFirst bool,
 ) {
-	runCleanupCycle(ctx, db, artifactStore, retentionDays)
+	if runFirst {
+		runCleanupCycle(ctx, db, artifactStore, retentionDays)
+	}
 
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
@@ -55,19 +115,20 @@ func runCleanupCycle(
 
 	projects, err := db.ListProjects(cycleCtx)
 	if err != nil {
-		log.Printf("auto-cleanup failed loading projects: %v", err)
+		slog.ErrorContext(cycleCtx, "auto-cleanup failed loading projects", logging.Err(err))
 		return
 	}
 
 	totalSessions := 0
 	totalEventObjects := 0
 	totalArtifactObjects := 0
+	totalHeldSessions := 0
 	totalFailures := 0
 
 	for _, project := range projects {
 		result, err := db.CleanupExpiredData(cycleCtx, project.ID, retentionDays)
 		if err != nil {
-			log.Printf("auto-cleanup failed project=%s err=%v", project.ID, err)
+			slog.ErrorContext(cycleCtx, "auto-cleanup failed", slog.String(logging.ProjectIDKey, project.ID), logging.Err(err))
 			totalFailures++
 			continue
 		}
@@ -76,27 +137,221 @@ func runCleanupCycle(
 			err := artifactStore.DeleteObject(cycleCtx, objectKey)
 			if err != nil && !errors.Is(err, artifacts.ErrNotConfigured) {
 				totalFailures++
-				log.Printf("auto-cleanup failed deleting event object key=%s err=%v", objectKey, err)
+				slog.ErrorContext(cycleCtx, "auto-cleanup event object delete failed", slog.String(logging.ProjectIDKey, project.ID), slog.String("object_key", objectKey), logging.Err(err))
 			}
 		}
 		for _, objectKey := range result.DeletedArtifactObjectKeys {
 			err := artifactStore.DeleteObject(cycleCtx, objectKey)
 			if err != nil && !errors.Is(err, artifacts.ErrNotConfigured) {
 				totalFailures++
-				log.Printf("auto-cleanup failed deleting artifact object key=%s err=%v", objectKey, err)
+				slog.ErrorContext(cycleCtx, "auto-cleanup artifact object delete failed", slog.String(logging.ProjectIDKey, project.ID), slog.String("object_key", objectKey), logging.Err(err))
 			}
 		}
 
 		totalSessions += result.DeletedSessions
 		totalEventObjects += result.DeletedEventObjects
 		totalArtifactObjects += result.DeletedArtifactObjects
+		totalHeldSessions += result.HeldSessions
+	}
+
+	if _, err := db.PurgeExpiredArtifactTokenRevocations(cycleCtx); err != nil {
+		totalFailures++
+		slog.ErrorContext(cycleCtx, "auto-cleanup failed purging artifact token revocations", logging.Err(err))
 	}
 
-	log.Printf(
-		"auto-cleanup completed sessions=%d eventObjects=%d artifactObjects=%d failures=%d",
-		totalSessions,
-		totalEventObjects,
-		totalArtifactObjects,
-		totalFailures,
+	slog.InfoContext(cycleCtx, "auto-cleanup completed",
+		slog.Int("sessions", totalSessions),
+		slog.Int("event_objects", totalEventObjects),
+		slog.Int("artifact_objects", totalArtifactObjects),
+		slog.Int("held_sessions", totalHeldSessions),
+		slog.Int("failures", totalFailures),
 	)
 }
+
+func runRewrapLoop(
+	ctx context.Context,
+	db *store.Postgres,
+	rewrapper artifacts.DataKeyRewrapper,
+	interval time.Duration,
+	runFirst bool,
+) {
+	if runFirst {
+		runRewrapCycle(ctx, db, rewrapper)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			runRewrapCycle(ctx, db, rewrapper)
+		}
+	}
+}
+
+func runRewrapCycle(
+	ctx context.Context,
+	db *store.Postgres,
+	rewrapper artifacts.DataKeyRewrapper,
+) {
+	cycleCtx, cancel := context.WithTimeout(ctx, 45*time.Second)
+	defer cancel()
+
+	projects, err := db.ListProjects(cycleCtx)
+	if err != nil {
+		slog.ErrorContext(cycleCtx, "data key rewrap failed loading projects", logging.Err(err))
+		return
+	}
+
+	totalRewrapped := 0
+	totalFailures := 0
+	for _, project := range projects {
+		result, err := rewrapper.RewrapDataKeys(cycleCtx, project.ID, 500)
+		if err != nil {
+			slog.ErrorContext(cycleCtx, "data key rewrap failed", slog.String(logging.ProjectIDKey, project.ID), logging.Err(err))
+			totalFailures++
+			continue
+		}
+		totalRewrapped += result.Rewrapped
+		totalFailures += result.Failed
+	}
+
+	if totalRewrapped > 0 || totalFailures > 0 {
+		slog.InfoContext(cycleCtx, "data key rewrap completed", slog.Int("rewrapped", totalRewrapped), slog.Int("failures", totalFailures))
+	}
+}
+
+func runKeyAuditLoop(
+	ctx context.Context,
+	db *store.Postgres,
+	interval time.Duration,
+	unusedDays int,
+	runFirst bool,
+) {
+	if runFirst {
+		runKeyAuditCycle(ctx, db, unusedDays)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			runKeyAuditCycle(ctx, db, unusedDays)
+		}
+	}
+}
+
+func runKeyAuditCycle(ctx context.Context, db *store.Postgres, unusedDays int) {
+	cycleCtx, cancel := context.WithTimeout(ctx, 45*time.Second)
+	defer cancel()
+
+	flagged, err := db.FlagUnusedAPIKeys(cycleCtx, unusedDays)
+	if err != nil {
+		slog.ErrorContext(cycleCtx, "api key audit failed", logging.Err(err))
+		return
+	}
+
+	for _, apiKey := range flagged {
+		lastUsedAt := "never"
+		if apiKey.LastUsedAt != nil {
+			lastUsedAt = apiKey.LastUsedAt.UTC().Format(time.RFC3339)
+		}
+		slog.WarnContext(cycleCtx, "api key flagged unused",
+			slog.String(logging.ProjectIDKey, apiKey.ProjectID),
+			slog.String("api_key_id", apiKey.ID),
+			slog.String("label", apiKey.Label),
+			slog.String("last_used_at", lastUsedAt),
+			slog.Int("unused_days", unusedDays),
+		)
+	}
+}
+
+func runRetentionTagLoop(
+	ctx context.Context,
+	db *store.Postgres,
+	holder artifacts.RetentionHolder,
+	interval time.Duration,
+	runFirst bool,
+) {
+	if runFirst {
+		runRetentionTagCycle(ctx, db, holder)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			runRetentionTagCycle(ctx, db, holder)
+		}
+	}
+}
+
+// runRetentionTagCycle tags objects of sessions that predate retention tagging
+// and re-tags sessions whose holds were released or lapsed, so the lifecycle
+// rule expires them again.
+func runRetentionTagCycle(ctx context.Context, db *store.Postgres, holder artifacts.RetentionHolder) {
+	cycleCtx, cancel := context.WithTimeout(ctx, 45*time.Second)
+	defer cancel()
+
+	drift, err := db.ListRetentionTagDrift(cycleCtx, 500)
+	if err != nil {
+		slog.ErrorContext(cycleCtx, "retention tag sync failed loading sessions", logging.Err(err))
+		return
+	}
+
+	backfilled := 0
+	retagged := 0
+	totalFailures := 0
+	for _, session := range drift {
+		retentionTag := artifacts.RetentionTagExpire
+		if session.Held {
+			retentionTag = artifacts.RetentionTagHold
+		}
+
+		failed := false
+		for _, objectKey := range session.ObjectKeys {
+			err := holder.SetObjectRetentionHold(cycleCtx, objectKey, session.Held)
+			if errors.Is(err, artifacts.ErrNotConfigured) {
+				return
+			}
+			if err != nil && !errors.Is(err, artifacts.ErrObjectNotFound) {
+				failed = true
+				slog.ErrorContext(cycleCtx, "retention tag sync object tagging failed", slog.String(logging.ProjectIDKey, session.ProjectID), slog.String("object_key", objectKey), logging.Err(err))
+			}
+		}
+		if failed {
+			totalFailures++
+			continue
+		}
+
+		if err := db.MarkSessionRetentionTag(cycleCtx, session.ProjectID, session.SessionID, retentionTag); err != nil {
+			totalFailures++
+			slog.ErrorContext(cycleCtx, "retention tag sync record failed", slog.String(logging.SessionIDKey, session.SessionID), logging.Err(err))
+			continue
+		}
+		if session.RetentionTag == "" {
+			backfilled++
+		} else {
+			retagged++
+		}
+	}
+
+	if backfilled > 0 || retagged > 0 || totalFailures > 0 {
+		slog.InfoContext(cycleCtx, "retention tag sync completed",
+			slog.Int("backfilled", backfilled),
+			slog.Int("retagged", retagged),
+			slog.Int("failures", totalFailures),
+		)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"retrospec/services/orchestrator/internal/api"
+	"retrospec/services/orchestrator/internal/config"
+	"retrospec/services/orchestrator/internal/logging"
+	"retrospec/services/orchestrator/internal/store"
+)
+
+// configReloader re-reads the configuration on SIGHUP or the admin reload
+// route and applies the reloadable subset. The environment of a running
+// process cannot change, so in practice reloads pick up CONFIG_FILE edits.
+type configReloader struct {
+	mu          sync.Mutex
+	settings    []config.Setting
+	handler     *api.Handler
+	maintenance *maintenanceLoops
+}
+
+func newConfigReloader(settings []config.Setting, handler *api.Handler, maintenance *maintenanceLoops) *configReloader {
+	return &configReloader{settings: settings, handler: handler, maintenance: maintenance}
+}
+
+func (r *configReloader) reloadOnSignal(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			if _, err := r.reload(ctx); err != nil {
+				slog.ErrorContext(ctx, "config reload rejected, keeping current settings", logging.Err(err))
+			}
+		}
+	}
+}
+
+// reload applies a valid configuration and returns what changed. Settings
+// that need a restart are reported but keep their running value, so they are
+// reported again on the next reload.
+func (r *configReloader) reload(ctx context.Context) ([]config.Change, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg, settings, err := config.Inspect(os.Getenv(config.FileEnv))
+	if problems := configProblems(cfg, err); len(problems) > 0 {
+		reloadErrors := make(config.Errors, 0, len(problems))
+		for _, problem := range problems {
+			reloadErrors = append(reloadErrors, errors.New(problem))
+		}
+		return nil, reloadErrors
+	}
+
+	changes := config.Diff(r.settings, settings)
+	r.handler.ApplyRuntimeSettings(runtimeSettings(cfg))
+	r.maintenance.apply(maintenanceSettingsFor(cfg))
+
+	applied := map[string]config.Setting{}
+	for _, setting := range settings {
+		if config.Reloadable(setting.Key) {
+			applied[setting.Key] = setting
+		}
+	}
+	for i, setting := range r.settings {
+		if next, ok := applied[setting.Key]; ok {
+			r.settings[i] = next
+		}
+	}
+
+	for _, change := range changes {
+		attrs := []any{
+			slog.String("key", change.Key),
+			slog.String("old", change.Old),
+			slog.String("new", change.New),
+		}
+		if change.Reloadable {
+			slog.InfoContext(ctx, "config setting reloaded", attrs...)
+		} else {
+			slog.WarnContext(ctx, "config setting changed but requires a restart", attrs...)
+		}
+	}
+	slog.InfoContext(ctx, "config reloaded", slog.Int("changes", len(changes)))
+	return changes, nil
+}
+
+func runtimeSettings(cfg config.Config) api.RuntimeSettings {
+	return api.RuntimeSettings{
+		CORSAllowedOrigins: cfg.CORSAllowedOrigins,
+		RateLimits: map[string]store.RateLimit{
+			store.RateLimitClassIngest:  {RequestsPerSec: cfg.RateLimitIngestPerSec, Burst: cfg.RateLimitIngestBurst},
+			store.RateLimitClassRead:    {RequestsPerSec: cfg.RateLimitReadPerSec, Burst: cfg.RateLimitReadBurst},
+			store.RateLimitClassAdmin:   {RequestsPerSec: cfg.RateLimitAdminPerSec, Burst: cfg.RateLimitAdminBurst},
+			store.RateLimitClassAddress: {RequestsPerSec: cfg.RateLimitAddressPerSec, Burst: cfg.RateLimitAddressBurst},
+		},
+		ClusterPromoteMinSessions: cfg.ClusterPromoteMinSessions,
+		SessionRetentionDays:      cfg.SessionRetentionDays,
+	}
+}
+
+func maintenanceSettingsFor(cfg config.Config) maintenanceSettings {
+	return maintenanceSettings{
+		cleanupInterval:  time.Duration(cfg.AutoCleanupIntervalMinutes) * time.Minute,
+		retentionDays:    cfg.SessionRetentionDays,
+		rewrapInterval:   time.Duration(cfg.EncryptionRewrapMinutes) * time.Minute,
+		keyAuditInterval: time.Duration(cfg.APIKeyAuditIntervalMinutes) * time.Minute,
+		unusedKeyDays:    cfg.APIKeyUnusedDays,
+		retentionTagSync: time.Duration(cfg.RetentionTagSyncMinutes) * time.Minute,
+	}
+}
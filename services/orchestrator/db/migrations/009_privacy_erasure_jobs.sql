@@ -0,0 +1,32 @@
+ALTER TABLE sessions
+ADD COLUMN IF NOT EXISTS user_id TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS sessions_project_user_idx
+  ON sessions (project_id, user_id)
+  WHERE user_id <> '';
+
+CREATE TABLE IF NOT EXISTS privacy_erasure_jobs (
+  id TEXT PRIMARY KEY,
+  project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+  subject_user_id TEXT NOT NULL DEFAULT '',
+  requested_session_ids TEXT[] NOT NULL DEFAULT '{}'::text[],
+  status TEXT NOT NULL DEFAULT 'running',
+  requested_by TEXT NOT NULL DEFAULT '',
+  erased_session_ids TEXT[] NOT NULL DEFAULT '{}'::text[],
+  deleted_sessions INTEGER NOT NULL DEFAULT 0,
+  deleted_markers INTEGER NOT NULL DEFAULT 0,
+  deleted_report_cards INTEGER NOT NULL DEFAULT 0,
+  deleted_artifacts INTEGER NOT NULL DEFAULT 0,
+  deleted_feedback_events INTEGER NOT NULL DEFAULT 0,
+  deleted_event_object_keys TEXT[] NOT NULL DEFAULT '{}'::text[],
+  deleted_artifact_object_keys TEXT[] NOT NULL DEFAULT '{}'::text[],
+  failed_object_keys TEXT[] NOT NULL DEFAULT '{}'::text[],
+  affected_cluster_keys TEXT[] NOT NULL DEFAULT '{}'::text[],
+  error TEXT NOT NULL DEFAULT '',
+  receipt_digest TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS privacy_erasure_jobs_project_created_idx
+  ON privacy_erasure_jobs (project_id, created_at DESC);
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS legal_holds (
+  id TEXT PRIMARY KEY,
+  project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+  target_type TEXT NOT NULL,
+  target_id TEXT NOT NULL,
+  reason TEXT NOT NULL,
+  created_by TEXT NOT NULL,
+  expires_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  released_at TIMESTAMPTZ,
+  released_by TEXT NOT NULL DEFAULT '',
+  CONSTRAINT legal_holds_target_type_check CHECK (target_type IN ('session', 'issue_cluster'))
+);
+
+CREATE INDEX IF NOT EXISTS legal_holds_project_target_idx
+  ON legal_holds (project_id, target_type, target_id)
+  WHERE released_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS legal_holds_project_created_idx
+  ON legal_holds (project_id, created_at DESC);
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS encryption_data_keys (
+  id TEXT PRIMARY KEY,
+  project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+  master_key_id TEXT NOT NULL,
+  wrapped_key BYTEA NOT NULL,
+  status TEXT NOT NULL DEFAULT 'active',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  rewrapped_at TIMESTAMPTZ,
+  CONSTRAINT encryption_data_keys_status_check CHECK (status IN ('active', 'retired'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS encryption_data_keys_project_active_idx
+  ON encryption_data_keys (project_id)
+  WHERE status = 'active';
+
+CREATE INDEX IF NOT EXISTS encryption_data_keys_master_key_idx
+  ON encryption_data_keys (project_id, master_key_id);
@@ -0,0 +1,5 @@
+-- Keys default to ingest-only so browser SDK keys cannot read session data.
+-- Existing keys are backfilled to ingest-only as well, since they are the
+-- keys already embedded in browsers; mint scoped keys for readers.
+ALTER TABLE project_api_keys
+ADD COLUMN IF NOT EXISTS scopes TEXT[] NOT NULL DEFAULT ARRAY['ingest']::text[];
@@ -0,0 +1,12 @@
+ALTER TABLE project_api_keys
+ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+
+ALTER TABLE project_api_keys
+ADD COLUMN IF NOT EXISTS rotated_to_key_id TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE project_api_keys
+ADD COLUMN IF NOT EXISTS flagged_unused_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS project_api_keys_expires_at_idx
+  ON project_api_keys (expires_at)
+  WHERE expires_at IS NOT NULL;
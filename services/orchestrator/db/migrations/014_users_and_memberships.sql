@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS users (
+  id TEXT PRIMARY KEY,
+  email TEXT NOT NULL,
+  display_name TEXT NOT NULL DEFAULT '',
+  external_subject TEXT,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  last_seen_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_idx ON users (LOWER(email));
+CREATE UNIQUE INDEX IF NOT EXISTS users_external_subject_idx
+  ON users (external_subject)
+  WHERE external_subject IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS project_memberships (
+  project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+  user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  role TEXT NOT NULL,
+  status TEXT NOT NULL DEFAULT 'invited',
+  invited_by TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  accepted_at TIMESTAMPTZ,
+  PRIMARY KEY (project_id, user_id),
+  CONSTRAINT project_memberships_role_check CHECK (role IN ('viewer', 'triager', 'admin')),
+  CONSTRAINT project_memberships_status_check CHECK (status IN ('invited', 'active'))
+);
+
+CREATE INDEX IF NOT EXISTS project_memberships_user_idx ON project_memberships (user_id);
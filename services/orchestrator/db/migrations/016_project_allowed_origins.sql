@@ -0,0 +1,22 @@
+ALTER TABLE projects
+  ADD COLUMN IF NOT EXISTS allowed_origins TEXT[] NOT NULL DEFAULT '{}';
+
+-- Seed from the project's site, reduced to scheme://host[:port] so sites
+-- stored with a path or trailing slash still match browser Origin headers.
+-- The default project stays unrestricted so local development keeps working
+-- until origins are configured explicitly.
+UPDATE projects
+SET allowed_origins = ARRAY[seeded.origin]
+FROM (
+  SELECT
+    id,
+    CASE
+      WHEN lower(btrim(site)) ~ '^https?://' THEN substring(lower(btrim(site)) FROM '^(https?://[^/?#]+)')
+      ELSE 'https://' || substring(lower(btrim(site)) FROM '^([^/?#]+)')
+    END AS origin
+  FROM projects
+) seeded
+WHERE projects.id = seeded.id
+  AND seeded.origin IS NOT NULL
+  AND projects.allowed_origins = '{}'
+  AND projects.id <> 'proj_default';
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS artifact_token_revocations (
+  token_id TEXT PRIMARY KEY,
+  project_id TEXT NOT NULL,
+  reason TEXT NOT NULL DEFAULT '',
+  revoked_by TEXT NOT NULL DEFAULT '',
+  expires_at TIMESTAMPTZ NOT NULL,
+  revoked_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS artifact_token_revocations_expires_at_idx
+  ON artifact_token_revocations (expires_at);
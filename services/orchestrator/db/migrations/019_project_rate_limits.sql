@@ -0,0 +1,5 @@
+-- Per-project overrides keyed by route class, e.g.
+-- {"ingest": {"requestsPerSec": 100, "burst": 200}}. Classes without an entry
+-- use the RATE_LIMIT_* defaults.
+ALTER TABLE projects
+  ADD COLUMN IF NOT EXISTS rate_limits JSONB NOT NULL DEFAULT '{}'::jsonb;
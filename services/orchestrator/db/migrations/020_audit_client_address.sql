@@ -0,0 +1,2 @@
+ALTER TABLE audit_events
+  ADD COLUMN IF NOT EXISTS client_address TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,18 @@
+-- First time each session reached a pipeline stage. Later retries never move
+-- a timestamp, so latencies measure the first successful pass.
+CREATE TABLE IF NOT EXISTS session_pipeline_timings (
+  project_id TEXT NOT NULL,
+  session_id TEXT NOT NULL,
+  ingested_at TIMESTAMPTZ NOT NULL,
+  analysis_enqueued_at TIMESTAMPTZ,
+  text_reported_at TIMESTAMPTZ,
+  replay_enqueued_at TIMESTAMPTZ,
+  replay_reported_at TIMESTAMPTZ,
+  verdict_at TIMESTAMPTZ,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (project_id, session_id),
+  FOREIGN KEY (project_id, session_id) REFERENCES sessions(project_id, id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS session_pipeline_timings_ingested_idx
+  ON session_pipeline_timings (project_id, ingested_at DESC);
@@ -0,0 +1,10 @@
+-- Service tier used to pick the queue lane for a project's analysis and
+-- replay jobs: priority projects move up a lane, bulk projects down one.
+ALTER TABLE projects
+  ADD COLUMN IF NOT EXISTS tier TEXT NOT NULL DEFAULT 'standard';
+
+ALTER TABLE projects
+  DROP CONSTRAINT IF EXISTS projects_tier_check;
+
+ALTER TABLE projects
+  ADD CONSTRAINT projects_tier_check CHECK (tier IN ('priority', 'standard', 'bulk'));
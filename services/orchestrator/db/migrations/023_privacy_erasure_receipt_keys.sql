@@ -0,0 +1,5 @@
+-- Receipts are HMAC-signed with a server-held key; record which one so
+-- receipts stay verifiable across key rotation. Receipts written before this
+-- column existed have no key ID and cannot be verified.
+ALTER TABLE privacy_erasure_jobs
+  ADD COLUMN IF NOT EXISTS receipt_key_id TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+-- Sessions under an active legal hold are skipped by privacy erasure and
+-- recorded here so the job shows what it could not erase.
+ALTER TABLE privacy_erasure_jobs
+  ADD COLUMN IF NOT EXISTS held_session_ids TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,8 @@
+-- Records the retrospec-retention tag last applied to a session's objects.
+-- Existing sessions start NULL: their objects predate tagging, so the retention
+-- tag sync backfills them. New sessions are written tagged for expiry.
+ALTER TABLE sessions
+  ADD COLUMN IF NOT EXISTS retention_tag TEXT;
+
+ALTER TABLE sessions
+  ALTER COLUMN retention_tag SET DEFAULT 'expire';
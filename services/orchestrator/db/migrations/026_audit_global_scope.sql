@@ -0,0 +1,5 @@
+-- Admin actions have no project and were stored with an empty project_id,
+-- which no listing could read back. Move them to the '_global' scope served
+-- by GET /v1/admin/audit, and default new rows there too.
+UPDATE audit_events SET project_id = '_global' WHERE project_id = '';
+ALTER TABLE audit_events ALTER COLUMN project_id SET DEFAULT '_global';
@@ -0,0 +1,303 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+
+	"retrospec/services/orchestrator/internal/store"
+)
+
+type createProjectRequest struct {
+	Name           string   `json:"name"`
+	Site           string   `json:"site"`
+	KeyLabel       string   `json:"keyLabel"`
+	KeyScopes      []string `json:"keyScopes"`
+	AllowedOrigins []string `json:"allowedOrigins"`
+}
+
+type createAPIKeyRequest struct {
+	Label     string   `json:"label"`
+	Scopes    []string `json:"scopes"`
+	ExpiresAt string   `json:"expiresAt"`
+}
+
+type rotateAPIKeyRequest struct {
+	GraceMinutes *int   `json:"graceMinutes"`
+	ExpiresAt    string `json:"expiresAt"`
+}
+
+// adminStore is the part of the Postgres store the admin project and key routes
+// use, so they can be tested without a database.
+type adminStore interface {
+	CreateProjectWithAPIKey(ctx context.Context, name, site, label, rawKey string, scopes, allowedOrigins []string) (store.Project, error)
+	ListProjects(ctx context.Context) ([]store.Project, error)
+	GetProject(ctx context.Context, projectID string) (store.Project, error)
+	CreateAPIKeyForProject(ctx context.Context, projectID, label, rawKey string, scopes []string, expiresAt *time.Time) (store.ProjectAPIKey, error)
+	ListProjectAPIKeys(ctx context.Context, projectID string) ([]store.ProjectAPIKey, error)
+	RevokeProjectAPIKey(ctx context.Context, projectID, keyID string) (store.ProjectAPIKey, error)
+	RotateProjectAPIKey(
+		ctx context.Context,
+		projectID string,
+		keyID string,
+		rawKey string,
+		grace time.Duration,
+		successorExpiresAt *time.Time,
+	) (store.ProjectAPIKey, store.ProjectAPIKey, error)
+}
+
+func (h *Handler) requireAdminAccess(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.TrimSpace(h.adminAPIKey) == "" {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "admin endpoints disabled"})
+			return
+		}
+
+		provided := strings.TrimSpace(r.Header.Get("X-Retrospec-Admin"))
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(h.adminAPIKey)) == 1 {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), adminAuthContext, true)))
+			return
+		}
+
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	})
+}
+
+func (h *Handler) createProject(w http.ResponseWriter, r *http.Request) {
+	payload := createProjectRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid payload"})
+		return
+	}
+	if strings.TrimSpace(payload.Name) == "" || strings.TrimSpace(payload.Site) == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "name and site are required"})
+		return
+	}
+	scopes, err := store.NormalizeAPIKeyScopes(payload.KeyScopes)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	origins, err := store.NormalizeAllowedOrigins(payload.AllowedOrigins)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	rawKey, err := generateAPIKey()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "key generation failed"})
+		return
+	}
+
+	label := firstNonEmpty(payload.KeyLabel, "default")
+	project, err := h.adminStore.CreateProjectWithAPIKey(r.Context(), payload.Name, payload.Site, label, rawKey, scopes, origins)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "project creation failed"})
+		return
+	}
+
+	keys, err := h.adminStore.ListProjectAPIKeys(r.Context(), project.ID)
+	if err != nil || len(keys) == 0 {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "project key lookup failed"})
+		return
+	}
+
+	if h.originCache != nil {
+		h.originCache.invalidate()
+	}
+	annotateAuditTarget(r.Context(), "project", project.ID)
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"project": project,
+		"apiKey":  keys[0],
+		"rawKey":  rawKey,
+	})
+}
+
+func (h *Handler) listProjects(w http.ResponseWriter, r *http.Request) {
+	projects, err := h.adminStore.ListProjects(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "project lookup failed"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"projects": projects})
+}
+
+func (h *Handler) createProjectAPIKey(w http.ResponseWriter, r *http.Request) {
+	payload := createAPIKeyRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid payload"})
+		return
+	}
+	if strings.TrimSpace(payload.Label) == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "label is required"})
+		return
+	}
+	scopes, err := store.NormalizeAPIKeyScopes(payload.Scopes)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	expiresAt, err := parseOptionalFutureTime(payload.ExpiresAt)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "expiresAt " + err.Error()})
+		return
+	}
+
+	project, ok := h.loadAdminProject(w, r)
+	if !ok {
+		return
+	}
+
+	rawKey, err := generateAPIKey()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "key generation failed"})
+		return
+	}
+
+	apiKey, err := h.adminStore.CreateAPIKeyForProject(r.Context(), project.ID, payload.Label, rawKey, scopes, expiresAt)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "key creation failed"})
+		return
+	}
+
+	annotateAuditTarget(r.Context(), "api_key", apiKey.ID)
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"apiKey": apiKey,
+		"rawKey": rawKey,
+	})
+}
+
+func (h *Handler) listProjectAPIKeys(w http.ResponseWriter, r *http.Request) {
+	project, ok := h.loadAdminProject(w, r)
+	if !ok {
+		return
+	}
+
+	keys, err := h.adminStore.ListProjectAPIKeys(r.Context(), project.ID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "key lookup failed"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"projectId": project.ID,
+		"keys":      keys,
+	})
+}
+
+func (h *Handler) revokeProjectAPIKey(w http.ResponseWriter, r *http.Request) {
+	projectID := strings.TrimSpace(chi.URLParam(r, "projectID"))
+	keyID := strings.TrimSpace(chi.URLParam(r, "keyID"))
+
+	apiKey, err := h.adminStore.RevokeProjectAPIKey(r.Context(), projectID, keyID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "api key not found"})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "key revocation failed"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"apiKey": apiKey})
+}
+
+func (h *Handler) rotateProjectAPIKey(w http.ResponseWriter, r *http.Request) {
+	payload := rotateAPIKeyRequest{}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid payload"})
+			return
+		}
+	}
+
+	grace := h.apiKeyRotationGrace
+	if payload.GraceMinutes != nil {
+		if *payload.GraceMinutes < 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "graceMinutes must be >= 0"})
+			return
+		}
+		grace = time.Duration(*payload.GraceMinutes) * time.Minute
+	}
+	expiresAt, err := parseOptionalFutureTime(payload.ExpiresAt)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "expiresAt " + err.Error()})
+		return
+	}
+
+	rawKey, err := generateAPIKey()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "key generation failed"})
+		return
+	}
+
+	successor, predecessor, err := h.adminStore.RotateProjectAPIKey(
+		r.Context(),
+		chi.URLParam(r, "projectID"),
+		chi.URLParam(r, "keyID"),
+		rawKey,
+		grace,
+		expiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "active api key not found"})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "key rotation failed"})
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"apiKey":      successor,
+		"rawKey":      rawKey,
+		"predecessor": predecessor,
+	})
+}
+
+func (h *Handler) loadAdminProject(w http.ResponseWriter, r *http.Request) (store.Project, bool) {
+	project, err := h.adminStore.GetProject(r.Context(), chi.URLParam(r, "projectID"))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "project not found"})
+			return store.Project{}, false
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "project lookup failed"})
+		return store.Project{}, false
+	}
+	return project, true
+}
+
+func parseOptionalFutureTime(value string) (*time.Time, error) {
+	candidate := strings.TrimSpace(value)
+	if candidate == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339Nano, candidate)
+	if err != nil {
+		return nil, errors.New("must be RFC3339 timestamp")
+	}
+	if !parsed.After(time.Now()) {
+		return nil, errors.New("must be in the future")
+	}
+	return &parsed, nil
+}
+
+func generateAPIKey() (string, error) {
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return "rsk_" + hex.EncodeToString(raw), nil
+}
@@ -0,0 +1,225 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+
+	"retrospec/services/orchestrator/internal/store"
+)
+
+type fakeAdminStore struct {
+	projects []store.Project
+	keys     map[string][]store.ProjectAPIKey
+	calls    int
+}
+
+func newFakeAdminStore() *fakeAdminStore {
+	return &fakeAdminStore{keys: map[string][]store.ProjectAPIKey{}}
+}
+
+func (f *fakeAdminStore) CreateProjectWithAPIKey(
+	_ context.Context,
+	name, site, label, _ string,
+	scopes, allowedOrigins []string,
+) (store.Project, error) {
+	f.calls++
+	project := store.Project{
+		ID:             fmt.Sprintf("proj_%d", len(f.projects)+1),
+		Name:           name,
+		Site:           site,
+		AllowedOrigins: allowedOrigins,
+		CreatedAt:      time.Now(),
+	}
+	f.projects = append(f.projects, project)
+	f.addKey(project.ID, label, scopes, nil)
+	return project, nil
+}
+
+func (f *fakeAdminStore) ListProjects(context.Context) ([]store.Project, error) {
+	f.calls++
+	return f.projects, nil
+}
+
+func (f *fakeAdminStore) GetProject(_ context.Context, projectID string) (store.Project, error) {
+	f.calls++
+	for _, project := range f.projects {
+		if project.ID == projectID {
+			return project, nil
+		}
+	}
+	return store.Project{}, pgx.ErrNoRows
+}
+
+func (f *fakeAdminStore) CreateAPIKeyForProject(
+	_ context.Context,
+	projectID, label, _ string,
+	scopes []string,
+	expiresAt *time.Time,
+) (store.ProjectAPIKey, error) {
+	f.calls++
+	return f.addKey(projectID, label, scopes, expiresAt), nil
+}
+
+func (f *fakeAdminStore) ListProjectAPIKeys(_ context.Context, projectID string) ([]store.ProjectAPIKey, error) {
+	f.calls++
+	return f.keys[projectID], nil
+}
+
+func (f *fakeAdminStore) RevokeProjectAPIKey(_ context.Context, projectID, keyID string) (store.ProjectAPIKey, error) {
+	f.calls++
+	for index, apiKey := range f.keys[projectID] {
+		if apiKey.ID == keyID {
+			f.keys[projectID][index].Status = "revoked"
+			return f.keys[projectID][index], nil
+		}
+	}
+	return store.ProjectAPIKey{}, pgx.ErrNoRows
+}
+
+func (f *fakeAdminStore) RotateProjectAPIKey(
+	context.Context,
+	string,
+	string,
+	string,
+	time.Duration,
+	*time.Time,
+) (store.ProjectAPIKey, store.ProjectAPIKey, error) {
+	f.calls++
+	return store.ProjectAPIKey{}, store.ProjectAPIKey{}, pgx.ErrNoRows
+}
+
+func (f *fakeAdminStore) addKey(projectID, label string, scopes []string, expiresAt *time.Time) store.ProjectAPIKey {
+	apiKey := store.ProjectAPIKey{
+		ID:        fmt.Sprintf("key_%d", len(f.keys[projectID])+1),
+		ProjectID: projectID,
+		Label:     label,
+		Status:    "active",
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+	f.keys[projectID] = append(f.keys[projectID], apiKey)
+	return apiKey
+}
+
+func newAdminTestRouter(h *Handler) http.Handler {
+	router := chi.NewRouter()
+	router.Route("/admin", func(r chi.Router) {
+		r.Use(h.requireAdminAccess)
+		r.Post("/projects", h.createProject)
+		r.Get("/projects", h.listProjects)
+		r.Post("/projects/{projectID}/keys", h.createProjectAPIKey)
+		r.Get("/projects/{projectID}/keys", h.listProjectAPIKeys)
+		r.Post("/projects/{projectID}/keys/{keyID}/revoke", h.revokeProjectAPIKey)
+	})
+	return router
+}
+
+func sendAdmin(router http.Handler, method, path, adminKey, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if adminKey != "" {
+		req.Header.Set("X-Retrospec-Admin", adminKey)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestAdminRoutesRejectMissingOrWrongAdminKey(t *testing.T) {
+	fake := newFakeAdminStore()
+	router := newAdminTestRouter(&Handler{adminAPIKey: "admin-secret", adminStore: fake})
+
+	for _, adminKey := range []string{"", "wrong-secret", "admin-secret-but-longer"} {
+		if recorder := sendAdmin(router, http.MethodGet, "/admin/projects", adminKey, ""); recorder.Code != http.StatusUnauthorized {
+			t.Fatalf("expected admin key %q to be rejected, got %d", adminKey, recorder.Code)
+		}
+		body := `{"name":"Shop","site":"https://shop.example.com"}`
+		if recorder := sendAdmin(router, http.MethodPost, "/admin/projects", adminKey, body); recorder.Code != http.StatusUnauthorized {
+			t.Fatalf("expected project creation with admin key %q to be rejected, got %d", adminKey, recorder.Code)
+		}
+	}
+	if fake.calls != 0 {
+		t.Fatalf("expected rejected requests not to reach the store, got %d calls", fake.calls)
+	}
+
+	disabled := newAdminTestRouter(&Handler{adminStore: fake})
+	if recorder := sendAdmin(disabled, http.MethodGet, "/admin/projects", "admin-secret", ""); recorder.Code != http.StatusForbidden {
+		t.Fatalf("expected admin routes to be disabled without ADMIN_API_KEY, got %d", recorder.Code)
+	}
+}
+
+func TestAdminRoutesCreateListAndRevokeKeys(t *testing.T) {
+	fake := newFakeAdminStore()
+	router := newAdminTestRouter(&Handler{adminAPIKey: "admin-secret", adminStore: fake})
+
+	if recorder := sendAdmin(router, http.MethodPost, "/admin/projects", "admin-secret", `{"name":"Shop"}`); recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected project without site to be rejected, got %d", recorder.Code)
+	}
+
+	recorder := sendAdmin(router, http.MethodPost, "/admin/projects", "admin-secret", `{"name":"Shop","site":"https://shop.example.com"}`)
+	created := struct {
+		Project store.Project       `json:"project"`
+		APIKey  store.ProjectAPIKey `json:"apiKey"`
+		RawKey  string              `json:"rawKey"`
+	}{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &created); err != nil || recorder.Code != http.StatusCreated {
+		t.Fatalf("create project failed: %d %s err=%v", recorder.Code, recorder.Body.String(), err)
+	}
+	if !strings.HasPrefix(created.RawKey, "rsk_") || created.APIKey.ProjectID != created.Project.ID {
+		t.Fatalf("unexpected created project %+v key=%+v raw=%q", created.Project, created.APIKey, created.RawKey)
+	}
+	if len(created.APIKey.Scopes) != 1 || created.APIKey.Scopes[0] != store.APIKeyScopeIngest {
+		t.Fatalf("expected default key to be ingest-only, got %v", created.APIKey.Scopes)
+	}
+
+	recorder = sendAdmin(router, http.MethodGet, "/admin/projects", "admin-secret", "")
+	listedProjects := struct {
+		Projects []store.Project `json:"projects"`
+	}{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &listedProjects); err != nil || len(listedProjects.Projects) != 1 {
+		t.Fatalf("list projects failed: %d %s err=%v", recorder.Code, recorder.Body.String(), err)
+	}
+
+	keysPath := "/admin/projects/" + created.Project.ID + "/keys"
+	recorder = sendAdmin(router, http.MethodPost, keysPath, "admin-secret", `{"label":"ci","scopes":["read"]}`)
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("create key failed: %d %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder := sendAdmin(router, http.MethodPost, keysPath, "admin-secret", `{"label":"ci","scopes":["owner"]}`); recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected unknown scope to be rejected, got %d", recorder.Code)
+	}
+	if recorder := sendAdmin(router, http.MethodPost, "/admin/projects/proj_missing/keys", "admin-secret", `{"label":"ci"}`); recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected key for unknown project to be rejected, got %d", recorder.Code)
+	}
+
+	recorder = sendAdmin(router, http.MethodPost, keysPath+"/key_2/revoke", "admin-secret", "")
+	revoked := struct {
+		APIKey store.ProjectAPIKey `json:"apiKey"`
+	}{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &revoked); err != nil || recorder.Code != http.StatusOK || revoked.APIKey.Status != "revoked" {
+		t.Fatalf("revoke failed: %d %s err=%v", recorder.Code, recorder.Body.String(), err)
+	}
+	if recorder := sendAdmin(router, http.MethodPost, keysPath+"/key_missing/revoke", "admin-secret", ""); recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected unknown key revoke to return 404, got %d", recorder.Code)
+	}
+
+	recorder = sendAdmin(router, http.MethodGet, keysPath, "admin-secret", "")
+	listedKeys := struct {
+		Keys []store.ProjectAPIKey `json:"keys"`
+	}{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &listedKeys); err != nil || len(listedKeys.Keys) != 2 {
+		t.Fatalf("list keys failed: %d %s err=%v", recorder.Code, recorder.Body.String(), err)
+	}
+	if listedKeys.Keys[0].Status != "active" || listedKeys.Keys[1].Status != "revoked" {
+		t.Fatalf("unexpected key statuses %+v", listedKeys.Keys)
+	}
+}
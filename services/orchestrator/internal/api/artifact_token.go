@@ -1,70 +1,103 @@
 package api
 
 import (
+	"context"
 	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"log/slog"
+	"net/http"
 	"strings"
 	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"retrospec/services/orchestrator/internal/logging"
 )
 
-var errInvalidArtifactToken = errors.New("invalid artifact token")
+var (
+	errInvalidArtifactToken  = errors.New("invalid artifact token")
+	errRevokedArtifactToken  = errors.New("artifact token revoked")
+	errRedeemedArtifactToken = errors.New("artifact token already used")
+)
 
 type artifactTokenClaims struct {
+	ID           string `json:"jti"`
 	ProjectID    string `json:"projectId"`
 	SessionID    string `json:"sessionId"`
 	ArtifactType string `json:"artifactType"`
 	ExpiresAt    int64  `json:"exp"`
+	SingleUse    bool   `json:"once,omitempty"`
+}
+
+type revokeArtifactTokenRequest struct {
+	Token     string `json:"token"`
+	TokenID   string `json:"tokenId"`
+	ExpiresAt string `json:"expiresAt"`
+	Reason    string `json:"reason"`
 }
 
 func (h *Handler) hasArtifactTokenSecret() bool {
-	return strings.TrimSpace(h.artifactTokenSecret) != ""
+	_, ok := h.artifactTokenKeys[h.artifactTokenActiveKeyID]
+	return ok
 }
 
+// signArtifactToken produces kid.payload.signature, signed with the active key
+// so earlier keys can stay in the keyring until their tokens expire.
 func (h *Handler) signArtifactToken(
 	projectID, sessionID, artifactType string,
 	expiresAt time.Time,
-) (string, error) {
+	singleUse bool,
+) (string, artifactTokenClaims, error) {
 	if !h.hasArtifactTokenSecret() {
-		return "", errInvalidArtifactToken
+		return "", artifactTokenClaims{}, errInvalidArtifactToken
+	}
+
+	tokenID := make([]byte, 16)
+	if _, err := rand.Read(tokenID); err != nil {
+		return "", artifactTokenClaims{}, err
 	}
 
 	claims := artifactTokenClaims{
+		ID:           "atk_" + hex.EncodeToString(tokenID),
 		ProjectID:    strings.TrimSpace(projectID),
 		SessionID:    strings.TrimSpace(sessionID),
 		ArtifactType: strings.TrimSpace(artifactType),
 		ExpiresAt:    expiresAt.UTC().Unix(),
+		SingleUse:    singleUse,
 	}
 	payload, err := json.Marshal(claims)
 	if err != nil {
-		return "", err
+		return "", artifactTokenClaims{}, err
 	}
 
-	encodedPayload := base64.RawURLEncoding.EncodeToString(payload)
-	signature := h.signArtifactPayload(encodedPayload)
-	return encodedPayload + "." + signature, nil
+	keyID := h.artifactTokenActiveKeyID
+	signingInput := keyID + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signingInput + "." + signArtifactPayload(h.artifactTokenKeys[keyID], signingInput), claims, nil
 }
 
-func (h *Handler) verifyArtifactToken(rawToken string) (artifactTokenClaims, error) {
-	if !h.hasArtifactTokenSecret() {
+// decodeArtifactToken checks the signature against the token's key ID and the
+// expiry; revocation and single-use state are checked by verifyArtifactToken.
+func (h *Handler) decodeArtifactToken(rawToken string) (artifactTokenClaims, error) {
+	parts := strings.Split(strings.TrimSpace(rawToken), ".")
+	if len(parts) != 3 {
 		return artifactTokenClaims{}, errInvalidArtifactToken
 	}
 
-	parts := strings.Split(strings.TrimSpace(rawToken), ".")
-	if len(parts) != 2 {
+	secret, ok := h.artifactTokenKeys[parts[0]]
+	if !ok {
 		return artifactTokenClaims{}, errInvalidArtifactToken
 	}
-
-	encodedPayload := parts[0]
-	signature := parts[1]
-	expected := h.signArtifactPayload(encodedPayload)
-	if !hmac.Equal([]byte(signature), []byte(expected)) {
+	signingInput := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(signArtifactPayload(secret, signingInput))) {
 		return artifactTokenClaims{}, errInvalidArtifactToken
 	}
 
-	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
 	if err != nil {
 		return artifactTokenClaims{}, errInvalidArtifactToken
 	}
@@ -74,7 +107,7 @@ func (h *Handler) verifyArtifactToken(rawToken string) (artifactTokenClaims, err
 		return artifactTokenClaims{}, errInvalidArtifactToken
 	}
 
-	if claims.ProjectID == "" || claims.SessionID == "" || claims.ArtifactType == "" {
+	if claims.ID == "" || claims.ProjectID == "" || claims.SessionID == "" || claims.ArtifactType == "" {
 		return artifactTokenClaims{}, errInvalidArtifactToken
 	}
 	if claims.ExpiresAt < time.Now().UTC().Unix() {
@@ -84,8 +117,104 @@ func (h *Handler) verifyArtifactToken(rawToken string) (artifactTokenClaims, err
 	return claims, nil
 }
 
-func (h *Handler) signArtifactPayload(encodedPayload string) string {
-	mac := hmac.New(sha256.New, []byte(h.artifactTokenSecret))
-	mac.Write([]byte(encodedPayload))
+func (h *Handler) verifyArtifactToken(ctx context.Context, rawToken string) (artifactTokenClaims, error) {
+	claims, err := h.decodeArtifactToken(rawToken)
+	if err != nil {
+		return artifactTokenClaims{}, err
+	}
+
+	revoked, err := h.store.IsArtifactTokenRevoked(ctx, claims.ID)
+	if err != nil {
+		return artifactTokenClaims{}, err
+	}
+	if revoked {
+		return artifactTokenClaims{}, errRevokedArtifactToken
+	}
+
+	if claims.SingleUse {
+		ttl := time.Until(time.Unix(claims.ExpiresAt, 0)) + time.Minute
+		fresh, err := h.nonces.RememberNonce(ctx, "artifact-token:"+claims.ID, ttl)
+		if err != nil {
+			return artifactTokenClaims{}, err
+		}
+		if !fresh {
+			return artifactTokenClaims{}, errRedeemedArtifactToken
+		}
+	}
+
+	return claims, nil
+}
+
+func (h *Handler) revokeArtifactToken(w http.ResponseWriter, r *http.Request) {
+	payload := revokeArtifactTokenRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid payload"})
+		return
+	}
+
+	projectID := h.projectIDFromContext(r.Context())
+	tokenID := strings.TrimSpace(payload.TokenID)
+	expiresAt := time.Now().UTC().Add(h.artifactTokenTTL)
+
+	if strings.TrimSpace(payload.Token) != "" {
+		claims, err := h.decodeArtifactToken(payload.Token)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "token is invalid or already expired"})
+			return
+		}
+		if claims.ProjectID != projectID {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "token not found"})
+			return
+		}
+		tokenID = claims.ID
+		expiresAt = time.Unix(claims.ExpiresAt, 0).UTC()
+	} else if tokenID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "token or tokenId is required"})
+		return
+	} else if strings.TrimSpace(payload.ExpiresAt) != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(payload.ExpiresAt))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "expiresAt must be RFC3339 timestamp"})
+			return
+		}
+		expiresAt = parsed
+	}
+
+	revocation, err := h.store.RevokeArtifactToken(
+		r.Context(),
+		projectID,
+		tokenID,
+		expiresAt,
+		payload.Reason,
+		h.actorFromContext(r.Context()),
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "token not found"})
+			return
+		}
+		slog.ErrorContext(r.Context(), "artifact token revocation failed", slog.String(logging.ProjectIDKey, projectID), slog.String("token_id", tokenID), logging.Err(err))
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "token revocation failed"})
+		return
+	}
+
+	annotateAuditTarget(r.Context(), "artifact_token", revocation.TokenID)
+	writeJSON(w, http.StatusOK, map[string]any{"revocation": revocation})
+}
+
+func (h *Handler) artifactTokenSingleUse(r *http.Request) bool {
+	switch strings.ToLower(strings.TrimSpace(r.URL.Query().Get("singleUse"))) {
+	case "true", "1":
+		return true
+	case "false", "0":
+		return false
+	default:
+		return h.artifactTokenSingleUseDefault
+	}
+}
+
+func signArtifactPayload(secret []byte, signingInput string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signingInput))
 	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
 }
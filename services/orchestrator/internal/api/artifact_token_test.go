@@ -3,20 +3,23 @@ package api
 import (
 	"testing"
 	"time"
+
+	"retrospec/services/orchestrator/internal/auth"
 )
 
 func TestArtifactTokenRoundTrip(t *testing.T) {
 	handler := &Handler{
-		artifactTokenSecret: "test-secret",
+		artifactTokenKeys:        auth.SigningKeys{"k1": []byte("test-secret")},
+		artifactTokenActiveKeyID: "k1",
 	}
 
 	expiresAt := time.Now().UTC().Add(2 * time.Minute)
-	token, err := handler.signArtifactToken("proj_abc", "session_1", "replay_video", expiresAt)
+	token, _, err := handler.signArtifactToken("proj_abc", "session_1", "replay_video", expiresAt, false)
 	if err != nil {
 		t.Fatalf("expected token to be generated: %v", err)
 	}
 
-	claims, err := handler.verifyArtifactToken(token)
+	claims, err := handler.decodeArtifactToken(token)
 	if err != nil {
 		t.Fatalf("expected token to verify: %v", err)
 	}
@@ -28,20 +31,49 @@ func TestArtifactTokenRoundTrip(t *testing.T) {
 
 func TestArtifactTokenRejectsExpired(t *testing.T) {
 	handler := &Handler{
-		artifactTokenSecret: "test-secret",
+		artifactTokenKeys:        auth.SigningKeys{"k1": []byte("test-secret")},
+		artifactTokenActiveKeyID: "k1",
 	}
 
-	token, err := handler.signArtifactToken(
+	token, _, err := handler.signArtifactToken(
 		"proj_abc",
 		"session_1",
 		"replay_video",
 		time.Now().UTC().Add(-1*time.Minute),
+		false,
 	)
 	if err != nil {
 		t.Fatalf("expected token to be generated: %v", err)
 	}
 
-	if _, err := handler.verifyArtifactToken(token); err == nil {
+	if _, err := handler.decodeArtifactToken(token); err == nil {
 		t.Fatal("expected expired token to fail verification")
 	}
 }
+
+func TestArtifactTokenVerifiesWithRotatedKeyring(t *testing.T) {
+	signer := &Handler{
+		artifactTokenKeys:        auth.SigningKeys{"k1": []byte("old-secret")},
+		artifactTokenActiveKeyID: "k1",
+	}
+	token, _, err := signer.signArtifactToken("proj_abc", "session_1", "replay_video", time.Now().Add(time.Minute), false)
+	if err != nil {
+		t.Fatalf("expected token to be generated: %v", err)
+	}
+
+	rotated := &Handler{
+		artifactTokenKeys:        auth.SigningKeys{"k1": []byte("old-secret"), "k2": []byte("new-secret")},
+		artifactTokenActiveKeyID: "k2",
+	}
+	if _, err := rotated.decodeArtifactToken(token); err != nil {
+		t.Fatalf("expected token signed by retired key to verify: %v", err)
+	}
+
+	retired := &Handler{
+		artifactTokenKeys:        auth.SigningKeys{"k2": []byte("new-secret")},
+		artifactTokenActiveKeyID: "k2",
+	}
+	if _, err := retired.decodeArtifactToken(token); err == nil {
+		t.Fatal("expected token to fail once its key is removed")
+	}
+}
@@ -0,0 +1,246 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"retrospec/services/orchestrator/internal/logging"
+	"retrospec/services/orchestrator/internal/store"
+)
+
+const (
+	auditOutcomeSuccess = "success"
+	auditOutcomeDenied  = "denied"
+	auditOutcomeFailure = "failure"
+
+	auditEntryContext = requestContextKey("audit_entry")
+
+	auditExportPageSize      = 1000
+	auditExportStatusTrailer = "X-Retrospec-Export-Status"
+)
+
+type auditEntry struct {
+	targetType string
+	targetID   string
+}
+
+// audit records one audit event per request once the wrapped handler returns.
+// It should sit in front of scope checks so denied attempts are recorded too.
+func (h *Handler) audit(action, targetType, targetParam string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			entry := &auditEntry{targetType: targetType}
+			if targetParam != "" {
+				entry.targetID = strings.TrimSpace(chi.URLParam(r, targetParam))
+			}
+
+			recorder := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(recorder, r.WithContext(context.WithValue(r.Context(), auditEntryContext, entry)))
+
+			event := h.auditEvent(r, action, entry, recorder.Status())
+			if err := h.store.InsertAuditEvent(context.WithoutCancel(r.Context()), event); err != nil {
+				slog.ErrorContext(r.Context(), "audit event write failed", slog.String("action", action), logging.Err(err))
+			}
+		})
+	}
+}
+
+// annotateAuditTarget lets handlers name the target of an audited action when
+// it is only known after the handler runs, e.g. the ID of a created record.
+func annotateAuditTarget(ctx context.Context, targetType, targetID string) {
+	entry, ok := ctx.Value(auditEntryContext).(*auditEntry)
+	if !ok {
+		return
+	}
+	entry.targetType = targetType
+	entry.targetID = targetID
+}
+
+func (h *Handler) auditEvent(r *http.Request, action string, entry *auditEntry, status int) store.AuditEvent {
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	projectID, _ := r.Context().Value(projectIDContextKey).(string)
+	if projectID == "" {
+		projectID = chi.URLParam(r, "projectID")
+	}
+	if projectID == "" {
+		projectID = store.GlobalAuditProjectID
+	}
+
+	return store.AuditEvent{
+		ProjectID:  projectID,
+		Actor:      h.actorFromContext(r.Context()),
+		Action:     action,
+		TargetType: entry.targetType,
+		TargetID:   entry.targetID,
+		RequestID:  middleware.GetReqID(r.Context()),
+		Outcome:    auditOutcome(status),
+		StatusCode: status,
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		ClientAddr: clientAddress(r),
+	}
+}
+
+func auditOutcome(status int) string {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return auditOutcomeDenied
+	case status >= 400:
+		return auditOutcomeFailure
+	default:
+		return auditOutcomeSuccess
+	}
+}
+
+func (h *Handler) listAuditEvents(w http.ResponseWriter, r *http.Request) {
+	h.serveAuditEvents(w, r, h.projectIDFromContext(r.Context()))
+}
+
+// listGlobalAuditEvents lists events recorded outside any project, such as
+// project creation, config reloads and dead-letter redrives.
+func (h *Handler) listGlobalAuditEvents(w http.ResponseWriter, r *http.Request) {
+	h.serveAuditEvents(w, r, store.GlobalAuditProjectID)
+}
+
+func (h *Handler) serveAuditEvents(w http.ResponseWriter, r *http.Request, projectID string) {
+	query := r.URL.Query()
+	filter := store.AuditEventFilter{
+		ProjectID: projectID,
+		Action:    query.Get("action"),
+		Actor:     query.Get("actor"),
+		TargetID:  query.Get("target"),
+		Outcome:   query.Get("outcome"),
+		Limit:     100,
+	}
+
+	switch filter.Outcome {
+	case "", auditOutcomeSuccess, auditOutcomeDenied, auditOutcomeFailure:
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "outcome must be success, denied or failure"})
+		return
+	}
+
+	for name, target := range map[string]**time.Time{"since": &filter.Since, "until": &filter.Until} {
+		raw := strings.TrimSpace(query.Get(name))
+		if raw == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": name + " must be RFC3339 timestamp"})
+			return
+		}
+		*target = &parsed
+	}
+
+	if raw := strings.TrimSpace(query.Get("before")); raw != "" {
+		beforeID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || beforeID < 1 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "before must be a positive event id"})
+			return
+		}
+		filter.BeforeID = beforeID
+	}
+
+	maxEvents := 0
+	if raw := strings.TrimSpace(query.Get("limit")); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "limit must be a positive integer"})
+			return
+		}
+		filter.Limit = limit
+		maxEvents = limit
+	}
+
+	if wantsNDJSON(r) {
+		exportAuditEvents(r.Context(), w, filter, maxEvents, h.auditEvents)
+		return
+	}
+
+	events, err := h.auditEvents(r.Context(), filter)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "audit event lookup failed", slog.String(logging.ProjectIDKey, filter.ProjectID), logging.Err(err))
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "audit lookup failed"})
+		return
+	}
+
+	response := map[string]any{"events": events}
+	if len(events) > 0 && len(events) == filter.Limit {
+		response["nextBefore"] = events[len(events)-1].ID
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+type auditEventLister func(context.Context, store.AuditEventFilter) ([]store.AuditEvent, error)
+
+// exportAuditEvents streams every event matching filter as NDJSON, paging by
+// event ID so compliance exports are not cut off at one page. A positive
+// maxEvents caps the export. The X-Retrospec-Export-Status trailer reads
+// "complete" only when every page was written.
+func exportAuditEvents(ctx context.Context, w http.ResponseWriter, filter store.AuditEventFilter, maxEvents int, list auditEventLister) {
+	pageSize := func(written int) int {
+		if maxEvents > 0 {
+			return min(auditExportPageSize, maxEvents-written)
+		}
+		return auditExportPageSize
+	}
+
+	filter.Limit = pageSize(0)
+	events, err := list(ctx, filter)
+	if err != nil {
+		slog.ErrorContext(ctx, "audit event lookup failed", slog.String(logging.ProjectIDKey, filter.ProjectID), logging.Err(err))
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "audit lookup failed"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit-`+filter.ProjectID+`.ndjson"`)
+	w.Header().Set("Trailer", auditExportStatusTrailer)
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set(auditExportStatusTrailer, "failed")
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+	written := 0
+	for {
+		for _, event := range events {
+			if err := encoder.Encode(event); err != nil {
+				return
+			}
+		}
+		written += len(events)
+		if len(events) < filter.Limit || (maxEvents > 0 && written >= maxEvents) {
+			w.Header().Set(auditExportStatusTrailer, "complete")
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		filter.BeforeID = events[len(events)-1].ID
+		filter.Limit = pageSize(written)
+		events, err = list(ctx, filter)
+		if err != nil {
+			slog.ErrorContext(ctx, "audit export page lookup failed", slog.String(logging.ProjectIDKey, filter.ProjectID), slog.Int("events_written", written), logging.Err(err))
+			return
+		}
+	}
+}
+
+func wantsNDJSON(r *http.Request) bool {
+	if strings.EqualFold(strings.TrimSpace(r.URL.Query().Get("format")), "ndjson") {
+		return true
+	}
+	return strings.Contains(r.Header.Get("Accept"), "application/x-ndjson")
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"retrospec/services/orchestrator/internal/store"
+)
+
+func TestAuditOutcomeClassifiesStatus(t *testing.T) {
+	cases := map[int]string{
+		http.StatusOK:                  auditOutcomeSuccess,
+		http.StatusCreated:             auditOutcomeSuccess,
+		http.StatusUnauthorized:        auditOutcomeDenied,
+		http.StatusForbidden:           auditOutcomeDenied,
+		http.StatusNotFound:            auditOutcomeFailure,
+		http.StatusInternalServerError: auditOutcomeFailure,
+	}
+	for status, expected := range cases {
+		if outcome := auditOutcome(status); outcome != expected {
+			t.Fatalf("status %d: expected %s, got %s", status, expected, outcome)
+		}
+	}
+}
+
+func TestAuditEventRecordsKeyActorAndAnnotatedTarget(t *testing.T) {
+	handler := &Handler{}
+	req := httptest.NewRequest(http.MethodPost, "/v1/legal-holds", nil)
+	ctx := context.WithValue(req.Context(), middleware.RequestIDKey, "req-1")
+	ctx = context.WithValue(ctx, projectIDContextKey, "proj_a")
+	ctx = context.WithValue(ctx, keyAuthenticatedContext, true)
+	ctx = context.WithValue(ctx, apiKeyIDContext, "key_1")
+	entry := &auditEntry{}
+	ctx = context.WithValue(ctx, auditEntryContext, entry)
+	req = req.WithContext(ctx)
+
+	annotateAuditTarget(req.Context(), "legal_hold", "hold_1")
+	event := handler.auditEvent(req, "legal_hold.create", entry, 0)
+
+	if event.Actor != "api_key:key_1" || event.ProjectID != "proj_a" || event.RequestID != "req-1" {
+		t.Fatalf("unexpected event identity: %+v", event)
+	}
+	if event.TargetType != "legal_hold" || event.TargetID != "hold_1" {
+		t.Fatalf("expected annotated target, got %+v", event)
+	}
+	if event.StatusCode != http.StatusOK || event.Outcome != auditOutcomeSuccess {
+		t.Fatalf("expected implicit 200 success, got %+v", event)
+	}
+}
+
+func TestExportAuditEventsPagesThroughEveryMatchingEvent(t *testing.T) {
+	const total = 2500
+	failAfter := 0
+	calls := 0
+	list := func(_ context.Context, filter store.AuditEventFilter) ([]store.AuditEvent, error) {
+		calls++
+		if failAfter > 0 && calls > failAfter {
+			return nil, errors.New("connection reset")
+		}
+		next := int64(total)
+		if filter.BeforeID > 0 {
+			next = filter.BeforeID - 1
+		}
+		events := []store.AuditEvent{}
+		for id := next; id > 0 && len(events) < filter.Limit; id-- {
+			events = append(events, store.AuditEvent{ID: id, ProjectID: filter.ProjectID})
+		}
+		return events, nil
+	}
+	export := func(maxEvents int) (int, string) {
+		recorder := httptest.NewRecorder()
+		exportAuditEvents(context.Background(), recorder, store.AuditEventFilter{ProjectID: "proj_a"}, maxEvents, list)
+		result := recorder.Result()
+		return bytes.Count(recorder.Body.Bytes(), []byte("\n")), result.Trailer.Get(auditExportStatusTrailer)
+	}
+
+	if lines, status := export(0); lines != total || status != "complete" {
+		t.Fatalf("expected all %d events and a complete trailer, got %d lines status=%q", total, lines, status)
+	}
+	if lines, status := export(1200); lines != 1200 || status != "complete" {
+		t.Fatalf("expected limit to cap the export, got %d lines status=%q", lines, status)
+	}
+
+	calls, failAfter = 0, 1
+	if lines, status := export(0); lines != auditExportPageSize || status != "failed" {
+		t.Fatalf("expected a failed page to mark the export incomplete, got %d lines status=%q", lines, status)
+	}
+}
+
+func TestAdminAuditListingReturnsConfigReloadEvents(t *testing.T) {
+	recorded := []store.AuditEvent{}
+	handler := &Handler{
+		auditEvents: func(_ context.Context, filter store.AuditEventFilter) ([]store.AuditEvent, error) {
+			events := []store.AuditEvent{}
+			for _, event := range recorded {
+				if event.ProjectID == filter.ProjectID && (filter.Action == "" || event.Action == filter.Action) {
+					events = append(events, event)
+				}
+			}
+			return events, nil
+		},
+	}
+
+	reload := httptest.NewRequest(http.MethodPost, "/v1/admin/config/reload", nil)
+	reload = reload.WithContext(context.WithValue(reload.Context(), adminAuthContext, true))
+	event := handler.auditEvent(reload, "config.reload", &auditEntry{}, http.StatusOK)
+	if event.ProjectID != store.GlobalAuditProjectID {
+		t.Fatalf("expected admin action in the global scope, got %q", event.ProjectID)
+	}
+	recorded = append(recorded, event)
+
+	list := func(serve http.HandlerFunc) []store.AuditEvent {
+		recorder := httptest.NewRecorder()
+		serve(recorder, httptest.NewRequest(http.MethodGet, "/v1/audit?action=config.reload", nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+		}
+		var body struct {
+			Events []store.AuditEvent `json:"events"`
+		}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode events: %v", err)
+		}
+		return body.Events
+	}
+
+	if events := list(handler.listGlobalAuditEvents); len(events) != 1 || events[0].Action != "config.reload" {
+		t.Fatalf("expected config.reload in the admin listing, got %+v", events)
+	}
+	if events := list(handler.listAuditEvents); len(events) != 0 {
+		t.Fatalf("expected project listing not to include global events, got %+v", events)
+	}
+}
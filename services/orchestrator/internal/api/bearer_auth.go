@@ -0,0 +1,134 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"retrospec/services/orchestrator/internal/store"
+)
+
+type bearerPrincipal struct {
+	Subject   string
+	UserID    string
+	ProjectID string
+	Role      string
+	Scopes    []string
+}
+
+func bearerToken(r *http.Request) string {
+	header := strings.TrimSpace(r.Header.Get("Authorization"))
+	if len(header) < 7 || !strings.EqualFold(header[:7], "bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(header[7:])
+}
+
+// resolveBearerPrincipal verifies a dashboard JWT and picks the project it is
+// acting on: an explicit X-Retrospec-Project/projectId, or the only project the
+// token or the user's memberships grant. Once the subject maps to a user, only
+// active memberships grant access, so removing a member revokes it; the token's
+// projects claim applies to subjects without a user row.
+func (h *Handler) resolveBearerPrincipal(r *http.Request, token string) (bearerPrincipal, int, string) {
+	claims, err := h.jwtVerifier.Verify(r.Context(), token)
+	if err != nil {
+		return bearerPrincipal{}, http.StatusUnauthorized, "unauthorized"
+	}
+
+	principal := bearerPrincipal{Subject: claims.Subject}
+	memberProjects := []string{}
+	user, err := h.store.ResolveUserForSubject(r.Context(), claims.Subject, claims.Email, claims.EmailVerified)
+	switch {
+	case err == nil:
+		principal.UserID = user.ID
+		memberProjects, err = h.store.ListUserProjectIDs(r.Context(), user.ID)
+		if err != nil {
+			return bearerPrincipal{}, http.StatusInternalServerError, "membership lookup failed"
+		}
+	case !errors.Is(err, pgx.ErrNoRows):
+		return bearerPrincipal{}, http.StatusInternalServerError, "user lookup failed"
+	}
+
+	projectID := firstNonEmpty(r.Header.Get("X-Retrospec-Project"), r.URL.Query().Get("projectId"))
+	if projectID == "" {
+		candidates := memberProjects
+		if principal.UserID == "" {
+			candidates = uniqueStrings(claims.Projects)
+		}
+		if len(candidates) != 1 || candidates[0] == "*" {
+			return bearerPrincipal{}, http.StatusBadRequest, "X-Retrospec-Project header is required"
+		}
+		projectID = candidates[0]
+	}
+	principal.ProjectID = projectID
+
+	if principal.UserID != "" {
+		membership, err := h.store.GetProjectMembership(r.Context(), projectID, principal.UserID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return bearerPrincipal{}, http.StatusInternalServerError, "membership lookup failed"
+		}
+		if err != nil || membership.Status != "active" {
+			return bearerPrincipal{}, http.StatusForbidden, "user is not a member of project"
+		}
+		principal.Role = membership.Role
+		principal.Scopes = store.RoleScopes(membership.Role)
+		return principal, 0, ""
+	}
+
+	if !claims.AllowsProject(projectID) {
+		return bearerPrincipal{}, http.StatusForbidden, "token does not grant access to project"
+	}
+	principal.Scopes = h.jwtScopes
+	return principal, 0, ""
+}
+
+func uniqueStrings(values []string) []string {
+	seen := map[string]struct{}{}
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if _, exists := seen[value]; exists {
+			continue
+		}
+		seen[value] = struct{}{}
+		unique = append(unique, value)
+	}
+	return unique
+}
+
+// actorFromContext names who made the request for audit fields: the member
+// user, the bearer subject, the API key ID, the global admin key, or the
+// share link used for a public view.
+func (h *Handler) actorFromContext(ctx context.Context) string {
+	if link, ok := shareLinkFromContext(ctx); ok {
+		return "share_link:" + link.ID
+	}
+	if userID := h.userIDFromContext(ctx); userID != "" {
+		return "user:" + userID
+	}
+	if subject := h.subjectFromContext(ctx); subject != "" {
+		return "subject:" + subject
+	}
+	if keyID, _ := ctx.Value(apiKeyIDContext).(string); keyID != "" {
+		return "api_key:" + keyID
+	}
+	if h.keyAuthenticatedFromContext(ctx) {
+		return "api_key"
+	}
+	if admin, _ := ctx.Value(adminAuthContext).(bool); admin {
+		return "admin_api"
+	}
+	return "anonymous"
+}
+
+func (h *Handler) userIDFromContext(ctx context.Context) string {
+	value, _ := ctx.Value(authUserContext).(string)
+	return value
+}
+
+func (h *Handler) subjectFromContext(ctx context.Context) string {
+	value, _ := ctx.Value(authSubjectContext).(string)
+	return value
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+const clientAddressContext = requestContextKey("client_address")
+
+// ParseTrustedProxies reads a comma-separated list of CIDRs or bare addresses
+// of proxies allowed to report the client address through forwarding headers.
+func ParseTrustedProxies(list string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0)
+	for _, value := range strings.Split(list, ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if !strings.Contains(value, "/") {
+			addr, err := netip.ParseAddr(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", value)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", value)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// withClientAddress resolves the client address once per request and rewrites
+// RemoteAddr so request logs, audit events and rate limits agree on it.
+func (h *Handler) withClientAddress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		address := resolveClientAddress(r, h.trustedProxies)
+		if address != "" {
+			r.RemoteAddr = address
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientAddressContext, address)))
+	})
+}
+
+func clientAddress(r *http.Request) string {
+	if address, ok := r.Context().Value(clientAddressContext).(string); ok {
+		return address
+	}
+	return resolveClientAddress(r, nil)
+}
+
+// resolveClientAddress only honours forwarding headers when the peer is a
+// trusted proxy. The hop chain is walked from the right, skipping trusted
+// proxies, so entries a client prepends cannot override what the nearest
+// untrusted hop reported.
+func resolveClientAddress(r *http.Request, trusted []netip.Prefix) string {
+	peer, ok := parseForwardedAddr(r.RemoteAddr)
+	if !ok {
+		return strings.TrimSpace(r.RemoteAddr)
+	}
+	if !addrTrusted(trusted, peer) {
+		return peer.String()
+	}
+
+	hops := forwardedForHops(r.Header)
+	if len(hops) == 0 {
+		if realIP, ok := parseForwardedAddr(r.Header.Get("X-Real-IP")); ok {
+			return realIP.String()
+		}
+		return peer.String()
+	}
+
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, ok := parseForwardedAddr(hops[i])
+		if !ok {
+			break
+		}
+		client = hop
+		if !addrTrusted(trusted, hop) {
+			break
+		}
+	}
+	return client.String()
+}
+
+// forwardedForHops prefers the RFC 7239 Forwarded header and falls back to
+// X-Forwarded-For. Repeated headers are treated as one comma-joined list.
+func forwardedForHops(header http.Header) []string {
+	hops := make([]string, 0)
+	if values := header.Values("Forwarded"); len(values) > 0 {
+		for _, element := range strings.Split(strings.Join(values, ","), ",") {
+			for _, pair := range strings.Split(element, ";") {
+				name, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+				if found && strings.EqualFold(strings.TrimSpace(name), "for") {
+					hops = append(hops, strings.Trim(strings.TrimSpace(value), `"`))
+				}
+			}
+		}
+		return hops
+	}
+
+	for _, entry := range strings.Split(strings.Join(header.Values("X-Forwarded-For"), ","), ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			hops = append(hops, entry)
+		}
+	}
+	return hops
+}
+
+// parseForwardedAddr accepts an address with or without a port, including the
+// bracketed IPv6 form used by Forwarded. Obfuscated identifiers and "unknown"
+// do not parse.
+func parseForwardedAddr(value string) (netip.Addr, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return netip.Addr{}, false
+	}
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		value = host
+	}
+	addr, err := netip.ParseAddr(strings.Trim(value, "[]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap().WithZone(""), true
+}
+
+func addrTrusted(trusted []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
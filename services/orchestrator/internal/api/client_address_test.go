@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResolveClientAddressIgnoresHeadersFromUntrustedPeers(t *testing.T) {
+	trusted, err := ParseTrustedProxies("10.0.0.0/8")
+	if err != nil {
+		t.Fatalf("parse trusted proxies: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/v1/issues", nil)
+	req.Header.Set("X-Forwarded-For", "203.0.113.9")
+	req.Header.Set("X-Real-IP", "198.51.100.4")
+	req.RemoteAddr = "192.0.2.50:1234"
+
+	if got := resolveClientAddress(req, trusted); got != "192.0.2.50" {
+		t.Fatalf("expected untrusted peer address, got %q", got)
+	}
+}
+
+func TestResolveClientAddressWalksForwardedForFromTheRight(t *testing.T) {
+	trusted, err := ParseTrustedProxies("10.0.0.0/8, 172.16.0.1")
+	if err != nil {
+		t.Fatalf("parse trusted proxies: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/v1/issues", nil)
+	req.Header.Set("X-Forwarded-For", "1.1.1.1, 203.0.113.9, 172.16.0.1")
+	req.RemoteAddr = "10.0.0.2:1234"
+
+	if got := resolveClientAddress(req, trusted); got != "203.0.113.9" {
+		t.Fatalf("expected nearest untrusted hop, got %q", got)
+	}
+}
+
+func TestResolveClientAddressPrefersForwardedHeader(t *testing.T) {
+	trusted, err := ParseTrustedProxies("10.0.0.0/8")
+	if err != nil {
+		t.Fatalf("parse trusted proxies: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/v1/issues", nil)
+	req.Header.Set("Forwarded", `for="[2001:db8::1]:4711";proto=https, for=10.0.0.7`)
+	req.Header.Set("X-Forwarded-For", "198.51.100.4")
+	req.RemoteAddr = "10.0.0.2:1234"
+
+	if got := resolveClientAddress(req, trusted); got != "2001:db8::1" {
+		t.Fatalf("expected address from Forwarded header, got %q", got)
+	}
+}
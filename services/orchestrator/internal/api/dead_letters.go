@@ -0,0 +1,233 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"retrospec/services/orchestrator/internal/logging"
+	"retrospec/services/orchestrator/internal/queue"
+)
+
+const (
+	maxDeadLetterPageSize = 200
+	maxDeadLetterBatch    = 500
+)
+
+type deadLetterActionRequest struct {
+	Scope  string                   `json:"scope"`
+	Limit  int                      `json:"limit"`
+	DryRun bool                     `json:"dryRun"`
+	Filter *deadLetterFilterRequest `json:"filter"`
+}
+
+type deadLetterFilterRequest struct {
+	ProjectID     string `json:"projectId"`
+	SessionID     string `json:"sessionId"`
+	TriggerKind   string `json:"triggerKind"`
+	ErrorContains string `json:"errorContains"`
+	FailedAfter   string `json:"failedAfter"`
+	FailedBefore  string `json:"failedBefore"`
+}
+
+func (h *Handler) listDeadLetters(w http.ResponseWriter, r *http.Request) {
+	inspector, ok := h.replayProducer.(queue.DeadLetterInspector)
+	if !ok {
+		writeJSON(w, http.StatusNotImplemented, map[string]string{"error": "dead-letter inspection unsupported"})
+		return
+	}
+	queueKind, ok := deadLetterQueueKind(w, r)
+	if !ok {
+		return
+	}
+	scope, ok := deadLetterScope(w, r.URL.Query().Get("scope"))
+	if !ok {
+		return
+	}
+
+	offset := 0
+	if candidate := strings.TrimSpace(r.URL.Query().Get("offset")); candidate != "" {
+		parsed, err := strconv.Atoi(candidate)
+		if err != nil || parsed < 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "offset must be a non-negative integer"})
+			return
+		}
+		offset = parsed
+	}
+	limit := 25
+	if candidate := strings.TrimSpace(r.URL.Query().Get("limit")); candidate != "" {
+		parsed, err := strconv.Atoi(candidate)
+		if err != nil || parsed < 1 || parsed > maxDeadLetterPageSize {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "limit must be an integer between 1 and 200"})
+			return
+		}
+		limit = parsed
+	}
+
+	result, err := inspector.ListDeadLetters(r.Context(), queueKind, scope, offset, limit)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "dead-letter list failed", slog.String("queue_kind", string(queueKind)), logging.Err(err))
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "dead-letter list failed"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"deadLetters": result})
+}
+
+func (h *Handler) redriveDeadLetters(w http.ResponseWriter, r *http.Request) {
+	redriver, ok := h.replayProducer.(queue.DeadLetterRedriver)
+	if !ok {
+		writeJSON(w, http.StatusNotImplemented, map[string]string{"error": "dead-letter redrive unsupported"})
+		return
+	}
+	queueKind, ok := deadLetterQueueKind(w, r)
+	if !ok {
+		return
+	}
+	payload, ok := decodeDeadLetterAction(w, r)
+	if !ok {
+		return
+	}
+
+	if payload.Filter != nil || payload.DryRun {
+		h.redriveMatchingDeadLetters(w, r, queueKind, payload)
+		return
+	}
+
+	result, err := redriver.RedriveDeadLetters(r.Context(), queueKind, payload.Limit)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "dead-letter redrive failed", slog.String("queue_kind", string(queueKind)), slog.Int("redriven", result.Redriven), logging.Err(err))
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": "dead-letter redrive failed", "redrive": result})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"redrive": result})
+}
+
+// redriveMatchingDeadLetters handles redrives with a filter or dryRun; a dry
+// run without a filter previews the entries a plain redrive would move.
+func (h *Handler) redriveMatchingDeadLetters(w http.ResponseWriter, r *http.Request, queueKind queue.DeadLetterQueueKind, payload deadLetterActionRequest) {
+	redriver, ok := h.replayProducer.(queue.DeadLetterSelectiveRedriver)
+	if !ok {
+		writeJSON(w, http.StatusNotImplemented, map[string]string{"error": "selective dead-letter redrive unsupported"})
+		return
+	}
+	filter, err := parseDeadLetterFilter(payload.Filter)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	result, err := redriver.RedriveMatchingDeadLetters(r.Context(), queueKind, filter, payload.Limit, payload.DryRun)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "dead-letter redrive failed", slog.String("queue_kind", string(queueKind)), slog.Int("redriven", result.Redriven), logging.Err(err))
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": "dead-letter redrive failed", "redrive": result})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"redrive": result})
+}
+
+func parseDeadLetterFilter(payload *deadLetterFilterRequest) (queue.DeadLetterFilter, error) {
+	if payload == nil {
+		return queue.DeadLetterFilter{}, nil
+	}
+	filter := queue.DeadLetterFilter{
+		ProjectID:     strings.TrimSpace(payload.ProjectID),
+		SessionID:     strings.TrimSpace(payload.SessionID),
+		TriggerKind:   strings.TrimSpace(payload.TriggerKind),
+		ErrorContains: strings.TrimSpace(payload.ErrorContains),
+	}
+	for _, bound := range []struct {
+		name   string
+		raw    string
+		target *time.Time
+	}{
+		{"failedAfter", payload.FailedAfter, &filter.FailedAfter},
+		{"failedBefore", payload.FailedBefore, &filter.FailedBefore},
+	} {
+		if strings.TrimSpace(bound.raw) == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, strings.TrimSpace(bound.raw))
+		if err != nil {
+			return queue.DeadLetterFilter{}, errors.New(bound.name + " must be RFC3339 timestamp")
+		}
+		*bound.target = parsed
+	}
+	if !filter.FailedAfter.IsZero() && !filter.FailedBefore.IsZero() && !filter.FailedAfter.Before(filter.FailedBefore) {
+		return queue.DeadLetterFilter{}, errors.New("failedAfter must be before failedBefore")
+	}
+	return filter, nil
+}
+
+func (h *Handler) purgeDeadLetters(w http.ResponseWriter, r *http.Request) {
+	purger, ok := h.replayProducer.(queue.DeadLetterPurger)
+	if !ok {
+		writeJSON(w, http.StatusNotImplemented, map[string]string{"error": "dead-letter purge unsupported"})
+		return
+	}
+	queueKind, ok := deadLetterQueueKind(w, r)
+	if !ok {
+		return
+	}
+	payload, ok := decodeDeadLetterAction(w, r)
+	if !ok {
+		return
+	}
+	scope, ok := deadLetterScope(w, payload.Scope)
+	if !ok {
+		return
+	}
+
+	result, err := purger.PurgeDeadLetters(r.Context(), queueKind, scope, payload.Limit)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "dead-letter purge failed", slog.String("queue_kind", string(queueKind)), logging.Err(err))
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "dead-letter purge failed"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"purge": result})
+}
+
+func deadLetterQueueKind(w http.ResponseWriter, r *http.Request) (queue.DeadLetterQueueKind, bool) {
+	queueKind := queue.DeadLetterQueueKind(strings.TrimSpace(chi.URLParam(r, "queueKind")))
+	switch queueKind {
+	case queue.DeadLetterQueueReplay, queue.DeadLetterQueueAnalysis:
+		return queueKind, true
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "queueKind must be one of: replay, analysis"})
+		return "", false
+	}
+}
+
+func deadLetterScope(w http.ResponseWriter, raw string) (queue.DeadLetterScope, bool) {
+	scope := queue.DeadLetterScope(strings.TrimSpace(raw))
+	switch scope {
+	case "":
+		return queue.DeadLetterScopeFailed, true
+	case queue.DeadLetterScopeFailed, queue.DeadLetterScopeUnprocessable:
+		return scope, true
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "scope must be one of: failed, unprocessable"})
+		return "", false
+	}
+}
+
+// decodeDeadLetterAction reads the optional body of a redrive or purge. The
+// limit is required so a bare POST never drains a whole queue.
+func decodeDeadLetterAction(w http.ResponseWriter, r *http.Request) (deadLetterActionRequest, bool) {
+	payload := deadLetterActionRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid payload"})
+		return payload, false
+	}
+	if payload.Limit < 1 || payload.Limit > maxDeadLetterBatch {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "limit must be an integer between 1 and 500"})
+		return payload, false
+	}
+	return payload, true
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-chi/chi/v5"
+
+	"retrospec/services/orchestrator/internal/queue"
+)
+
+func TestDeadLetterRoutesListRedriveAndPurge(t *testing.T) {
+	mr := miniredis.RunT(t)
+	producer, err := queue.NewRedisProducer(mr.Addr(), "replay-jobs", "analysis-jobs")
+	if err != nil {
+		t.Fatalf("new producer failed: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = producer.Close()
+	})
+	for _, sessionID := range []string{"sess_1", "sess_2", "sess_3"} {
+		entry := `{"failedAt":"2026-10-01T00:00:00Z","error":"render timeout","attempt":3,"payload":"{\"projectId\":\"proj_a\",\"sessionId\":\"` + sessionID + `\"}"}`
+		if _, err := mr.Lpush("replay-jobs:failed", entry); err != nil {
+			t.Fatalf("seed dead letter failed: %v", err)
+		}
+	}
+
+	h := &Handler{replayProducer: producer}
+	router := chi.NewRouter()
+	router.Get("/{queueKind}/dead-letters", h.listDeadLetters)
+	router.Post("/{queueKind}/dead-letters/redrive", h.redriveDeadLetters)
+	router.Post("/{queueKind}/dead-letters/purge", h.purgeDeadLetters)
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return recorder
+	}
+
+	recorder := send(http.MethodGet, "/replay/dead-letters?limit=2&offset=1", "")
+	listed := struct {
+		DeadLetters queue.DeadLetterListResult `json:"deadLetters"`
+	}{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &listed); err != nil || recorder.Code != http.StatusOK {
+		t.Fatalf("list failed: %d %s err=%v", recorder.Code, recorder.Body.String(), err)
+	}
+	if listed.DeadLetters.Total != 3 || len(listed.DeadLetters.Entries) != 2 || listed.DeadLetters.Entries[0].SessionID != "sess_2" {
+		t.Fatalf("unexpected page %+v", listed.DeadLetters)
+	}
+
+	if recorder := send(http.MethodPost, "/replay/dead-letters/redrive", ""); recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected redrive without limit to be rejected, got %d", recorder.Code)
+	}
+	if recorder := send(http.MethodGet, "/billing/dead-letters", ""); recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected unknown queue kind to be rejected, got %d", recorder.Code)
+	}
+
+	recorder = send(http.MethodPost, "/replay/dead-letters/redrive", `{"limit":1}`)
+	redriven := struct {
+		Redrive queue.DeadLetterRedriveResult `json:"redrive"`
+	}{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &redriven); err != nil || redriven.Redrive.Redriven != 1 || redriven.Redrive.RemainingFailed != 2 {
+		t.Fatalf("unexpected redrive %d %s err=%v", recorder.Code, recorder.Body.String(), err)
+	}
+
+	recorder = send(http.MethodPost, "/replay/dead-letters/redrive", `{"limit":5,"dryRun":true,"filter":{"sessionId":"sess_3"}}`)
+	preview := struct {
+		Redrive queue.DeadLetterSelectiveRedriveResult `json:"redrive"`
+	}{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &preview); err != nil || preview.Redrive.Matched != 1 || !preview.Redrive.DryRun || preview.Redrive.RemainingFailed != 2 {
+		t.Fatalf("unexpected dry run %d %s err=%v", recorder.Code, recorder.Body.String(), err)
+	}
+	if recorder := send(http.MethodPost, "/replay/dead-letters/redrive", `{"limit":5,"filter":{"failedAfter":"yesterday"}}`); recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid failedAfter to be rejected, got %d", recorder.Code)
+	}
+
+	recorder = send(http.MethodPost, "/replay/dead-letters/purge", `{"scope":"failed","limit":5}`)
+	purged := struct {
+		Purge queue.DeadLetterPurgeResult `json:"purge"`
+	}{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &purged); err != nil || purged.Purge.Deleted != 2 || purged.Purge.Remaining != 0 {
+		t.Fatalf("unexpected purge %d %s err=%v", recorder.Code, recorder.Body.String(), err)
+	}
+}
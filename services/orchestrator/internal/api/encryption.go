@@ -0,0 +1,116 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"retrospec/services/orchestrator/internal/artifacts"
+	"retrospec/services/orchestrator/internal/logging"
+)
+
+// loadInternalSessionEvents lets workers read event blobs that the
+// orchestrator encrypted, since they have no access to the data keys.
+func (h *Handler) loadInternalSessionEvents(w http.ResponseWriter, r *http.Request) {
+	objectKey := strings.TrimSpace(r.URL.Query().Get("key"))
+	if !strings.HasPrefix(objectKey, "session-events/") || strings.Contains(objectKey, "..") {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "key must reference a session-events object"})
+		return
+	}
+
+	eventsJSON, err := h.artifactStore.LoadJSON(r.Context(), objectKey)
+	if err != nil {
+		if errors.Is(err, artifacts.ErrNotConfigured) {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "artifact store unavailable"})
+			return
+		}
+		slog.ErrorContext(r.Context(), "internal session events load failed", slog.String("object_key", objectKey), logging.Err(err))
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "unable to load session events"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"eventsObjectKey": objectKey,
+		"events":          eventsJSON,
+	})
+}
+
+const replayArtifactKeyPrefix = "replay-artifacts/"
+
+// storeInternalArtifact writes a worker-rendered artifact through the artifact
+// store, so it is encrypted like event blobs when encryption is enabled. Keys
+// are confined to replay-artifacts/<projectId>/, so a callback credential
+// cannot overwrite other objects or another project's artifacts.
+func (h *Handler) storeInternalArtifact(w http.ResponseWriter, r *http.Request) {
+	projectID := strings.TrimSpace(r.URL.Query().Get("projectId"))
+	objectKey := strings.TrimSpace(r.URL.Query().Get("key"))
+	if projectID == "" || strings.Contains(projectID, "/") {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "projectId is required"})
+		return
+	}
+	if !validReplayArtifactKey(projectID, objectKey) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "key must reference a replay artifact of the project"})
+		return
+	}
+
+	writer, ok := h.artifactStore.(artifacts.ObjectWriter)
+	if !ok {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "artifact store unavailable"})
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid payload"})
+		return
+	}
+
+	contentType := strings.TrimSpace(r.Header.Get("Content-Type"))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	ctx := artifacts.WithProjectID(r.Context(), projectID)
+	if err := writer.StoreObject(ctx, objectKey, body, contentType); err != nil {
+		if errors.Is(err, artifacts.ErrNotConfigured) {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "artifact store unavailable"})
+			return
+		}
+		slog.ErrorContext(r.Context(), "internal artifact store failed", slog.String("object_key", objectKey), logging.Err(err))
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "unable to store artifact"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"artifactKey": objectKey})
+}
+
+func validReplayArtifactKey(projectID, objectKey string) bool {
+	rest, ok := strings.CutPrefix(objectKey, replayArtifactKeyPrefix+projectID+"/")
+	if !ok || rest == "" {
+		return false
+	}
+	for _, segment := range strings.Split(rest, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+func (h *Handler) rewrapDataKeys(w http.ResponseWriter, r *http.Request) {
+	rewrapper, ok := h.artifactStore.(artifacts.DataKeyRewrapper)
+	if !ok {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "artifact encryption not enabled"})
+		return
+	}
+
+	result, err := rewrapper.RewrapDataKeys(r.Context(), h.projectIDFromContext(r.Context()), 500)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "data key rewrap failed"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"retrospec/services/orchestrator/internal/artifacts"
+)
+
+type recordingArtifactStore struct {
+	artifacts.Store
+	stored []string
+}
+
+func (s *recordingArtifactStore) StoreObject(_ context.Context, objectKey string, _ []byte, _ string) error {
+	s.stored = append(s.stored, objectKey)
+	return nil
+}
+
+func TestStoreInternalArtifactConfinesKeysToProjectReplayArtifacts(t *testing.T) {
+	backend := &recordingArtifactStore{Store: artifacts.NewNoopStore()}
+	handler := &Handler{artifactStore: backend}
+
+	send := func(projectID, key string) int {
+		query := url.Values{"projectId": {projectID}, "key": {key}}
+		req := httptest.NewRequest(http.MethodPut, "/v1/internal/artifacts?"+query.Encode(), strings.NewReader(`{"ok":true}`))
+		recorder := httptest.NewRecorder()
+		handler.storeInternalArtifact(recorder, req)
+		return recorder.Code
+	}
+
+	if code := send("proj_a", "replay-artifacts/proj_a/session-1/analysis.json"); code != http.StatusOK {
+		t.Fatalf("expected own replay artifact to be stored, got %d", code)
+	}
+	for _, tc := range []struct{ projectID, key string }{
+		{"proj_a", "replay-artifacts/proj_b/session-1/analysis.json"},
+		{"proj_a", "replay-artifacts/session-1/analysis.json"},
+		{"proj_a", "replay-artifacts/proj_a/"},
+		{"proj_a", "replay-artifacts/proj_a/../proj_b/session-1/analysis.json"},
+		{"proj_a", "replay-artifacts/proj_a//analysis.json"},
+		{"proj_a", "session-events/proj_a/session-1.json"},
+		{"proj_a", "exports/proj_a/dump.json"},
+		{"proj_a/..", "replay-artifacts/proj_a/../x.json"},
+		{"", "replay-artifacts//session-1/analysis.json"},
+	} {
+		if code := send(tc.projectID, tc.key); code != http.StatusBadRequest {
+			t.Fatalf("project %q key %q: expected 400, got %d", tc.projectID, tc.key, code)
+		}
+	}
+	if len(backend.stored) != 1 {
+		t.Fatalf("expected only the valid key to reach the store, got %v", backend.stored)
+	}
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
+	"net/netip"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -17,32 +19,56 @@ import (
 	"github.com/jackc/pgx/v5"
 
 	"retrospec/services/orchestrator/internal/artifacts"
+	"retrospec/services/orchestrator/internal/auth"
+	"retrospec/services/orchestrator/internal/logging"
 	"retrospec/services/orchestrator/internal/queue"
 	"retrospec/services/orchestrator/internal/store"
 )
 
 type Handler struct {
-	artifactStore            artifacts.Store
-	replayProducer           queue.Producer
-	queueStatsProvider       queue.StatsProvider
-	corsAllowedOrigins       []string
-	internalAPIKey           string
-	ingestAPIKey             string
-	store                    *store.Postgres
-	clusterPromoteMinSession int
-	rateLimiter              *apiRateLimiter
-	metrics                  *apiMetrics
-	artifactTokenSecret      string
-	artifactTokenTTL         time.Duration
-	sessionRetentionDays     int
+	artifactStore                 artifacts.Store
+	replayProducer                queue.Producer
+	queueStatsProvider            queue.StatsProvider
+	internalSigningKeys           auth.SigningKeys
+	internalSignatureSkew         time.Duration
+	nonces                        queue.NonceStore
+	ingestAPIKey                  string
+	ingestAPIKeyFullAccess        bool
+	store                         *store.Postgres
+	adminStore                    adminStore
+	auditEvents                   auditEventLister
+	rateLimiter                   *apiRateLimiter
+	metrics                       *apiMetrics
+	artifactTokenKeys             auth.SigningKeys
+	artifactTokenActiveKeyID      string
+	artifactTokenSingleUseDefault bool
+	artifactTokenTTL              time.Duration
+	erasureReceiptKeys            auth.SigningKeys
+	erasureReceiptActiveKeyID     string
+	adminAPIKey                   string
+	apiKeyRotationGrace           time.Duration
+	jwtVerifier                   *auth.JWTVerifier
+	jwtScopes                     []string
+	forbidAnonymous               bool
+	originCache                   *projectOriginCache
+	trustedProxies                []netip.Prefix
+	readinessChecks               []readinessCheck
+	runtime                       atomic.Pointer[RuntimeSettings]
+	configReloader                ConfigReloader
 }
 
 type requestContextKey string
 
 const (
 	defaultProjectID        = "proj_default"
+	globalIngestKeyID       = "ingest_api_key"
 	projectIDContextKey     = requestContextKey("project_id")
 	keyAuthenticatedContext = requestContextKey("key_authenticated")
+	apiKeyScopesContext     = requestContextKey("api_key_scopes")
+	authSubjectContext      = requestContextKey("auth_subject")
+	authUserContext         = requestContextKey("auth_user")
+	apiKeyIDContext         = requestContextKey("api_key_id")
+	adminAuthContext        = requestContextKey("admin_authenticated")
 )
 
 func NewHandler(
@@ -50,80 +76,186 @@ func NewHandler(
 	replayProducer queue.Producer,
 	artifactStore artifacts.Store,
 	corsAllowedOrigins []string,
-	internalAPIKey string,
+	internalSigningKeys auth.SigningKeys,
+	internalSignatureSkewSeconds int,
 	ingestAPIKey string,
+	ingestAPIKeyFullAccess bool,
 	clusterPromoteMinSession int,
-	rateLimitRequestsPerSec float64,
-	rateLimitBurst int,
-	artifactTokenSecret string,
+	rateLimits map[string]store.RateLimit,
+	artifactTokenKeys auth.SigningKeys,
+	artifactTokenActiveKeyID string,
+	artifactTokenSingleUseDefault bool,
 	artifactTokenTTLSeconds int,
+	erasureReceiptKeys auth.SigningKeys,
+	erasureReceiptActiveKeyID string,
 	sessionRetentionDays int,
+	adminAPIKey string,
+	apiKeyRotationGraceMinutes int,
+	jwtVerifier *auth.JWTVerifier,
+	jwtScopes []string,
+	forbidAnonymous bool,
+	trustedProxies []netip.Prefix,
+	metricsMaxProjectLabels int,
+	requiredDependencies []string,
 ) *Handler {
 	var queueStatsProvider queue.StatsProvider
 	if provider, ok := replayProducer.(queue.StatsProvider); ok {
 		queueStatsProvider = provider
 	}
 
-	metrics := newAPIMetrics(queueStatsProvider)
-
-	return &Handler{
-		store:                    store,
-		replayProducer:           replayProducer,
-		queueStatsProvider:       queueStatsProvider,
-		artifactStore:            artifactStore,
-		corsAllowedOrigins:       corsAllowedOrigins,
-		internalAPIKey:           internalAPIKey,
-		ingestAPIKey:             ingestAPIKey,
-		clusterPromoteMinSession: clusterPromoteMinSession,
-		rateLimiter: newAPIRateLimiter(rateLimitRequestsPerSec, rateLimitBurst, func() {
-			metrics.rateLimitedTotal.Add(1)
-		}),
-		metrics:              metrics,
-		artifactTokenSecret:  strings.TrimSpace(artifactTokenSecret),
-		artifactTokenTTL:     time.Duration(maxInt(60, artifactTokenTTLSeconds)) * time.Second,
-		sessionRetentionDays: sessionRetentionDays,
-	}
+	metrics := newAPIMetricsWithProjectLimit(queueStatsProvider, metricsMaxProjectLabels)
+
+	h := &Handler{
+		store:                  store,
+		adminStore:             store,
+		auditEvents:            store.ListAuditEvents,
+		replayProducer:         replayProducer,
+		queueStatsProvider:     queueStatsProvider,
+		artifactStore:          artifactStore,
+		internalSigningKeys:    internalSigningKeys,
+		internalSignatureSkew:  time.Duration(maxInt(5, internalSignatureSkewSeconds)) * time.Second,
+		nonces:                 nonceStoreFor(replayProducer),
+		ingestAPIKey:           ingestAPIKey,
+		ingestAPIKeyFullAccess: ingestAPIKeyFullAccess,
+		rateLimiter: newAPIRateLimiter(
+			rateLimitStoreFor(replayProducer),
+			rateLimits,
+			newProjectRateLimitCache(store.ListProjectRateLimits),
+			func() { metrics.rateLimitedTotal.Add(1) },
+			func() { metrics.rateLimitErrorsTotal.Add(1) },
+		),
+		metrics:                       metrics,
+		artifactTokenKeys:             artifactTokenKeys,
+		artifactTokenActiveKeyID:      strings.TrimSpace(artifactTokenActiveKeyID),
+		artifactTokenSingleUseDefault: artifactTokenSingleUseDefault,
+		artifactTokenTTL:              time.Duration(maxInt(60, artifactTokenTTLSeconds)) * time.Second,
+		erasureReceiptKeys:            erasureReceiptKeys,
+		erasureReceiptActiveKeyID:     strings.TrimSpace(erasureReceiptActiveKeyID),
+		adminAPIKey:                   strings.TrimSpace(adminAPIKey),
+		apiKeyRotationGrace:           time.Duration(maxInt(0, apiKeyRotationGraceMinutes)) * time.Minute,
+		jwtVerifier:                   jwtVerifier,
+		jwtScopes:                     jwtScopes,
+		forbidAnonymous:               forbidAnonymous,
+		originCache:                   newProjectOriginCache(store.ListProjectAllowedOrigins),
+		trustedProxies:                trustedProxies,
+		readinessChecks:               newReadinessChecks(store.Health, replayProducer, artifactStore, requiredDependencies),
+	}
+	h.ApplyRuntimeSettings(RuntimeSettings{
+		CORSAllowedOrigins:        corsAllowedOrigins,
+		RateLimits:                rateLimits,
+		ClusterPromoteMinSessions: clusterPromoteMinSession,
+		SessionRetentionDays:      sessionRetentionDays,
+	})
+	return h
 }
 
 func (h *Handler) Router() http.Handler {
 	r := chi.NewRouter()
 	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
-	r.Use(middleware.Logger)
+	r.Use(h.withClientAddress)
+	r.Use(traceRequests)
+	r.Use(h.metrics.instrument)
+	r.Use(logRequests)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.Timeout(15 * time.Second))
-	if h.rateLimiter != nil {
-		r.Use(h.rateLimiter.Middleware)
-	}
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   h.corsAllowedOrigins,
+		AllowOriginFunc:  h.allowCORSOrigin,
 		AllowedMethods:   []string{"GET", "POST", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-Retrospec-Key", "X-Retrospec-Internal"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-Retrospec-Key", "X-Retrospec-Admin", "X-Retrospec-Project", "X-Retrospec-Share-Password"},
+		ExposedHeaders:   []string{"Link", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"},
 		AllowCredentials: false,
 		MaxAge:           300,
 	}))
 
 	r.Get("/healthz", h.healthz)
+	r.Get("/readyz", h.readyz)
 	r.Get("/metrics", h.metrics.handleMetrics)
 	r.Route("/v1", func(r chi.Router) {
 		r.With(h.requireInternalAccess).Post("/internal/replay-results", h.reportReplayResult)
 		r.With(h.requireInternalAccess).Post("/internal/analysis-reports", h.reportAnalysisResult)
-		r.With(h.withProjectContextAllowQueryKey).Get("/sessions/{sessionID}/artifacts/{artifactType}", h.getSessionArtifact)
+		r.With(h.requireInternalAccess).Get("/internal/session-events", h.loadInternalSessionEvents)
+		r.With(h.requireInternalAccessLimit(maxInternalArtifactBytes)).Put("/internal/artifacts", h.storeInternalArtifact)
 
+		// Public routes are limited per client address before authentication,
+		// then per route class, project and caller once the caller is known.
 		r.Group(func(r chi.Router) {
-			r.Use(h.withProjectContext)
-
-			r.With(h.requireWriteAccess).Post("/artifacts/session-events", h.uploadSessionEvents)
-			r.With(h.requireWriteAccess).Post("/ingest/session", h.ingestSession)
-			r.With(h.requireWriteAccess).Post("/issues/promote", h.promoteIssues)
-			r.Get("/issues/stats", h.listIssueStats)
-			r.Get("/issues", h.listIssues)
-			r.Get("/issues/{clusterKey}/sessions", h.listIssueSessions)
-			r.Get("/sessions/{sessionID}", h.getSession)
-			r.Get("/sessions/{sessionID}/events", h.getSessionEvents)
-			r.With(h.requireKeyAccess).Get("/sessions/{sessionID}/artifacts/{artifactType}/token", h.createArtifactToken)
-			r.With(h.requireWriteAccess).Post("/maintenance/cleanup", h.cleanupExpiredData)
+			r.Use(h.rateLimitByAddress)
+
+			r.With(h.requireAdminAccess, h.rateLimit(store.RateLimitClassAdmin), h.audit("config.reload", "", "")).Post("/admin/config/reload", h.reloadConfig)
+			r.With(h.requireAdminAccess, h.rateLimit(store.RateLimitClassAdmin)).Get("/admin/audit", h.listGlobalAuditEvents)
+			r.Route("/admin/queues/{queueKind}/dead-letters", func(r chi.Router) {
+				r.Use(h.requireAdminAccess, h.rateLimit(store.RateLimitClassAdmin))
+				r.Get("/", h.listDeadLetters)
+				r.With(h.audit("dead_letter.redrive", "queue", "queueKind")).Post("/redrive", h.redriveDeadLetters)
+				r.With(h.audit("dead_letter.purge", "queue", "queueKind")).Post("/purge", h.purgeDeadLetters)
+			})
+			r.Route("/admin/projects", func(r chi.Router) {
+				r.Use(h.requireAdminAccess, h.rateLimit(store.RateLimitClassAdmin))
+				r.With(h.audit("project.create", "project", "")).Post("/", h.createProject)
+				r.Get("/", h.listProjects)
+				r.With(h.audit("api_key.create", "project", "projectID")).Post("/{projectID}/keys", h.createProjectAPIKey)
+				r.Get("/{projectID}/keys", h.listProjectAPIKeys)
+				r.With(h.audit("api_key.revoke", "api_key", "keyID")).Post("/{projectID}/keys/{keyID}/revoke", h.revokeProjectAPIKey)
+				r.With(h.audit("api_key.rotate", "api_key", "keyID")).Post("/{projectID}/keys/{keyID}/rotate", h.rotateProjectAPIKey)
+				r.With(h.audit("member.invite", "project", "projectID")).Post("/{projectID}/members", h.inviteProjectMemberAsAdmin)
+				r.With(h.audit("project.origins.update", "project", "projectID")).Post("/{projectID}/origins", h.updateProjectAllowedOrigins)
+				r.With(h.audit("project.rate_limits.update", "project", "projectID")).Post("/{projectID}/rate-limits", h.updateProjectRateLimits)
+				r.With(h.audit("project.tier.update", "project", "projectID")).Post("/{projectID}/tier", h.updateProjectTier)
+			})
+
+			r.With(
+				h.withProjectContextAllowQueryKey,
+				h.rateLimit(store.RateLimitClassRead),
+				h.audit("artifact.read", "session", "sessionID"),
+			).Get("/sessions/{sessionID}/artifacts/{artifactType}", h.getSessionArtifact)
+
+			r.Route("/shared/{shareToken}", func(r chi.Router) {
+				r.Use(h.withShareLink, h.rateLimit(store.RateLimitClassRead))
+				r.With(h.audit("share_link.view", "", ""), h.requireSharePassword).Get("/", h.getSharedSession)
+				r.With(h.audit("share_link.events.read", "", ""), h.requireSharePassword).Get("/events", h.getSharedSessionEvents)
+				r.With(h.audit("share_link.artifact.read", "", ""), h.requireSharePassword).Get("/artifacts/{artifactType}", h.getSharedSessionArtifact)
+			})
+
+			r.Group(func(r chi.Router) {
+				r.Use(h.withProjectContext)
+
+				r.Group(func(r chi.Router) {
+					r.Use(h.rateLimit(store.RateLimitClassIngest))
+					r.With(h.requireScope(store.APIKeyScopeIngest), h.requireProjectOrigin).Post("/artifacts/session-events", h.uploadSessionEvents)
+					r.With(h.requireScope(store.APIKeyScopeIngest), h.requireProjectOrigin).Post("/ingest/session", h.ingestSession)
+				})
+
+				r.Group(func(r chi.Router) {
+					r.Use(h.rateLimit(store.RateLimitClassRead))
+					r.With(h.audit("issues.promote", "", ""), h.requireScope(store.APIKeyScopeTriage)).Post("/issues/promote", h.promoteIssues)
+					r.With(h.requireScope(store.APIKeyScopeRead)).Get("/issues/stats", h.listIssueStats)
+					r.With(h.requireScope(store.APIKeyScopeRead)).Get("/issues", h.listIssues)
+					r.With(h.requireScope(store.APIKeyScopeRead)).Get("/issues/{clusterKey}/sessions", h.listIssueSessions)
+					r.With(h.requireScope(store.APIKeyScopeRead)).Get("/sessions/{sessionID}", h.getSession)
+					r.With(h.requireScope(store.APIKeyScopeRead)).Get("/pipeline/latency", h.getPipelineLatency)
+					r.With(h.audit("session.events.read", "session", "sessionID"), h.requireScope(store.APIKeyScopeRead)).Get("/sessions/{sessionID}/events", h.getSessionEvents)
+					r.With(h.audit("artifact_token.create", "session", "sessionID"), h.requireKeyScope(store.APIKeyScopeRead)).Get("/sessions/{sessionID}/artifacts/{artifactType}/token", h.createArtifactToken)
+					r.With(h.audit("share_link.create", "session", "sessionID"), h.requireScope(store.APIKeyScopeTriage)).Post("/sessions/{sessionID}/share-links", h.createSessionShareLink)
+					r.With(h.requireScope(store.APIKeyScopeRead)).Get("/sessions/{sessionID}/share-links", h.listSessionShareLinks)
+					r.With(h.audit("share_link.revoke", "share_link", "linkID"), h.requireScope(store.APIKeyScopeTriage)).Post("/share-links/{linkID}/revoke", h.revokeSessionShareLink)
+				})
+
+				r.Group(func(r chi.Router) {
+					r.Use(h.rateLimit(store.RateLimitClassAdmin))
+					r.With(h.audit("artifact_token.revoke", "", ""), h.requireScope(store.APIKeyScopeAdmin)).Post("/artifact-tokens/revoke", h.revokeArtifactToken)
+					r.With(h.audit("maintenance.cleanup", "", ""), h.requireScope(store.APIKeyScopeAdmin)).Post("/maintenance/cleanup", h.cleanupExpiredData)
+					r.With(h.audit("encryption.rewrap", "", ""), h.requireScope(store.APIKeyScopeAdmin)).Post("/maintenance/encryption/rewrap", h.rewrapDataKeys)
+					r.With(h.audit("privacy_erasure.create", "", ""), h.requireScope(store.APIKeyScopeAdmin)).Post("/privacy/erasure", h.requestPrivacyErasure)
+					r.With(h.audit("privacy_erasure.read", "privacy_erasure", "jobID"), h.requireScope(store.APIKeyScopeAdmin)).Get("/privacy/erasure/{jobID}", h.getPrivacyErasureJob)
+					r.With(h.audit("legal_hold.create", "", ""), h.requireScope(store.APIKeyScopeAdmin)).Post("/legal-holds", h.placeLegalHold)
+					r.With(h.requireScope(store.APIKeyScopeAdmin)).Get("/legal-holds", h.listLegalHolds)
+					r.With(h.audit("legal_hold.release", "legal_hold", "holdID"), h.requireScope(store.APIKeyScopeAdmin)).Post("/legal-holds/{holdID}/release", h.releaseLegalHold)
+					r.With(h.audit("member.list", "", ""), h.requireScope(store.APIKeyScopeAdmin)).Get("/members", h.listProjectMembers)
+					r.With(h.audit("member.invite", "", ""), h.requireScope(store.APIKeyScopeAdmin)).Post("/members/invite", h.inviteProjectMember)
+					r.With(h.audit("member.remove", "user", "userID"), h.requireScope(store.APIKeyScopeAdmin)).Post("/members/{userID}/remove", h.removeProjectMember)
+					r.With(h.requireScope(store.APIKeyScopeAdmin)).Get("/audit", h.listAuditEvents)
+				})
+			})
 		})
 	})
 
@@ -152,8 +284,16 @@ func (h *Handler) ingestSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	annotateRequestSession(r.Context(), stored.ProjectID, stored.ID)
+	if len(stored.Markers) > 0 {
+		logging.SetClusterKey(r.Context(), stored.Markers[0].ClusterKey)
+	}
+	h.syncSessionRetentionTags(r.Context(), stored.ProjectID, stored.ID, true)
+	h.recordPipelineStage(r.Context(), stored.ProjectID, stored.ID, store.PipelineStageIngested)
+
 	analysisQueueError := ""
 	if job, ok := buildAnalysisJob(stored); ok {
+		job.Lane = h.jobLane(r.Context(), stored, job.TriggerKind)
 		if _, err := h.store.UpsertSessionReportCard(
 			r.Context(),
 			stored.ProjectID,
@@ -169,15 +309,17 @@ func (h *Handler) ingestSession(w http.ResponseWriter, r *http.Request) {
 		); err != nil {
 			analysisQueueError = err.Error()
 			h.metrics.analysisQueueErrorsTotal.Add(1)
-			log.Printf("analysis report init failed session=%s err=%v", stored.ID, err)
+			slog.ErrorContext(r.Context(), "analysis report init failed", logging.Err(err))
 		}
 		if err := h.replayProducer.EnqueueAnalysisJob(r.Context(), job); err != nil {
 			analysisQueueError = err.Error()
 			h.metrics.analysisQueueErrorsTotal.Add(1)
-			log.Printf("analysis job enqueue failed session=%s err=%v", stored.ID, err)
+			slog.ErrorContext(r.Context(), "analysis job enqueue failed", logging.Err(err))
+		} else {
+			h.recordPipelineStage(r.Context(), stored.ProjectID, stored.ID, store.PipelineStageAnalysisEnqueue)
 		}
 	}
-	h.metrics.ingestSessionsTotal.Add(1)
+	h.metrics.recordIngestSession(stored.ProjectID)
 
 	writeJSON(w, http.StatusAccepted, map[string]any{
 		"session":            stored,
@@ -224,6 +366,7 @@ func (h *Handler) reportReplayResult(w http.ResponseWriter, r *http.Request) {
 
 	projectID := strings.TrimSpace(payload.ProjectID)
 	sessionID := strings.TrimSpace(payload.SessionID)
+	annotateRequestSession(r.Context(), projectID, sessionID)
 	artifactKey := strings.TrimSpace(payload.ArtifactKey)
 	status := strings.TrimSpace(payload.Status)
 	if status == "" {
@@ -268,6 +411,8 @@ func (h *Handler) reportReplayResult(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "artifact upsert failed"})
 		return
 	}
+	h.syncSessionRetentionTags(r.Context(), projectID, sessionID, true)
+	h.recordPipelineStage(r.Context(), projectID, sessionID, store.PipelineStageReplayReported)
 
 	writeJSON(w, http.StatusAccepted, map[string]any{
 		"artifact": artifact,
@@ -284,6 +429,7 @@ func (h *Handler) reportAnalysisResult(w http.ResponseWriter, r *http.Request) {
 
 	projectID := strings.TrimSpace(payload.ProjectID)
 	sessionID := strings.TrimSpace(payload.SessionID)
+	annotateRequestSession(r.Context(), projectID, sessionID)
 	if projectID == "" || sessionID == "" {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "projectId and sessionId are required"})
 		return
@@ -350,18 +496,24 @@ func (h *Handler) reportAnalysisResult(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.recordPipelineStage(r.Context(), projectID, sessionID, store.PipelineStageTextReported)
+
 	replayQueueError := ""
 	if report.Status == "pending" {
 		if job, ok := buildReplayJob(session); ok {
+			job.Lane = h.jobLane(r.Context(), session, job.TriggerKind)
 			if err := h.replayProducer.EnqueueReplayJob(r.Context(), job); err != nil {
 				replayQueueError = err.Error()
 				h.metrics.replayQueueErrorsTotal.Add(1)
-				log.Printf("replay job enqueue failed session=%s err=%v", session.ID, err)
+				slog.ErrorContext(r.Context(), "replay job enqueue failed", logging.Err(err))
+			} else {
+				h.recordPipelineStage(r.Context(), projectID, sessionID, store.PipelineStageReplayEnqueued)
 			}
 		}
 	} else {
-		if _, err := h.store.PromoteClusters(r.Context(), projectID, h.clusterPromoteMinSession); err != nil {
-			log.Printf("post-analysis cluster promote failed session=%s err=%v", sessionID, err)
+		h.recordPipelineStage(r.Context(), projectID, sessionID, store.PipelineStageVerdict)
+		if _, err := h.store.PromoteClusters(r.Context(), projectID, h.runtimeSettings().ClusterPromoteMinSessions); err != nil {
+			slog.ErrorContext(r.Context(), "post-analysis cluster promote failed", logging.Err(err))
 		}
 	}
 
@@ -399,7 +551,8 @@ func (h *Handler) uploadSessionEvents(w http.ResponseWriter, r *http.Request) {
 	objectKey := time.Now().UTC().Format("2006/01/02")
 	objectKey = "session-events/" + projectID + "/" + objectKey + "/" + siteKey + "/" + sessionID + ".json"
 
-	if err := h.artifactStore.StoreJSON(r.Context(), objectKey, sanitizedEvents); err != nil {
+	storeCtx := artifacts.WithProjectID(r.Context(), projectID)
+	if err := h.artifactStore.StoreJSON(storeCtx, objectKey, sanitizedEvents); err != nil {
 		if errors.Is(err, artifacts.ErrNotConfigured) {
 			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "artifact store unavailable"})
 			return
@@ -417,7 +570,7 @@ func (h *Handler) uploadSessionEvents(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) promoteIssues(w http.ResponseWriter, r *http.Request) {
 	projectID := h.projectIDFromContext(r.Context())
-	result, err := h.store.PromoteClusters(r.Context(), projectID, h.clusterPromoteMinSession)
+	result, err := h.store.PromoteClusters(r.Context(), projectID, h.runtimeSettings().ClusterPromoteMinSessions)
 	if err != nil {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "promote failed"})
 		return
@@ -454,6 +607,7 @@ func (h *Handler) listIssueSessions(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "clusterKey is required"})
 		return
 	}
+	logging.SetClusterKey(r.Context(), clusterKey)
 
 	limit := 30
 	if candidate := strings.TrimSpace(r.URL.Query().Get("limit")); candidate != "" {
@@ -530,7 +684,7 @@ func (h *Handler) listIssueStats(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) cleanupExpiredData(w http.ResponseWriter, r *http.Request) {
 	projectID := h.projectIDFromContext(r.Context())
-	result, err := h.store.CleanupExpiredData(r.Context(), projectID, h.sessionRetentionDays)
+	result, err := h.store.CleanupExpiredData(r.Context(), projectID, h.runtimeSettings().SessionRetentionDays)
 	if err != nil {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "cleanup failed"})
 		return
@@ -540,19 +694,19 @@ func (h *Handler) cleanupExpiredData(w http.ResponseWriter, r *http.Request) {
 		err := h.artifactStore.DeleteObject(r.Context(), objectKey)
 		if err != nil && !errors.Is(err, artifacts.ErrNotConfigured) {
 			result.FailedEventObjectDelete++
-			log.Printf("failed to delete event object key=%s err=%v", objectKey, err)
+			slog.ErrorContext(r.Context(), "event object delete failed", slog.String("object_key", objectKey), logging.Err(err))
 		}
 	}
 	for _, objectKey := range result.DeletedArtifactObjectKeys {
 		err := h.artifactStore.DeleteObject(r.Context(), objectKey)
 		if err != nil && !errors.Is(err, artifacts.ErrNotConfigured) {
 			result.FailedArtifactObjectDelete++
-			log.Printf("failed to delete artifact object key=%s err=%v", objectKey, err)
+			slog.ErrorContext(r.Context(), "artifact object delete failed", slog.String("object_key", objectKey), logging.Err(err))
 		}
 	}
 	h.metrics.cleanupRunsTotal.Add(1)
-	h.metrics.cleanupEventObjectsTotal.Add(int64(result.DeletedEventObjects))
-	h.metrics.cleanupArtifactObjectsTotal.Add(int64(result.DeletedArtifactObjects))
+	h.metrics.cleanupEventObjectsTotal.Add(float64(result.DeletedEventObjects))
+	h.metrics.cleanupArtifactObjectsTotal.Add(float64(result.DeletedArtifactObjects))
 
 	writeJSON(w, http.StatusOK, result)
 }
@@ -569,8 +723,11 @@ func (h *Handler) getSession(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) getSessionEvents(w http.ResponseWriter, r *http.Request) {
-	sessionID := chi.URLParam(r, "sessionID")
-	session, err := h.loadSession(r.Context(), h.projectIDFromContext(r.Context()), sessionID)
+	h.writeSessionEvents(w, r, h.projectIDFromContext(r.Context()), chi.URLParam(r, "sessionID"))
+}
+
+func (h *Handler) writeSessionEvents(w http.ResponseWriter, r *http.Request, projectID, sessionID string) {
+	session, err := h.loadSession(r.Context(), projectID, sessionID)
 	if err != nil {
 		writeLookupError(w, err)
 		return
@@ -625,14 +782,17 @@ func (h *Handler) createArtifactToken(w http.ResponseWriter, r *http.Request) {
 	}
 
 	expiresAt := time.Now().UTC().Add(h.artifactTokenTTL)
-	token, err := h.signArtifactToken(projectID, sessionID, artifactType, expiresAt)
+	token, claims, err := h.signArtifactToken(projectID, sessionID, artifactType, expiresAt, h.artifactTokenSingleUse(r))
 	if err != nil {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "token generation failed"})
 		return
 	}
 
+	annotateAuditTarget(r.Context(), "artifact_token", claims.ID)
 	writeJSON(w, http.StatusOK, map[string]any{
 		"token":     token,
+		"tokenId":   claims.ID,
+		"singleUse": claims.SingleUse,
 		"expiresAt": expiresAt.Format(time.RFC3339),
 	})
 }
@@ -648,10 +808,21 @@ func (h *Handler) getSessionArtifact(w http.ResponseWriter, r *http.Request) {
 	projectID := h.projectIDFromContext(r.Context())
 	artifactToken := strings.TrimSpace(r.URL.Query().Get("artifactToken"))
 	if artifactToken != "" {
-		claims, err := h.verifyArtifactToken(artifactToken)
-		if err != nil {
+		claims, err := h.verifyArtifactToken(r.Context(), artifactToken)
+		switch {
+		case errors.Is(err, errRevokedArtifactToken):
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "artifact token revoked"})
+			return
+		case errors.Is(err, errRedeemedArtifactToken):
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "artifact token already used"})
+			return
+		case errors.Is(err, errInvalidArtifactToken):
 			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid artifact token"})
 			return
+		case err != nil:
+			slog.ErrorContext(r.Context(), "artifact token verification failed", slog.String(logging.SessionIDKey, sessionID), logging.Err(err))
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "artifact token verification unavailable"})
+			return
 		}
 		if claims.SessionID != sessionID || claims.ArtifactType != artifactType {
 			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "artifact token scope mismatch"})
@@ -661,8 +832,15 @@ func (h *Handler) getSessionArtifact(w http.ResponseWriter, r *http.Request) {
 	} else if strings.TrimSpace(h.ingestAPIKey) != "" && !h.keyAuthenticatedFromContext(r.Context()) {
 		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
 		return
+	} else if !h.hasScope(r.Context(), store.APIKeyScopeRead) {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "missing scope: " + store.APIKeyScopeRead})
+		return
 	}
 
+	h.writeSessionArtifact(w, r, projectID, sessionID, artifactType)
+}
+
+func (h *Handler) writeSessionArtifact(w http.ResponseWriter, r *http.Request, projectID, sessionID, artifactType string) {
 	session, err := h.loadSession(r.Context(), projectID, sessionID)
 	if err != nil {
 		writeLookupError(w, err)
@@ -786,15 +964,15 @@ func buildAnalysisJob(session store.Session) (queue.AnalysisJob, bool) {
 	}, true
 }
 
-func strongerTrigger(current, candidate string) string {
-	weight := map[string]int{
-		"ui_no_effect":      1,
-		"validation_failed": 2,
-		"api_error":         3,
-		"js_exception":      4,
-	}
+var triggerWeights = map[string]int{
+	"ui_no_effect":      1,
+	"validation_failed": 2,
+	"api_error":         3,
+	"js_exception":      4,
+}
 
-	if weight[candidate] > weight[current] {
+func strongerTrigger(current, candidate string) string {
+	if triggerWeights[candidate] > triggerWeights[current] {
 		return candidate
 	}
 	return current
@@ -816,70 +994,111 @@ func (h *Handler) withProjectContextMode(next http.Handler, allowQueryKey bool)
 		}
 		projectID := defaultProjectID
 		authenticated := false
+		scopes := h.anonymousScopes()
+		subject := ""
+		userID := ""
+		keyID := ""
+		token := ""
+		if h.jwtVerifier != nil && provided == "" {
+			token = bearerToken(r)
+		}
 
 		switch {
+		case token != "":
+			principal, status, message := h.resolveBearerPrincipal(r, token)
+			if status != 0 {
+				writeJSON(w, status, map[string]string{"error": message})
+				return
+			}
+			projectID = principal.ProjectID
+			authenticated = true
+			scopes = principal.Scopes
+			subject = principal.Subject
+			userID = principal.UserID
 		case provided == "":
 			// anonymous read access falls back to default project.
 		case strings.TrimSpace(h.ingestAPIKey) != "" && provided == h.ingestAPIKey:
 			authenticated = true
+			scopes = h.ingestAPIKeyScopes()
+			keyID = globalIngestKeyID
 		default:
-			resolvedProjectID, err := h.store.ResolveProjectIDByAPIKey(r.Context(), provided)
+			resolved, err := h.store.ResolveAPIKey(r.Context(), provided)
 			if err != nil {
 				writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
 				return
 			}
-			projectID = resolvedProjectID
+			projectID = resolved.ProjectID
 			authenticated = true
+			scopes = resolved.Scopes
+			keyID = resolved.ID
 		}
 
+		annotateMetricsProject(r.Context(), projectID)
+		annotateRequestSession(r.Context(), projectID, "")
 		ctx := context.WithValue(r.Context(), projectIDContextKey, projectID)
 		ctx = context.WithValue(ctx, keyAuthenticatedContext, authenticated)
+		ctx = context.WithValue(ctx, apiKeyScopesContext, scopes)
+		ctx = context.WithValue(ctx, authSubjectContext, subject)
+		ctx = context.WithValue(ctx, authUserContext, userID)
+		ctx = context.WithValue(ctx, apiKeyIDContext, keyID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
-func (h *Handler) requireWriteAccess(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if strings.TrimSpace(h.ingestAPIKey) == "" {
-			next.ServeHTTP(w, r)
-			return
-		}
-
-		if h.keyAuthenticatedFromContext(r.Context()) {
-			next.ServeHTTP(w, r)
-			return
-		}
+// requireScope admits key-authenticated requests whose key carries scope, and
+// anonymous requests only when the anonymous scope set includes it.
+func (h *Handler) requireScope(scope string) func(http.Handler) http.Handler {
+	return h.scopeMiddleware(scope, false)
+}
 
-		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
-	})
+func (h *Handler) requireKeyScope(scope string) func(http.Handler) http.Handler {
+	return h.scopeMiddleware(scope, true)
 }
 
-func (h *Handler) requireKeyAccess(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if h.keyAuthenticatedFromContext(r.Context()) {
-			next.ServeHTTP(w, r)
-			return
-		}
+func (h *Handler) scopeMiddleware(scope string, requireKey bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authenticated := h.keyAuthenticatedFromContext(r.Context())
+			if requireKey && !authenticated {
+				writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+				return
+			}
 
-		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
-	})
+			if h.hasScope(r.Context(), scope) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !authenticated {
+				writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+				return
+			}
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "missing scope: " + scope})
+		})
+	}
 }
 
-func (h *Handler) requireInternalAccess(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if strings.TrimSpace(h.internalAPIKey) == "" {
-			writeJSON(w, http.StatusForbidden, map[string]string{"error": "internal endpoints disabled"})
-			return
-		}
+func (h *Handler) hasScope(ctx context.Context, scope string) bool {
+	return store.HasAPIKeyScope(h.scopesFromContext(ctx), scope)
+}
 
-		provided := strings.TrimSpace(r.Header.Get("X-Retrospec-Internal"))
-		if provided == h.internalAPIKey {
-			next.ServeHTTP(w, r)
-			return
-		}
+// ingestAPIKeyScopes limits INGEST_API_KEY to ingest, since it is the key
+// embedded in browsers, unless INGEST_API_KEY_FULL_ACCESS opts back in.
+func (h *Handler) ingestAPIKeyScopes() []string {
+	if h.ingestAPIKeyFullAccess {
+		return store.AllAPIKeyScopes
+	}
+	return []string{store.APIKeyScopeIngest}
+}
 
-		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
-	})
+func (h *Handler) anonymousScopes() []string {
+	if h.forbidAnonymous {
+		return nil
+	}
+	if strings.TrimSpace(h.ingestAPIKey) == "" {
+		return store.AllAPIKeyScopes
+	}
+	return []string{store.APIKeyScopeRead}
 }
 
 func (h *Handler) projectIDFromContext(ctx context.Context) string {
@@ -890,6 +1109,14 @@ func (h *Handler) projectIDFromContext(ctx context.Context) string {
 	return value
 }
 
+func (h *Handler) scopesFromContext(ctx context.Context) []string {
+	value, ok := ctx.Value(apiKeyScopesContext).([]string)
+	if !ok {
+		return nil
+	}
+	return value
+}
+
 func (h *Handler) keyAuthenticatedFromContext(ctx context.Context) bool {
 	value, ok := ctx.Value(keyAuthenticatedContext).(bool)
 	return ok && value
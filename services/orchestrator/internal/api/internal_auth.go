@@ -0,0 +1,132 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"retrospec/services/orchestrator/internal/auth"
+	"retrospec/services/orchestrator/internal/logging"
+	"retrospec/services/orchestrator/internal/queue"
+)
+
+const (
+	maxInternalRequestBytes  = 8 << 20
+	maxInternalArtifactBytes = 128 << 20
+)
+
+// requireInternalAccess verifies HMAC-signed worker callbacks: the key ID must
+// be configured, the timestamp within the skew window, the body digest must
+// match and the nonce must not have been seen before.
+func (h *Handler) requireInternalAccess(next http.Handler) http.Handler {
+	return h.requireInternalAccessLimit(maxInternalRequestBytes)(next)
+}
+
+// requireInternalAccessLimit is requireInternalAccess with a custom body cap,
+// for artifact uploads that exceed the callback limit.
+func (h *Handler) requireInternalAccessLimit(maxBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return h.verifyInternalRequest(next, maxBytes)
+	}
+}
+
+func (h *Handler) verifyInternalRequest(next http.Handler, maxBytes int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(h.internalSigningKeys) == 0 {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "internal endpoints disabled"})
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBytes))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": "payload too large"})
+				return
+			}
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "could not read request body"})
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		signed := auth.SignedRequest{
+			Method:        r.Method,
+			RequestURI:    r.URL.RequestURI(),
+			KeyID:         strings.TrimSpace(r.Header.Get(auth.HeaderSignatureKeyID)),
+			Timestamp:     strings.TrimSpace(r.Header.Get(auth.HeaderTimestamp)),
+			Nonce:         strings.TrimSpace(r.Header.Get(auth.HeaderNonce)),
+			ContentSHA256: strings.TrimSpace(r.Header.Get(auth.HeaderContentSHA256)),
+			Signature:     strings.TrimSpace(r.Header.Get(auth.HeaderSignature)),
+		}
+		if err := auth.VerifySignedRequest(h.internalSigningKeys, signed, body, time.Now(), h.internalSignatureSkew); err != nil {
+			h.metrics.internalAuthFailuresTotal.Add(1)
+			slog.WarnContext(r.Context(), "internal request rejected", slog.String("path", r.URL.Path), slog.String("signing_key_id", signed.KeyID), logging.Err(err))
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			return
+		}
+
+		fresh, err := h.nonces.RememberNonce(r.Context(), "internal:"+signed.KeyID+":"+signed.Nonce, 2*h.internalSignatureSkew)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "internal nonce check failed", slog.String("path", r.URL.Path), logging.Err(err))
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "nonce store unavailable"})
+			return
+		}
+		if !fresh {
+			h.metrics.internalAuthFailuresTotal.Add(1)
+			slog.WarnContext(r.Context(), "internal request replay rejected", slog.String("path", r.URL.Path), slog.String("signing_key_id", signed.KeyID))
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "request already processed"})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// memoryNonceStore is the single-instance fallback when Redis is unavailable.
+type memoryNonceStore struct {
+	mu        sync.Mutex
+	expiresAt map[string]time.Time
+	lastSweep time.Time
+}
+
+func newMemoryNonceStore() *memoryNonceStore {
+	return &memoryNonceStore{expiresAt: map[string]time.Time{}}
+}
+
+func (s *memoryNonceStore) RememberNonce(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	if ttl <= 0 {
+		return false, errors.New("nonce ttl must be positive")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) > time.Minute {
+		for candidate, expiresAt := range s.expiresAt {
+			if now.After(expiresAt) {
+				delete(s.expiresAt, candidate)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	if expiresAt, exists := s.expiresAt[key]; exists && now.Before(expiresAt) {
+		return false, nil
+	}
+	s.expiresAt[key] = now.Add(ttl)
+	return true, nil
+}
+
+func nonceStoreFor(producer queue.Producer) queue.NonceStore {
+	if store, ok := producer.(queue.NonceStore); ok {
+		return store
+	}
+	return newMemoryNonceStore()
+}
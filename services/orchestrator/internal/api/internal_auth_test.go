@@ -0,0 +1,81 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"retrospec/services/orchestrator/internal/auth"
+)
+
+func TestRequireInternalAccessRejectsReplayedRequests(t *testing.T) {
+	keys := auth.SigningKeys{"k1": []byte("secret")}
+	handler := &Handler{
+		metrics:               newAPIMetrics(nil),
+		internalSigningKeys:   keys,
+		internalSignatureSkew: time.Minute,
+		nonces:                newMemoryNonceStore(),
+	}
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	body := `{"sessionId":"s1"}`
+	signed := auth.SignedRequest{
+		Method:        http.MethodPost,
+		RequestURI:    "/v1/internal/replay-results",
+		KeyID:         "k1",
+		Timestamp:     strconv.FormatInt(time.Now().Unix(), 10),
+		Nonce:         "nonce-0123456789abcdef",
+		ContentSHA256: auth.ContentSHA256([]byte(body)),
+	}
+	signed.Signature = auth.Sign(keys["k1"], signed)
+
+	send := func() int {
+		req := httptest.NewRequest(http.MethodPost, signed.RequestURI, strings.NewReader(body))
+		req.Header.Set(auth.HeaderSignatureKeyID, signed.KeyID)
+		req.Header.Set(auth.HeaderTimestamp, signed.Timestamp)
+		req.Header.Set(auth.HeaderNonce, signed.Nonce)
+		req.Header.Set(auth.HeaderContentSHA256, signed.ContentSHA256)
+		req.Header.Set(auth.HeaderSignature, signed.Signature)
+		recorder := httptest.NewRecorder()
+		handler.requireInternalAccess(next).ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	if code := send(); code != http.StatusAccepted {
+		t.Fatalf("expected signed request to pass, got %d", code)
+	}
+	if code := send(); code != http.StatusUnauthorized {
+		t.Fatalf("expected replayed request to be rejected, got %d", code)
+	}
+}
+
+type failingBody struct{}
+
+func (failingBody) Read([]byte) (int, error) { return 0, io.ErrUnexpectedEOF }
+
+func TestRequireInternalAccessReportsOnlyOversizedBodiesAsTooLarge(t *testing.T) {
+	handler := &Handler{internalSigningKeys: auth.SigningKeys{"k1": []byte("secret")}}
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	send := func(body io.Reader) int {
+		req := httptest.NewRequest(http.MethodPost, "/v1/internal/replay-results", body)
+		recorder := httptest.NewRecorder()
+		handler.requireInternalAccessLimit(16)(next).ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	if code := send(strings.NewReader(strings.Repeat("x", 17))); code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected oversized body to be rejected with 413, got %d", code)
+	}
+	if code := send(failingBody{}); code != http.StatusBadRequest {
+		t.Fatalf("expected broken body to be rejected with 400, got %d", code)
+	}
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+
+	"retrospec/services/orchestrator/internal/logging"
+	"retrospec/services/orchestrator/internal/queue"
+	"retrospec/services/orchestrator/internal/store"
+)
+
+type updateProjectTierRequest struct {
+	Tier string `json:"tier"`
+}
+
+func (h *Handler) updateProjectTier(w http.ResponseWriter, r *http.Request) {
+	payload := updateProjectTierRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid payload"})
+		return
+	}
+
+	tier, err := store.NormalizeProjectTier(payload.Tier)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	project, err := h.store.UpdateProjectTier(r.Context(), chi.URLParam(r, "projectID"), tier)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "project not found"})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "tier update failed"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"project": project})
+}
+
+// jobLane picks the queue lane for a session's jobs. A failed lookup only
+// loses the tier and cluster adjustments; the job is still enqueued.
+func (h *Handler) jobLane(ctx context.Context, session store.Session, triggerKind string) queue.Lane {
+	clusterKeys := sessionClusterKeys(session)
+	tier, states, err := h.store.JobPriorityInputs(ctx, session.ProjectID, clusterKeys)
+	if err != nil {
+		slog.WarnContext(ctx, "job lane lookup failed, using trigger lane", logging.Err(err))
+		tier, states = store.ProjectTierStandard, nil
+	}
+	return laneFor(triggerKind, tier, clusterKeys, states)
+}
+
+// laneFor starts from the trigger weight: crashes and API errors go high,
+// validation failures default, no-effect clicks low. A session hitting a
+// resolved cluster is a regression and moves up a lane; one whose clusters
+// are all muted moves down. The project tier then moves it once more.
+func laneFor(triggerKind, tier string, clusterKeys []string, clusterStates map[string]string) queue.Lane {
+	lane := queue.LaneLow
+	switch weight := triggerWeights[triggerKind]; {
+	case weight >= triggerWeights["api_error"]:
+		lane = queue.LaneHigh
+	case weight == triggerWeights["validation_failed"]:
+		lane = queue.LaneDefault
+	}
+
+	regressed := false
+	muted := len(clusterKeys) > 0
+	for _, clusterKey := range clusterKeys {
+		switch clusterStates[clusterKey] {
+		case "resolved":
+			regressed = true
+			muted = false
+		case "muted":
+		default:
+			muted = false
+		}
+	}
+	if regressed {
+		lane = lane.Promote()
+	} else if muted {
+		lane = lane.Demote()
+	}
+
+	switch tier {
+	case store.ProjectTierPriority:
+		lane = lane.Promote()
+	case store.ProjectTierBulk:
+		lane = lane.Demote()
+	}
+	return lane
+}
+
+func sessionClusterKeys(session store.Session) []string {
+	seen := map[string]struct{}{}
+	keys := make([]string, 0, len(session.Markers))
+	for _, marker := range session.Markers {
+		if marker.ClusterKey == "" {
+			continue
+		}
+		if _, ok := seen[marker.ClusterKey]; ok {
+			continue
+		}
+		seen[marker.ClusterKey] = struct{}{}
+		keys = append(keys, marker.ClusterKey)
+	}
+	return keys
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"testing"
+
+	"retrospec/services/orchestrator/internal/queue"
+	"retrospec/services/orchestrator/internal/store"
+)
+
+func TestLaneFor(t *testing.T) {
+	cases := []struct {
+		name        string
+		triggerKind string
+		tier        string
+		clusterKeys []string
+		states      map[string]string
+		expected    queue.Lane
+	}{
+		{name: "crash", triggerKind: "js_exception", tier: store.ProjectTierStandard, expected: queue.LaneHigh},
+		{name: "api error", triggerKind: "api_error", tier: store.ProjectTierStandard, expected: queue.LaneHigh},
+		{name: "validation", triggerKind: "validation_failed", tier: store.ProjectTierStandard, expected: queue.LaneDefault},
+		{name: "no effect", triggerKind: "ui_no_effect", tier: store.ProjectTierStandard, expected: queue.LaneLow},
+		{name: "unknown trigger", triggerKind: "other", tier: store.ProjectTierStandard, expected: queue.LaneLow},
+		{name: "priority tier", triggerKind: "ui_no_effect", tier: store.ProjectTierPriority, expected: queue.LaneDefault},
+		{name: "bulk tier", triggerKind: "js_exception", tier: store.ProjectTierBulk, expected: queue.LaneDefault},
+		{
+			name:        "regression",
+			triggerKind: "validation_failed",
+			tier:        store.ProjectTierStandard,
+			clusterKeys: []string{"a", "b"},
+			states:      map[string]string{"a": "muted", "b": "resolved"},
+			expected:    queue.LaneHigh,
+		},
+		{
+			name:        "all muted",
+			triggerKind: "js_exception",
+			tier:        store.ProjectTierBulk,
+			clusterKeys: []string{"a", "b"},
+			states:      map[string]string{"a": "muted", "b": "muted"},
+			expected:    queue.LaneLow,
+		},
+		{
+			name:        "partly muted",
+			triggerKind: "validation_failed",
+			tier:        store.ProjectTierStandard,
+			clusterKeys: []string{"a", "b"},
+			states:      map[string]string{"a": "muted"},
+			expected:    queue.LaneDefault,
+		},
+	}
+	for _, tc := range cases {
+		if lane := laneFor(tc.triggerKind, tc.tier, tc.clusterKeys, tc.states); lane != tc.expected {
+			t.Fatalf("%s: expected lane %s, got %s", tc.name, tc.expected, lane)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+
+	"retrospec/services/orchestrator/internal/artifacts"
+	"retrospec/services/orchestrator/internal/logging"
+	"retrospec/services/orchestrator/internal/store"
+)
+
+type placeLegalHoldRequest struct {
+	TargetType string `json:"targetType"`
+	TargetID   string `json:"targetId"`
+	Reason     string `json:"reason"`
+	CreatedBy  string `json:"createdBy"`
+	ExpiresAt  string `json:"expiresAt"`
+}
+
+type releaseLegalHoldRequest struct {
+	ReleasedBy string `json:"releasedBy"`
+}
+
+type retentionTagResult struct {
+	ObjectsTagged int `json:"objectsTagged"`
+	TagFailures   int `json:"tagFailures"`
+}
+
+func (h *Handler) placeLegalHold(w http.ResponseWriter, r *http.Request) {
+	payload := placeLegalHoldRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid payload"})
+		return
+	}
+
+	targetType := strings.TrimSpace(payload.TargetType)
+	targetID := strings.TrimSpace(payload.TargetID)
+	if targetType != store.LegalHoldTargetSession && targetType != store.LegalHoldTargetIssueCluster {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "targetType must be one of: session, issue_cluster"})
+		return
+	}
+	if targetID == "" || strings.TrimSpace(payload.Reason) == "" || strings.TrimSpace(payload.CreatedBy) == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "targetId, reason and createdBy are required"})
+		return
+	}
+
+	var expiresAt *time.Time
+	if candidate := strings.TrimSpace(payload.ExpiresAt); candidate != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, candidate)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "expiresAt must be RFC3339 timestamp"})
+			return
+		}
+		if !parsed.After(time.Now()) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "expiresAt must be in the future"})
+			return
+		}
+		expiresAt = &parsed
+	}
+
+	projectID := h.projectIDFromContext(r.Context())
+	switch targetType {
+	case store.LegalHoldTargetSession:
+		if _, err := h.loadSession(r.Context(), projectID, targetID); err != nil {
+			writeLookupError(w, err)
+			return
+		}
+	case store.LegalHoldTargetIssueCluster:
+		exists, err := h.store.IssueClusterExists(r.Context(), projectID, targetID)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "lookup failed"})
+			return
+		}
+		if !exists {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "issue cluster not found"})
+			return
+		}
+	}
+
+	hold, err := h.store.PlaceLegalHold(
+		r.Context(),
+		projectID,
+		targetType,
+		targetID,
+		payload.Reason,
+		payload.CreatedBy,
+		expiresAt,
+	)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "legal hold creation failed"})
+		return
+	}
+
+	annotateAuditTarget(r.Context(), "legal_hold", hold.ID)
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"hold":      hold,
+		"retention": h.syncLegalHoldRetentionTags(r.Context(), hold),
+	})
+}
+
+func (h *Handler) listLegalHolds(w http.ResponseWriter, r *http.Request) {
+	targetType := strings.TrimSpace(r.URL.Query().Get("targetType"))
+	if targetType != "" && targetType != store.LegalHoldTargetSession && targetType != store.LegalHoldTargetIssueCluster {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "targetType must be one of: session, issue_cluster"})
+		return
+	}
+	includeInactive := strings.TrimSpace(r.URL.Query().Get("includeInactive")) == "true"
+
+	holds, err := h.store.ListLegalHolds(r.Context(), h.projectIDFromContext(r.Context()), targetType, includeInactive)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "legal hold lookup failed"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"holds":           holds,
+		"targetType":      targetType,
+		"includeInactive": includeInactive,
+	})
+}
+
+func (h *Handler) releaseLegalHold(w http.ResponseWriter, r *http.Request) {
+	payload := releaseLegalHoldRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid payload"})
+		return
+	}
+	if strings.TrimSpace(payload.ReleasedBy) == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "releasedBy is required"})
+		return
+	}
+
+	holdID := strings.TrimSpace(chi.URLParam(r, "holdID"))
+	hold, err := h.store.ReleaseLegalHold(r.Context(), h.projectIDFromContext(r.Context()), holdID, payload.ReleasedBy)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "legal hold not found"})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "legal hold release failed"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"hold":      hold,
+		"retention": h.syncLegalHoldRetentionTags(r.Context(), hold),
+	})
+}
+
+func (h *Handler) syncLegalHoldRetentionTags(ctx context.Context, hold store.LegalHold) retentionTagResult {
+	sessionIDs, err := h.store.ListLegalHoldSessionIDs(ctx, hold)
+	if err != nil {
+		slog.ErrorContext(ctx, "legal hold session lookup failed", slog.String("legal_hold_id", hold.ID), logging.Err(err))
+		return retentionTagResult{TagFailures: 1}
+	}
+
+	result := retentionTagResult{}
+	for _, sessionID := range sessionIDs {
+		tagged, failed := h.syncSessionRetentionTags(ctx, hold.ProjectID, sessionID, false)
+		result.ObjectsTagged += tagged
+		result.TagFailures += failed
+	}
+	return result
+}
+
+// syncSessionRetentionTags re-tags a session's objects so the S3 lifecycle rule
+// skips them while any hold covers the session. With onlyHeld, sessions without
+// a hold are left untouched to avoid tagging calls on the ingest path. A partial
+// failure clears the session's recorded tag so the retention tag sync retries it.
+func (h *Handler) syncSessionRetentionTags(
+	ctx context.Context,
+	projectID string,
+	sessionID string,
+	onlyHeld bool,
+) (int, int) {
+	holder, ok := h.artifactStore.(artifacts.RetentionHolder)
+	if !ok {
+		return 0, 0
+	}
+
+	held, err := h.store.SessionUnderLegalHold(ctx, projectID, sessionID)
+	if err != nil {
+		slog.ErrorContext(ctx, "legal hold check failed", slog.String(logging.SessionIDKey, sessionID), logging.Err(err))
+		return 0, 1
+	}
+	if onlyHeld && !held {
+		return 0, 0
+	}
+
+	session, err := h.loadSession(ctx, projectID, sessionID)
+	if err != nil {
+		slog.ErrorContext(ctx, "legal hold session load failed", slog.String(logging.SessionIDKey, sessionID), logging.Err(err))
+		return 0, 1
+	}
+
+	objectKeys := make([]string, 0, len(session.Artifacts)+1)
+	if session.EventsObjectKey != "" {
+		objectKeys = append(objectKeys, session.EventsObjectKey)
+	}
+	for _, artifact := range session.Artifacts {
+		if strings.TrimSpace(artifact.ArtifactKey) != "" {
+			objectKeys = append(objectKeys, artifact.ArtifactKey)
+		}
+	}
+
+	tagged := 0
+	failed := 0
+	for _, objectKey := range objectKeys {
+		err := holder.SetObjectRetentionHold(ctx, objectKey, held)
+		if errors.Is(err, artifacts.ErrNotConfigured) {
+			return tagged, failed
+		}
+		if errors.Is(err, artifacts.ErrObjectNotFound) {
+			continue
+		}
+		if err != nil {
+			failed++
+			slog.ErrorContext(ctx, "legal hold tagging failed", slog.String(logging.SessionIDKey, sessionID), slog.String("object_key", objectKey), logging.Err(err))
+			continue
+		}
+		tagged++
+	}
+
+	retentionTag := artifacts.RetentionTagExpire
+	if held {
+		retentionTag = artifacts.RetentionTagHold
+	}
+	if failed > 0 {
+		retentionTag = ""
+	}
+	if err := h.store.MarkSessionRetentionTag(ctx, projectID, sessionID, retentionTag); err != nil {
+		slog.ErrorContext(ctx, "retention tag record failed", slog.String(logging.SessionIDKey, sessionID), logging.Err(err))
+	}
+	return tagged, failed
+}
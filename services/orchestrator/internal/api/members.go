@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+
+	"retrospec/services/orchestrator/internal/store"
+)
+
+type inviteMemberRequest struct {
+	Email       string `json:"email"`
+	DisplayName string `json:"displayName"`
+	Role        string `json:"role"`
+}
+
+func (h *Handler) listProjectMembers(w http.ResponseWriter, r *http.Request) {
+	projectID := h.projectIDFromContext(r.Context())
+	members, err := h.store.ListProjectMemberships(r.Context(), projectID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "member lookup failed"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"projectId": projectID,
+		"members":   members,
+	})
+}
+
+func (h *Handler) inviteProjectMember(w http.ResponseWriter, r *http.Request) {
+	h.writeMemberInvite(w, r, h.projectIDFromContext(r.Context()), h.actorFromContext(r.Context()))
+}
+
+// inviteProjectMemberAsAdmin bootstraps a project's first member admin through
+// the global admin API.
+func (h *Handler) inviteProjectMemberAsAdmin(w http.ResponseWriter, r *http.Request) {
+	project, ok := h.loadAdminProject(w, r)
+	if !ok {
+		return
+	}
+	h.writeMemberInvite(w, r, project.ID, "admin_api")
+}
+
+func (h *Handler) writeMemberInvite(w http.ResponseWriter, r *http.Request, projectID, invitedBy string) {
+	payload := inviteMemberRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid payload"})
+		return
+	}
+	if store.NormalizeRole(payload.Role) == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "role must be one of: viewer, triager, admin"})
+		return
+	}
+	email := strings.TrimSpace(payload.Email)
+	if email == "" || !strings.Contains(email, "@") {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "a valid email is required"})
+		return
+	}
+
+	membership, err := h.store.InviteProjectMember(
+		r.Context(),
+		projectID,
+		email,
+		payload.DisplayName,
+		payload.Role,
+		invitedBy,
+	)
+	if err != nil {
+		if errors.Is(err, store.ErrLastProjectAdmin) {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "member invite failed"})
+		return
+	}
+
+	annotateAuditTarget(r.Context(), "user", membership.UserID)
+	writeJSON(w, http.StatusCreated, map[string]any{"member": membership})
+}
+
+func (h *Handler) removeProjectMember(w http.ResponseWriter, r *http.Request) {
+	membership, err := h.store.RemoveProjectMember(
+		r.Context(),
+		h.projectIDFromContext(r.Context()),
+		strings.TrimSpace(chi.URLParam(r, "userID")),
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "member not found"})
+			return
+		}
+		if errors.Is(err, store.ErrLastProjectAdmin) {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "member removal failed"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"removed": membership})
+}
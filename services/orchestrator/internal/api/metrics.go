@@ -2,123 +2,261 @@ package api
 
 import (
 	"context"
-	"fmt"
 	"net/http"
-	"sync/atomic"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"retrospec/services/orchestrator/internal/metrics"
 	"retrospec/services/orchestrator/internal/queue"
 )
 
+const (
+	defaultMaxProjectLabels = 50
+	otherProjectLabel       = "other"
+
+	requestMetricsContext = requestContextKey("request_metrics")
+)
+
 type apiMetrics struct {
-	startedAtUnix               int64
+	registry                    *prometheus.Registry
 	queueStatsProvider          queue.StatsProvider
-	ingestSessionsTotal         atomic.Int64
-	replayQueueErrorsTotal      atomic.Int64
-	analysisQueueErrorsTotal    atomic.Int64
-	replayArtifactsTotal        atomic.Int64
-	analysisReportsTotal        atomic.Int64
-	cleanupRunsTotal            atomic.Int64
-	cleanupEventObjectsTotal    atomic.Int64
-	cleanupArtifactObjectsTotal atomic.Int64
-	rateLimitedTotal            atomic.Int64
-	queueMetricsErrorsTotal     atomic.Int64
+	projectLabels               *boundedLabels
+	ingestSessionsTotal         prometheus.Counter
+	replayQueueErrorsTotal      prometheus.Counter
+	analysisQueueErrorsTotal    prometheus.Counter
+	replayArtifactsTotal        prometheus.Counter
+	analysisReportsTotal        prometheus.Counter
+	cleanupRunsTotal            prometheus.Counter
+	cleanupEventObjectsTotal    prometheus.Counter
+	cleanupArtifactObjectsTotal prometheus.Counter
+	rateLimitedTotal            prometheus.Counter
+	rateLimitErrorsTotal        prometheus.Counter
+	blockedOriginsTotal         prometheus.Counter
+	internalAuthFailuresTotal   prometheus.Counter
+	queueMetricsErrorsTotal     prometheus.Counter
+	httpRequestDuration         *prometheus.HistogramVec
+	projectRequestsTotal        *prometheus.CounterVec
+	projectIngestSessionsTotal  *prometheus.CounterVec
+	pipelineStageDuration       *prometheus.HistogramVec
+}
+
+// requestMetrics is filled in by inner middleware so the outer HTTP metrics
+// middleware can label the request with the resolved project.
+type requestMetrics struct {
+	projectID string
 }
 
 func newAPIMetrics(queueStatsProvider queue.StatsProvider) *apiMetrics {
-	return &apiMetrics{
-		startedAtUnix:      time.Now().Unix(),
-		queueStatsProvider: queueStatsProvider,
+	return newAPIMetricsWithProjectLimit(queueStatsProvider, defaultMaxProjectLabels)
+}
+
+func newAPIMetricsWithProjectLimit(queueStatsProvider queue.StatsProvider, maxProjectLabels int) *apiMetrics {
+	counter := func(name, help string) prometheus.Counter {
+		return prometheus.NewCounter(prometheus.CounterOpts{Name: name, Help: help})
+	}
+
+	m := &apiMetrics{
+		registry:                    prometheus.NewRegistry(),
+		queueStatsProvider:          queueStatsProvider,
+		projectLabels:               newBoundedLabels(maxProjectLabels),
+		ingestSessionsTotal:         counter("retrospec_ingest_sessions_total", "Number of accepted ingest sessions."),
+		replayQueueErrorsTotal:      counter("retrospec_replay_queue_errors_total", "Replay enqueue failures."),
+		analysisQueueErrorsTotal:    counter("retrospec_analysis_queue_errors_total", "Analysis enqueue failures."),
+		replayArtifactsTotal:        counter("retrospec_replay_artifacts_total", "Reported replay artifacts from workers."),
+		analysisReportsTotal:        counter("retrospec_analysis_reports_total", "Reported session analysis cards from workers."),
+		cleanupRunsTotal:            counter("retrospec_cleanup_runs_total", "Cleanup runs executed."),
+		cleanupEventObjectsTotal:    counter("retrospec_cleanup_event_objects_total", "Event objects deleted by cleanup."),
+		cleanupArtifactObjectsTotal: counter("retrospec_cleanup_artifact_objects_total", "Replay artifact objects deleted by cleanup."),
+		rateLimitedTotal:            counter("retrospec_rate_limited_total", "Requests rejected due to rate limiting."),
+		rateLimitErrorsTotal:        counter("retrospec_rate_limit_errors_total", "Rate limit checks that fell back to per-replica limits because the shared store failed."),
+		blockedOriginsTotal:         counter("retrospec_blocked_origins_total", "Ingest requests rejected because Origin is not allowed for the project."),
+		internalAuthFailuresTotal:   counter("retrospec_internal_auth_failures_total", "Internal callbacks rejected for bad signatures, skew or replayed nonces."),
+		queueMetricsErrorsTotal:     counter("retrospec_queue_metrics_errors_total", "Queue metrics collection errors."),
+		httpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "retrospec_http_request_duration_seconds",
+			Help:    "HTTP request duration by route pattern, method and status code.",
+			Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 15},
+		}, []string{"route", "method", "status"}),
+		projectRequestsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "retrospec_project_http_requests_total",
+			Help: "HTTP requests per project and status class; projects beyond the label limit are reported as \"other\".",
+		}, []string{"project", "status_class"}),
+		projectIngestSessionsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "retrospec_project_ingest_sessions_total",
+			Help: "Accepted ingest sessions per project; projects beyond the label limit are reported as \"other\".",
+		}, []string{"project"}),
+		pipelineStageDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "retrospec_pipeline_stage_seconds",
+			Help:    "Time from session ingest until the session first reached each pipeline stage.",
+			Buckets: []float64{1, 5, 15, 30, 60, 120, 300, 600, 900, 1800, 3600},
+		}, []string{"stage"}),
+	}
+
+	startedAt := time.Now()
+	m.registry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "retrospec_uptime_seconds",
+			Help: "Process uptime in seconds.",
+		}, func() float64 { return float64(int64(time.Since(startedAt).Seconds())) }),
+		m.ingestSessionsTotal,
+		m.replayQueueErrorsTotal,
+		m.analysisQueueErrorsTotal,
+		m.replayArtifactsTotal,
+		m.analysisReportsTotal,
+		m.cleanupRunsTotal,
+		m.cleanupEventObjectsTotal,
+		m.cleanupArtifactObjectsTotal,
+		m.rateLimitedTotal,
+		m.rateLimitErrorsTotal,
+		m.blockedOriginsTotal,
+		m.internalAuthFailuresTotal,
+		m.queueMetricsErrorsTotal,
+		m.httpRequestDuration,
+		m.projectRequestsTotal,
+		m.projectIngestSessionsTotal,
+		m.pipelineStageDuration,
+	)
+	if queueStatsProvider != nil {
+		m.registry.MustRegister(&queueStatsCollector{metrics: m})
 	}
+	return m
 }
 
 func (m *apiMetrics) handleMetrics(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
-	w.WriteHeader(http.StatusOK)
-
-	uptimeSeconds := time.Now().Unix() - m.startedAtUnix
-	_, _ = fmt.Fprintf(w, "# HELP retrospec_uptime_seconds Process uptime in seconds.\n")
-	_, _ = fmt.Fprintf(w, "# TYPE retrospec_uptime_seconds gauge\n")
-	_, _ = fmt.Fprintf(w, "retrospec_uptime_seconds %d\n", uptimeSeconds)
-
-	_, _ = fmt.Fprintf(w, "# HELP retrospec_ingest_sessions_total Number of accepted ingest sessions.\n")
-	_, _ = fmt.Fprintf(w, "# TYPE retrospec_ingest_sessions_total counter\n")
-	_, _ = fmt.Fprintf(w, "retrospec_ingest_sessions_total %d\n", m.ingestSessionsTotal.Load())
-
-	_, _ = fmt.Fprintf(w, "# HELP retrospec_replay_queue_errors_total Replay enqueue failures.\n")
-	_, _ = fmt.Fprintf(w, "# TYPE retrospec_replay_queue_errors_total counter\n")
-	_, _ = fmt.Fprintf(w, "retrospec_replay_queue_errors_total %d\n", m.replayQueueErrorsTotal.Load())
-
-	_, _ = fmt.Fprintf(w, "# HELP retrospec_analysis_queue_errors_total Analysis enqueue failures.\n")
-	_, _ = fmt.Fprintf(w, "# TYPE retrospec_analysis_queue_errors_total counter\n")
-	_, _ = fmt.Fprintf(w, "retrospec_analysis_queue_errors_total %d\n", m.analysisQueueErrorsTotal.Load())
-
-	_, _ = fmt.Fprintf(w, "# HELP retrospec_replay_artifacts_total Reported replay artifacts from workers.\n")
-	_, _ = fmt.Fprintf(w, "# TYPE retrospec_replay_artifacts_total counter\n")
-	_, _ = fmt.Fprintf(w, "retrospec_replay_artifacts_total %d\n", m.replayArtifactsTotal.Load())
-
-	_, _ = fmt.Fprintf(w, "# HELP retrospec_analysis_reports_total Reported session analysis cards from workers.\n")
-	_, _ = fmt.Fprintf(w, "# TYPE retrospec_analysis_reports_total counter\n")
-	_, _ = fmt.Fprintf(w, "retrospec_analysis_reports_total %d\n", m.analysisReportsTotal.Load())
-
-	_, _ = fmt.Fprintf(w, "# HELP retrospec_cleanup_runs_total Cleanup runs executed.\n")
-	_, _ = fmt.Fprintf(w, "# TYPE retrospec_cleanup_runs_total counter\n")
-	_, _ = fmt.Fprintf(w, "retrospec_cleanup_runs_total %d\n", m.cleanupRunsTotal.Load())
-
-	_, _ = fmt.Fprintf(w, "# HELP retrospec_cleanup_event_objects_total Event objects deleted by cleanup.\n")
-	_, _ = fmt.Fprintf(w, "# TYPE retrospec_cleanup_event_objects_total counter\n")
-	_, _ = fmt.Fprintf(w, "retrospec_cleanup_event_objects_total %d\n", m.cleanupEventObjectsTotal.Load())
-
-	_, _ = fmt.Fprintf(w, "# HELP retrospec_cleanup_artifact_objects_total Replay artifact objects deleted by cleanup.\n")
-	_, _ = fmt.Fprintf(w, "# TYPE retrospec_cleanup_artifact_objects_total counter\n")
-	_, _ = fmt.Fprintf(w, "retrospec_cleanup_artifact_objects_total %d\n", m.cleanupArtifactObjectsTotal.Load())
-
-	_, _ = fmt.Fprintf(w, "# HELP retrospec_rate_limited_total Requests rejected due to rate limiting.\n")
-	_, _ = fmt.Fprintf(w, "# TYPE retrospec_rate_limited_total counter\n")
-	_, _ = fmt.Fprintf(w, "retrospec_rate_limited_total %d\n", m.rateLimitedTotal.Load())
-
-	if m.queueStatsProvider != nil {
-		stats, err := m.loadQueueStats(r.Context())
-		if err != nil {
-			m.queueMetricsErrorsTotal.Add(1)
-		} else {
-			_, _ = fmt.Fprintf(w, "# HELP retrospec_replay_queue_stream_depth Replay stream entries waiting/retained in Redis stream.\n")
-			_, _ = fmt.Fprintf(w, "# TYPE retrospec_replay_queue_stream_depth gauge\n")
-			_, _ = fmt.Fprintf(w, "retrospec_replay_queue_stream_depth %d\n", stats.ReplayStreamDepth)
-
-			_, _ = fmt.Fprintf(w, "# HELP retrospec_replay_queue_pending_total Replay stream pending entries for consumer group.\n")
-			_, _ = fmt.Fprintf(w, "# TYPE retrospec_replay_queue_pending_total gauge\n")
-			_, _ = fmt.Fprintf(w, "retrospec_replay_queue_pending_total %d\n", stats.ReplayPending)
-
-			_, _ = fmt.Fprintf(w, "# HELP retrospec_replay_queue_retry_depth Replay retry zset depth.\n")
-			_, _ = fmt.Fprintf(w, "# TYPE retrospec_replay_queue_retry_depth gauge\n")
-			_, _ = fmt.Fprintf(w, "retrospec_replay_queue_retry_depth %d\n", stats.ReplayRetryDepth)
-
-			_, _ = fmt.Fprintf(w, "# HELP retrospec_replay_queue_failed_depth Replay dead-letter list depth.\n")
-			_, _ = fmt.Fprintf(w, "# TYPE retrospec_replay_queue_failed_depth gauge\n")
-			_, _ = fmt.Fprintf(w, "retrospec_replay_queue_failed_depth %d\n", stats.ReplayFailedDepth)
-
-			_, _ = fmt.Fprintf(w, "# HELP retrospec_analysis_queue_stream_depth Analysis stream entries waiting/retained in Redis stream.\n")
-			_, _ = fmt.Fprintf(w, "# TYPE retrospec_analysis_queue_stream_depth gauge\n")
-			_, _ = fmt.Fprintf(w, "retrospec_analysis_queue_stream_depth %d\n", stats.AnalysisStreamDepth)
-
-			_, _ = fmt.Fprintf(w, "# HELP retrospec_analysis_queue_pending_total Analysis stream pending entries for consumer group.\n")
-			_, _ = fmt.Fprintf(w, "# TYPE retrospec_analysis_queue_pending_total gauge\n")
-			_, _ = fmt.Fprintf(w, "retrospec_analysis_queue_pending_total %d\n", stats.AnalysisPending)
-
-			_, _ = fmt.Fprintf(w, "# HELP retrospec_analysis_queue_retry_depth Analysis retry zset depth.\n")
-			_, _ = fmt.Fprintf(w, "# TYPE retrospec_analysis_queue_retry_depth gauge\n")
-			_, _ = fmt.Fprintf(w, "retrospec_analysis_queue_retry_depth %d\n", stats.AnalysisRetryDepth)
-
-			_, _ = fmt.Fprintf(w, "# HELP retrospec_analysis_queue_failed_depth Analysis dead-letter list depth.\n")
-			_, _ = fmt.Fprintf(w, "# TYPE retrospec_analysis_queue_failed_depth gauge\n")
-			_, _ = fmt.Fprintf(w, "retrospec_analysis_queue_failed_depth %d\n", stats.AnalysisFailedDepth)
+	promhttp.HandlerFor(
+		prometheus.Gatherers{m.registry, metrics.Registry},
+		promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError},
+	).ServeHTTP(w, r)
+}
+
+// instrument records request duration by chi route pattern, which keeps the
+// label set bounded, plus per-project request counts.
+func (m *apiMetrics) instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		labels := &requestMetrics{}
+		recorder := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(recorder, r.WithContext(context.WithValue(r.Context(), requestMetricsContext, labels)))
+
+		status := recorder.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		route := "unmatched"
+		if routeContext := chi.RouteContext(r.Context()); routeContext != nil && routeContext.RoutePattern() != "" {
+			route = routeContext.RoutePattern()
+		}
+
+		m.httpRequestDuration.WithLabelValues(route, r.Method, strconv.Itoa(status)).Observe(time.Since(started).Seconds())
+		if labels.projectID != "" {
+			m.projectRequestsTotal.WithLabelValues(m.projectLabels.label(labels.projectID), strconv.Itoa(status/100)+"xx").Inc()
 		}
+	})
+}
+
+func (m *apiMetrics) recordIngestSession(projectID string) {
+	m.ingestSessionsTotal.Inc()
+	m.projectIngestSessionsTotal.WithLabelValues(m.projectLabels.label(projectID)).Inc()
+}
+
+// annotateMetricsProject tags the in-flight request with its project for the
+// per-project request counter.
+func annotateMetricsProject(ctx context.Context, projectID string) {
+	if labels, ok := ctx.Value(requestMetricsContext).(*requestMetrics); ok {
+		labels.projectID = projectID
 	}
+}
 
-	_, _ = fmt.Fprintf(w, "# HELP retrospec_queue_metrics_errors_total Queue metrics collection errors.\n")
-	_, _ = fmt.Fprintf(w, "# TYPE retrospec_queue_metrics_errors_total counter\n")
-	_, _ = fmt.Fprintf(w, "retrospec_queue_metrics_errors_total %d\n", m.queueMetricsErrorsTotal.Load())
+// boundedLabels hands out the first max distinct values as their own label and
+// folds the rest into "other" so per-project series cannot grow without limit.
+type boundedLabels struct {
+	mu     sync.Mutex
+	max    int
+	values map[string]struct{}
+}
+
+func newBoundedLabels(max int) *boundedLabels {
+	return &boundedLabels{max: max, values: map[string]struct{}{}}
+}
+
+func (b *boundedLabels) label(value string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.values[value]; ok {
+		return value
+	}
+	if len(b.values) >= b.max {
+		return otherProjectLabel
+	}
+	b.values[value] = struct{}{}
+	return value
+}
+
+// queueStatsCollector reads queue depths from Redis at scrape time.
+type queueStatsCollector struct {
+	metrics *apiMetrics
+}
+
+var (
+	replayStreamDepthDesc   = prometheus.NewDesc("retrospec_replay_queue_stream_depth", "Replay stream entries waiting/retained in Redis stream.", nil, nil)
+	replayPendingDesc       = prometheus.NewDesc("retrospec_replay_queue_pending_total", "Replay stream pending entries for consumer group.", nil, nil)
+	replayRetryDepthDesc    = prometheus.NewDesc("retrospec_replay_queue_retry_depth", "Replay retry zset depth.", nil, nil)
+	replayFailedDepthDesc   = prometheus.NewDesc("retrospec_replay_queue_failed_depth", "Replay dead-letter list depth.", nil, nil)
+	analysisStreamDepthDesc = prometheus.NewDesc("retrospec_analysis_queue_stream_depth", "Analysis stream entries waiting/retained in Redis stream.", nil, nil)
+	analysisPendingDesc     = prometheus.NewDesc("retrospec_analysis_queue_pending_total", "Analysis stream pending entries for consumer group.", nil, nil)
+	analysisRetryDepthDesc  = prometheus.NewDesc("retrospec_analysis_queue_retry_depth", "Analysis retry zset depth.", nil, nil)
+	analysisFailedDepthDesc = prometheus.NewDesc("retrospec_analysis_queue_failed_depth", "Analysis dead-letter list depth.", nil, nil)
+	laneDepthDesc           = prometheus.NewDesc("retrospec_queue_lane_depth", "Entries in a queue lane stream, waiting or in flight.", []string{"queue", "lane"}, nil)
+	lanePendingDesc         = prometheus.NewDesc("retrospec_queue_lane_pending", "Queue lane entries delivered to a worker but not yet acknowledged.", []string{"queue", "lane"}, nil)
+	laneOldestAgeDesc       = prometheus.NewDesc("retrospec_queue_lane_oldest_age_seconds", "Age of the oldest entry left in a queue lane stream.", []string{"queue", "lane"}, nil)
+)
+
+func (c *queueStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		replayStreamDepthDesc, replayPendingDesc, replayRetryDepthDesc, replayFailedDepthDesc,
+		analysisStreamDepthDesc, analysisPendingDesc, analysisRetryDepthDesc, analysisFailedDepthDesc,
+		laneDepthDesc, lanePendingDesc, laneOldestAgeDesc,
+	} {
+		ch <- desc
+	}
+}
+
+func (c *queueStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	stats, err := c.metrics.loadQueueStats(context.Background())
+	if err != nil {
+		c.metrics.queueMetricsErrorsTotal.Inc()
+		return
+	}
+
+	gauge := func(desc *prometheus.Desc, value int64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(value))
+	}
+	gauge(replayStreamDepthDesc, stats.ReplayStreamDepth)
+	gauge(replayPendingDesc, stats.ReplayPending)
+	gauge(replayRetryDepthDesc, stats.ReplayRetryDepth)
+	gauge(replayFailedDepthDesc, stats.ReplayFailedDepth)
+	gauge(analysisStreamDepthDesc, stats.AnalysisStreamDepth)
+	gauge(analysisPendingDesc, stats.AnalysisPending)
+	gauge(analysisRetryDepthDesc, stats.AnalysisRetryDepth)
+	gauge(analysisFailedDepthDesc, stats.AnalysisFailedDepth)
+
+	for queueKind, lanes := range map[string]map[queue.Lane]queue.LaneStats{
+		"replay":   stats.ReplayLanes,
+		"analysis": stats.AnalysisLanes,
+	} {
+		for lane, laneStats := range lanes {
+			ch <- prometheus.MustNewConstMetric(laneDepthDesc, prometheus.GaugeValue, float64(laneStats.StreamDepth), queueKind, string(lane))
+			ch <- prometheus.MustNewConstMetric(lanePendingDesc, prometheus.GaugeValue, float64(laneStats.Pending), queueKind, string(lane))
+			ch <- prometheus.MustNewConstMetric(laneOldestAgeDesc, prometheus.GaugeValue, laneStats.OldestAgeSeconds, queueKind, string(lane))
+		}
+	}
 }
 
 func (m *apiMetrics) loadQueueStats(parent context.Context) (queue.QueueStats, error) {
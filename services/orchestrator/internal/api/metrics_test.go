@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestMetricsKeepLegacyNamesAndLabelRoutes(t *testing.T) {
+	metrics := newAPIMetricsWithProjectLimit(nil, 1)
+	router := chi.NewRouter()
+	router.Use(metrics.instrument)
+	router.Get("/v1/sessions/{sessionID}", func(w http.ResponseWriter, r *http.Request) {
+		annotateMetricsProject(r.Context(), chi.URLParam(r, "sessionID"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	router.Get("/metrics", metrics.handleMetrics)
+
+	for _, sessionID := range []string{"proj_a", "proj_b"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/sessions/"+sessionID, nil))
+	}
+	metrics.recordIngestSession("proj_a")
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := recorder.Body.String()
+
+	for _, want := range []string{
+		"retrospec_ingest_sessions_total 1",
+		"retrospec_queue_metrics_errors_total 0",
+		`retrospec_http_request_duration_seconds_count{method="GET",route="/v1/sessions/{sessionID}",status="204"} 2`,
+		`retrospec_project_http_requests_total{project="proj_a",status_class="2xx"} 1`,
+		`retrospec_project_http_requests_total{project="other",status_class="2xx"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected metrics output to contain %q\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+
+	"retrospec/services/orchestrator/internal/logging"
+	"retrospec/services/orchestrator/internal/store"
+)
+
+const projectOriginsRefreshInterval = 30 * time.Second
+
+type updateAllowedOriginsRequest struct {
+	AllowedOrigins []string `json:"allowedOrigins"`
+}
+
+// projectOriginCache keeps a periodically refreshed snapshot of per-project
+// origin allowlists so CORS and ingest checks do not hit Postgres per request.
+type projectOriginCache struct {
+	load func(context.Context) (map[string][]string, error)
+
+	mu        sync.Mutex
+	byProject map[string][]string
+	union     []string
+	loadedAt  time.Time
+}
+
+func newProjectOriginCache(load func(context.Context) (map[string][]string, error)) *projectOriginCache {
+	return &projectOriginCache{load: load}
+}
+
+func (c *projectOriginCache) snapshot(ctx context.Context) (map[string][]string, []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.byProject == nil || time.Since(c.loadedAt) >= projectOriginsRefreshInterval {
+		byProject, err := c.load(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "project origin refresh failed", logging.Err(err))
+			if c.byProject == nil {
+				return map[string][]string{}, nil
+			}
+		} else {
+			c.byProject = byProject
+			c.union = make([]string, 0)
+			for _, origins := range byProject {
+				c.union = append(c.union, origins...)
+			}
+		}
+		c.loadedAt = time.Now()
+	}
+	return c.byProject, c.union
+}
+
+func (c *projectOriginCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loadedAt = time.Time{}
+}
+
+// projectOrigins returns the allowlist for projectID; an empty list means the
+// project accepts any origin.
+func (h *Handler) projectOrigins(ctx context.Context, projectID string) []string {
+	if h.originCache == nil {
+		return nil
+	}
+	byProject, _ := h.originCache.snapshot(ctx)
+	return byProject[projectID]
+}
+
+// allowCORSOrigin accepts origins from CORS_ALLOWED_ORIGINS plus any project's
+// allowlist. Preflights carry no key, so the per-project decision is left to
+// requireProjectOrigin on the actual request.
+func (h *Handler) allowCORSOrigin(r *http.Request, origin string) bool {
+	if store.OriginAllowed(h.runtimeSettings().CORSAllowedOrigins, origin) {
+		return true
+	}
+	if h.originCache == nil {
+		return false
+	}
+	_, union := h.originCache.snapshot(r.Context())
+	return store.OriginAllowed(union, origin)
+}
+
+// requireProjectOrigin rejects browser requests whose Origin is not on the
+// resolved project's allowlist. Requests without an Origin header (server-side
+// senders) are not restricted.
+func (h *Handler) requireProjectOrigin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := strings.TrimSpace(r.Header.Get("Origin"))
+		if origin == "" || h.originCache == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		projectID := h.projectIDFromContext(r.Context())
+		allowed := h.projectOrigins(r.Context(), projectID)
+		if len(allowed) == 0 || store.OriginAllowed(allowed, origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h.metrics.blockedOriginsTotal.Add(1)
+		slog.WarnContext(r.Context(), "blocked origin", slog.String(logging.ProjectIDKey, projectID), slog.String("origin", origin), slog.String("path", r.URL.Path))
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "origin not allowed"})
+	})
+}
+
+func (h *Handler) updateProjectAllowedOrigins(w http.ResponseWriter, r *http.Request) {
+	payload := updateAllowedOriginsRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid payload"})
+		return
+	}
+
+	origins, err := store.NormalizeAllowedOrigins(payload.AllowedOrigins)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	project, err := h.store.UpdateProjectAllowedOrigins(r.Context(), chi.URLParam(r, "projectID"), origins)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "project not found"})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "origin update failed"})
+		return
+	}
+
+	if h.originCache != nil {
+		h.originCache.invalidate()
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"project": project})
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRequireProjectOriginBlocksUnlistedOrigins(t *testing.T) {
+	handler := &Handler{
+		metrics: newAPIMetrics(nil),
+		originCache: newProjectOriginCache(func(context.Context) (map[string][]string, error) {
+			return map[string][]string{"proj_a": {"https://shop.example.com"}}, nil
+		}),
+	}
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	send := func(projectID, origin string) int {
+		req := httptest.NewRequest(http.MethodPost, "/v1/ingest/session", nil)
+		req = req.WithContext(context.WithValue(req.Context(), projectIDContextKey, projectID))
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		recorder := httptest.NewRecorder()
+		handler.requireProjectOrigin(next).ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	if code := send("proj_a", "https://shop.example.com"); code != http.StatusAccepted {
+		t.Fatalf("expected listed origin to pass, got %d", code)
+	}
+	if code := send("proj_a", "https://copycat.example.net"); code != http.StatusForbidden {
+		t.Fatalf("expected unlisted origin to be blocked, got %d", code)
+	}
+	if code := send("proj_a", ""); code != http.StatusAccepted {
+		t.Fatalf("expected request without origin to pass, got %d", code)
+	}
+	if code := send("proj_b", "https://copycat.example.net"); code != http.StatusAccepted {
+		t.Fatalf("expected unrestricted project to pass, got %d", code)
+	}
+	if testutil.ToFloat64(handler.metrics.blockedOriginsTotal) != 1 {
+		t.Fatalf("expected one blocked origin, got %v", testutil.ToFloat64(handler.metrics.blockedOriginsTotal))
+	}
+
+	preflight := httptest.NewRequest(http.MethodOptions, "/v1/ingest/session", nil)
+	if !handler.allowCORSOrigin(preflight, "https://shop.example.com") || handler.allowCORSOrigin(preflight, "https://copycat.example.net") {
+		t.Fatal("expected CORS to allow only project origins when no global origins are configured")
+	}
+}
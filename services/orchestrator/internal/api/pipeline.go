@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"retrospec/services/orchestrator/internal/logging"
+)
+
+const (
+	defaultPipelineLatencyWindow = 24 * time.Hour
+	maxPipelineLatencyWindow     = 30 * 24 * time.Hour
+)
+
+var errPipelineLatencyWindow = errors.New("windowHours must be between 1 and 720")
+
+// recordPipelineStage is best effort: a failed write is logged but never
+// fails the request that moved the session forward.
+func (h *Handler) recordPipelineStage(ctx context.Context, projectID, sessionID, stage string) {
+	elapsed, recorded, err := h.store.RecordPipelineStage(ctx, projectID, sessionID, stage, time.Now().UTC())
+	if err != nil {
+		slog.ErrorContext(ctx, "pipeline stage record failed", slog.String("stage", stage), logging.Err(err))
+		return
+	}
+	if recorded {
+		h.metrics.pipelineStageDuration.WithLabelValues(stage).Observe(elapsed.Seconds())
+	}
+}
+
+func (h *Handler) getPipelineLatency(w http.ResponseWriter, r *http.Request) {
+	window, err := pipelineLatencyWindow(r.URL.Query().Get("windowHours"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	report, err := h.store.PipelineLatency(r.Context(), h.projectIDFromContext(r.Context()), time.Now().UTC().Add(-window))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "pipeline latency lookup failed"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"windowHours": int(window / time.Hour),
+		"latency":     report,
+	})
+}
+
+func pipelineLatencyWindow(raw string) (time.Duration, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return defaultPipelineLatencyWindow, nil
+	}
+	hours, err := strconv.Atoi(raw)
+	if err != nil || hours < 1 || time.Duration(hours)*time.Hour > maxPipelineLatencyWindow {
+		return 0, errPipelineLatencyWindow
+	}
+	return time.Duration(hours) * time.Hour, nil
+}
@@ -0,0 +1,27 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPipelineLatencyWindow(t *testing.T) {
+	cases := map[string]time.Duration{
+		"":    24 * time.Hour,
+		"1":   time.Hour,
+		" 72": 72 * time.Hour,
+		"720": 720 * time.Hour,
+	}
+	for raw, expected := range cases {
+		window, err := pipelineLatencyWindow(raw)
+		if err != nil || window != expected {
+			t.Fatalf("window %q: expected %s, got %s err=%v", raw, expected, window, err)
+		}
+	}
+
+	for _, raw := range []string{"0", "-3", "721", "1.5", "day"} {
+		if _, err := pipelineLatencyWindow(raw); err == nil {
+			t.Fatalf("expected window %q to be rejected", raw)
+		}
+	}
+}
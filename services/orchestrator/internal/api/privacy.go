@@ -0,0 +1,149 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+
+	"retrospec/services/orchestrator/internal/artifacts"
+	"retrospec/services/orchestrator/internal/logging"
+	"retrospec/services/orchestrator/internal/store"
+)
+
+const maxErasureSessionIDs = 500
+
+type privacyErasureRequest struct {
+	UserID      string   `json:"userId"`
+	SessionIDs  []string `json:"sessionIds"`
+	RequestedBy string   `json:"requestedBy"`
+}
+
+func (h *Handler) requestPrivacyErasure(w http.ResponseWriter, r *http.Request) {
+	payload := privacyErasureRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid payload"})
+		return
+	}
+
+	userID := strings.TrimSpace(payload.UserID)
+	if userID == "" && len(payload.SessionIDs) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "userId or sessionIds is required"})
+		return
+	}
+	if len(payload.SessionIDs) > maxErasureSessionIDs {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "sessionIds must include at most 500 entries"})
+		return
+	}
+
+	projectID := h.projectIDFromContext(r.Context())
+	job, err := h.store.CreatePrivacyErasureJob(r.Context(), projectID, userID, payload.SessionIDs, payload.RequestedBy)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "erasure job creation failed"})
+		return
+	}
+	annotateAuditTarget(r.Context(), "privacy_erasure", job.ID)
+
+	job, err = h.store.EraseSessionData(r.Context(), job, h.runtimeSettings().ClusterPromoteMinSessions)
+	if errors.Is(err, store.ErrClusterRefresh) {
+		slog.WarnContext(r.Context(), "privacy erasure committed but cluster refresh failed", slog.String("erasure_job_id", job.ID), logging.Err(err))
+		err = nil
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "privacy erasure failed", slog.String("erasure_job_id", job.ID), logging.Err(err))
+		job.Status = "failed"
+		job.Error = "session data deletion failed"
+		if failedJob, completeErr := h.completeErasureJob(r.Context(), job); completeErr == nil {
+			job = failedJob
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]any{
+			"error": "erasure failed",
+			"job":   job,
+		})
+		return
+	}
+
+	objectKeys := append(append([]string{}, job.DeletedEventObjectKeys...), job.DeletedArtifactObjectKeys...)
+	job.FailedObjectKeys = []string{}
+	for _, objectKey := range objectKeys {
+		err := h.artifactStore.DeleteObject(r.Context(), objectKey)
+		if err != nil && !errors.Is(err, artifacts.ErrNotConfigured) {
+			job.FailedObjectKeys = append(job.FailedObjectKeys, objectKey)
+			slog.ErrorContext(r.Context(), "privacy erasure object delete failed", slog.String("erasure_job_id", job.ID), slog.String("object_key", objectKey), logging.Err(err))
+		}
+	}
+
+	settleErasureJob(&job)
+
+	completed, err := h.completeErasureJob(r.Context(), job)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "erasure job completion failed"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"job":             completed,
+		"receiptVerified": h.erasureReceiptVerified(completed),
+	})
+}
+
+// settleErasureJob sets the final status of an erased job. Failed object
+// deletes and sessions skipped for a legal hold are both reported when they
+// happen together, so neither hides the other.
+func settleErasureJob(job *store.PrivacyErasureJob) {
+	failed := len(job.FailedObjectKeys) > 0
+	held := len(job.HeldSessionIDs) > 0
+	problems := []string{}
+	if failed {
+		problems = append(problems, "some objects could not be deleted")
+	}
+	if held {
+		problems = append(problems, "some sessions are under legal hold and were not erased")
+	}
+
+	switch {
+	case failed && held:
+		job.Status = "completed_with_errors_and_holds"
+	case failed:
+		job.Status = "completed_with_errors"
+	case held:
+		job.Status = "completed_with_holds"
+	default:
+		job.Status = "completed"
+	}
+	job.Error = strings.Join(problems, "; ")
+}
+
+func (h *Handler) getPrivacyErasureJob(w http.ResponseWriter, r *http.Request) {
+	jobID := strings.TrimSpace(chi.URLParam(r, "jobID"))
+	job, err := h.store.GetPrivacyErasureJob(r.Context(), h.projectIDFromContext(r.Context()), jobID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "erasure job not found"})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "lookup failed"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"job":             job,
+		"receiptVerified": h.erasureReceiptVerified(job),
+	})
+}
+
+func (h *Handler) completeErasureJob(ctx context.Context, job store.PrivacyErasureJob) (store.PrivacyErasureJob, error) {
+	return h.store.CompletePrivacyErasureJob(ctx, job, h.erasureReceiptActiveKeyID, h.erasureReceiptKeys[h.erasureReceiptActiveKeyID])
+}
+
+// erasureReceiptVerified checks the receipt against the key it names, so
+// receipts stay verifiable while their key remains in ERASURE_RECEIPT_KEYS.
+func (h *Handler) erasureReceiptVerified(job store.PrivacyErasureJob) bool {
+	key, ok := h.erasureReceiptKeys[job.ReceiptKeyID]
+	return ok && store.VerifyPrivacyErasureReceipt(job, key)
+}
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"retrospec/services/orchestrator/internal/store"
+)
+
+func TestSettleErasureJobReportsHoldsAndFailedDeletesTogether(t *testing.T) {
+	cases := []struct {
+		held, failed []string
+		status       string
+	}{
+		{nil, nil, "completed"},
+		{[]string{"session-1"}, nil, "completed_with_holds"},
+		{nil, []string{"session-events/a.json"}, "completed_with_errors"},
+		{[]string{"session-1"}, []string{"session-events/a.json"}, "completed_with_errors_and_holds"},
+	}
+	for _, tc := range cases {
+		job := store.PrivacyErasureJob{HeldSessionIDs: tc.held, FailedObjectKeys: tc.failed}
+		settleErasureJob(&job)
+		if job.Status != tc.status {
+			t.Fatalf("held=%v failed=%v: expected %s, got %s", tc.held, tc.failed, tc.status, job.Status)
+		}
+		if strings.Contains(job.Error, "legal hold") != (len(tc.held) > 0) ||
+			strings.Contains(job.Error, "could not be deleted") != (len(tc.failed) > 0) {
+			t.Fatalf("held=%v failed=%v: unexpected error %q", tc.held, tc.failed, job.Error)
+		}
+	}
+}
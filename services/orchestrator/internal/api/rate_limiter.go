@@ -1,99 +1,299 @@
 package api
 
 import (
-	"net"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"golang.org/x/time/rate"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+
+	"retrospec/services/orchestrator/internal/logging"
+	"retrospec/services/orchestrator/internal/queue"
+	"retrospec/services/orchestrator/internal/store"
 )
 
+const projectRateLimitsRefreshInterval = 30 * time.Second
+
+type updateRateLimitsRequest struct {
+	RateLimits map[string]store.RateLimit `json:"rateLimits"`
+}
+
+// apiRateLimiter enforces per-class limits through a shared store (Redis when
+// available) and falls back to process-local buckets when the store errors.
 type apiRateLimiter struct {
-	mu       sync.Mutex
-	rps      rate.Limit
-	burst    int
-	ttl      time.Duration
-	clients  map[string]*rate.Limiter
-	lastSeen map[string]time.Time
-	onReject func()
+	backend   queue.RateLimitStore
+	fallback  *memoryRateLimitStore
+	defaults  atomic.Pointer[map[string]store.RateLimit]
+	overrides *projectRateLimitCache
+	onReject  func()
+	onError   func()
 }
 
-func newAPIRateLimiter(requestsPerSec float64, burst int, onReject func()) *apiRateLimiter {
-	if requestsPerSec <= 0 || burst <= 0 {
-		return nil
+func newAPIRateLimiter(
+	backend queue.RateLimitStore,
+	defaults map[string]store.RateLimit,
+	overrides *projectRateLimitCache,
+	onReject func(),
+	onError func(),
+) *apiRateLimiter {
+	limiter := &apiRateLimiter{
+		backend:   backend,
+		fallback:  newMemoryRateLimitStore(),
+		overrides: overrides,
+		onReject:  onReject,
+		onError:   onError,
+	}
+	limiter.setDefaults(defaults)
+	return limiter
+}
+
+func (l *apiRateLimiter) setDefaults(defaults map[string]store.RateLimit) {
+	l.defaults.Store(&defaults)
+}
+
+// limitFor returns the project's override for class, or the configured
+// default. A zero limit disables rate limiting for the class.
+func (l *apiRateLimiter) limitFor(ctx context.Context, projectID, class string) store.RateLimit {
+	if l.overrides != nil {
+		if limit, ok := l.overrides.snapshot(ctx)[projectID][class]; ok {
+			return limit
+		}
+	}
+	return (*l.defaults.Load())[class]
+}
+
+func (l *apiRateLimiter) check(ctx context.Context, key string, limit store.RateLimit) queue.RateLimitDecision {
+	decision, err := l.backend.AllowRate(ctx, key, limit.RequestsPerSec, limit.Burst)
+	if err == nil {
+		return decision
 	}
 
-	return &apiRateLimiter{
-		rps:      rate.Limit(requestsPerSec),
-		burst:    burst,
-		ttl:      10 * time.Minute,
-		clients:  make(map[string]*rate.Limiter),
-		lastSeen: make(map[string]time.Time),
-		onReject: onReject,
+	if l.onError != nil {
+		l.onError()
 	}
+	slog.ErrorContext(ctx, "rate limit store failed", slog.String("rate_limit_key", key), logging.Err(err))
+	decision, _ = l.fallback.AllowRate(ctx, key, limit.RequestsPerSec, limit.Burst)
+	return decision
 }
 
-func (l *apiRateLimiter) Middleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if !l.allow(clientAddress(r)) {
-			if l.onReject != nil {
-				l.onReject()
+// rateLimit limits requests of a route class per project and caller. Callers
+// are identified by API key or user; anonymous and share-link traffic falls
+// back to the client address.
+func (h *Handler) rateLimit(class string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if h.rateLimiter == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			projectID := h.projectIDFromContext(r.Context())
+			limit := h.rateLimiter.limitFor(r.Context(), projectID, class)
+			key := class + ":" + projectID + ":" + h.rateLimitIdentity(r)
+			if h.allowRate(w, r, key, limit) {
+				next.ServeHTTP(w, r)
 			}
-			writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": "rate limit exceeded"})
+		})
+	}
+}
+
+// rateLimitByAddress limits every request per client address before it is
+// authenticated, so failed key, token, admin key and share password attempts
+// are throttled too and cannot reach Postgres unchecked.
+func (h *Handler) rateLimitByAddress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.rateLimiter == nil {
+			next.ServeHTTP(w, r)
 			return
 		}
 
-		next.ServeHTTP(w, r)
+		limit := (*h.rateLimiter.defaults.Load())[store.RateLimitClassAddress]
+		address := clientAddress(r)
+		if address == "" {
+			address = "unknown"
+		}
+		if h.allowRate(w, r, store.RateLimitClassAddress+":"+address, limit) {
+			next.ServeHTTP(w, r)
+		}
 	})
 }
 
-func (l *apiRateLimiter) allow(clientID string) bool {
-	if clientID == "" {
-		clientID = "unknown"
+// allowRate checks key against limit and writes the rate limit headers,
+// answering 429 itself when the request is rejected. A zero limit allows
+// every request.
+func (h *Handler) allowRate(w http.ResponseWriter, r *http.Request, key string, limit store.RateLimit) bool {
+	if limit.RequestsPerSec <= 0 || limit.Burst <= 0 {
+		return true
 	}
 
-	now := time.Now()
+	decision := h.rateLimiter.check(r.Context(), key, limit)
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Burst))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(decision.ResetAfter)))
+	if decision.Allowed {
+		return true
+	}
+
+	if h.rateLimiter.onReject != nil {
+		h.rateLimiter.onReject()
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(decision.RetryAfter)))
+	writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": "rate limit exceeded"})
+	return false
+}
+
+func (h *Handler) rateLimitIdentity(r *http.Request) string {
+	actor := h.actorFromContext(r.Context())
+	if actor == "anonymous" || strings.HasPrefix(actor, "share_link:") {
+		address := clientAddress(r)
+		if address == "" {
+			address = "unknown"
+		}
+		return "ip:" + address
+	}
+	return actor
+}
 
-	l.mu.Lock()
-	defer l.mu.Unlock()
+func (h *Handler) updateProjectRateLimits(w http.ResponseWriter, r *http.Request) {
+	payload := updateRateLimitsRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid payload"})
+		return
+	}
+
+	limits, err := store.NormalizeRateLimits(payload.RateLimits)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	project, err := h.store.UpdateProjectRateLimits(r.Context(), chi.URLParam(r, "projectID"), limits)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "project not found"})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "rate limit update failed"})
+		return
+	}
 
-	limiter, exists := l.clients[clientID]
-	if !exists {
-		limiter = rate.NewLimiter(l.rps, l.burst)
-		l.clients[clientID] = limiter
+	if h.rateLimiter != nil && h.rateLimiter.overrides != nil {
+		h.rateLimiter.overrides.invalidate()
 	}
-	l.lastSeen[clientID] = now
+	writeJSON(w, http.StatusOK, map[string]any{"project": project})
+}
 
-	for key, seenAt := range l.lastSeen {
-		if now.Sub(seenAt) > l.ttl {
-			delete(l.lastSeen, key)
-			delete(l.clients, key)
+// projectRateLimitCache keeps a periodically refreshed snapshot of per-project
+// overrides so the limiter does not hit Postgres per request.
+type projectRateLimitCache struct {
+	load func(context.Context) (map[string]map[string]store.RateLimit, error)
+
+	mu        sync.Mutex
+	byProject map[string]map[string]store.RateLimit
+	loadedAt  time.Time
+}
+
+func newProjectRateLimitCache(load func(context.Context) (map[string]map[string]store.RateLimit, error)) *projectRateLimitCache {
+	return &projectRateLimitCache{load: load}
+}
+
+func (c *projectRateLimitCache) snapshot(ctx context.Context) map[string]map[string]store.RateLimit {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.byProject == nil || time.Since(c.loadedAt) >= projectRateLimitsRefreshInterval {
+		byProject, err := c.load(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "project rate limit refresh failed", logging.Err(err))
+			if c.byProject == nil {
+				return map[string]map[string]store.RateLimit{}
+			}
+		} else {
+			c.byProject = byProject
 		}
+		c.loadedAt = time.Now()
 	}
+	return c.byProject
+}
 
-	return limiter.Allow()
+func (c *projectRateLimitCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loadedAt = time.Time{}
 }
 
-func clientAddress(r *http.Request) string {
-	forwarded := strings.TrimSpace(r.Header.Get("X-Forwarded-For"))
-	if forwarded != "" {
-		parts := strings.Split(forwarded, ",")
-		if len(parts) > 0 {
-			return strings.TrimSpace(parts[0])
+// memoryRateLimitStore is the single-instance GCRA used without Redis and as
+// the fallback when Redis errors.
+type memoryRateLimitStore struct {
+	mu        sync.Mutex
+	tat       map[string]time.Time
+	lastSweep time.Time
+}
+
+func newMemoryRateLimitStore() *memoryRateLimitStore {
+	return &memoryRateLimitStore{tat: map[string]time.Time{}}
+}
+
+func (s *memoryRateLimitStore) AllowRate(_ context.Context, key string, requestsPerSec float64, burst int) (queue.RateLimitDecision, error) {
+	if requestsPerSec <= 0 || burst <= 0 {
+		return queue.RateLimitDecision{}, errors.New("rate limit must be positive")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) > time.Minute {
+		for candidate, tat := range s.tat {
+			if now.After(tat) {
+				delete(s.tat, candidate)
+			}
 		}
+		s.lastSweep = now
 	}
 
-	realIP := strings.TrimSpace(r.Header.Get("X-Real-IP"))
-	if realIP != "" {
-		return realIP
+	emission := time.Duration(float64(time.Second) / requestsPerSec)
+	tolerance := emission * time.Duration(burst)
+
+	tat := s.tat[key]
+	if tat.Before(now) {
+		tat = now
+	}
+	ahead := tat.Add(emission).Sub(now)
+	if ahead > tolerance {
+		return queue.RateLimitDecision{
+			RetryAfter: ahead - tolerance,
+			ResetAfter: tat.Sub(now),
+		}, nil
 	}
 
-	host, _, err := net.SplitHostPort(strings.TrimSpace(r.RemoteAddr))
-	if err == nil && host != "" {
-		return host
+	s.tat[key] = tat.Add(emission)
+	return queue.RateLimitDecision{
+		Allowed:    true,
+		Remaining:  int((tolerance - ahead) / emission),
+		ResetAfter: ahead,
+	}, nil
+}
+
+func rateLimitStoreFor(producer queue.Producer) queue.RateLimitStore {
+	if store, ok := producer.(queue.RateLimitStore); ok {
+		return store
 	}
+	return newMemoryRateLimitStore()
+}
 
-	return strings.TrimSpace(r.RemoteAddr)
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int(math.Ceil(d.Seconds()))
 }
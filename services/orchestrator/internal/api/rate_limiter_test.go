@@ -1,31 +1,109 @@
 package api
 
 import (
+	"context"
+	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"retrospec/services/orchestrator/internal/store"
 )
 
-func TestClientAddressPrefersForwardedHeaders(t *testing.T) {
-	req := httptest.NewRequest("GET", "/v1/issues", nil)
-	req.Header.Set("X-Forwarded-For", "203.0.113.9, 10.0.0.1")
-	req.Header.Set("X-Real-IP", "198.51.100.4")
-	req.RemoteAddr = "127.0.0.1:1234"
+func TestRateLimiterBlocksExcessBurst(t *testing.T) {
+	limiter := newMemoryRateLimitStore()
 
-	if got := clientAddress(req); got != "203.0.113.9" {
-		t.Fatalf("expected forwarded IP, got %q", got)
+	decision, err := limiter.AllowRate(context.Background(), "read:proj_a:ip:192.0.2.10", 1, 1)
+	if err != nil || !decision.Allowed {
+		t.Fatalf("first request should be allowed, got %+v err=%v", decision, err)
+	}
+	decision, err = limiter.AllowRate(context.Background(), "read:proj_a:ip:192.0.2.10", 1, 1)
+	if err != nil || decision.Allowed {
+		t.Fatalf("second immediate request should be rate limited, got %+v err=%v", decision, err)
+	}
+	if decision.RetryAfter <= 0 {
+		t.Fatalf("expected retry-after on rejection, got %+v", decision)
 	}
 }
 
-func TestRateLimiterBlocksExcessBurst(t *testing.T) {
-	limiter := newAPIRateLimiter(1, 1, nil)
-	if limiter == nil {
-		t.Fatal("expected limiter to be created")
+func TestRateLimitMiddlewareKeysByAPIKeyAndSetsHeaders(t *testing.T) {
+	handler := &Handler{
+		rateLimiter: newAPIRateLimiter(
+			newMemoryRateLimitStore(),
+			map[string]store.RateLimit{store.RateLimitClassIngest: {RequestsPerSec: 1, Burst: 1}},
+			newProjectRateLimitCache(func(context.Context) (map[string]map[string]store.RateLimit, error) {
+				return map[string]map[string]store.RateLimit{
+					"proj_b": {store.RateLimitClassIngest: {RequestsPerSec: 1, Burst: 3}},
+				}, nil
+			}),
+			nil,
+			nil,
+		),
+	}
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	send := func(projectID, keyID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/ingest/session", nil)
+		req.RemoteAddr = "198.51.100.7:4321"
+		ctx := context.WithValue(req.Context(), projectIDContextKey, projectID)
+		ctx = context.WithValue(ctx, apiKeyIDContext, keyID)
+		recorder := httptest.NewRecorder()
+		handler.rateLimit(store.RateLimitClassIngest)(next).ServeHTTP(recorder, req.WithContext(ctx))
+		return recorder
+	}
+
+	if got := send("proj_a", "key_1"); got.Code != http.StatusAccepted || got.Header().Get("RateLimit-Limit") != "1" {
+		t.Fatalf("expected first request to pass with limit header, got %d %v", got.Code, got.Header())
 	}
+	limited := send("proj_a", "key_1")
+	if limited.Code != http.StatusTooManyRequests || limited.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected second request to be limited with Retry-After, got %d %v", limited.Code, limited.Header())
+	}
+	if got := send("proj_a", "key_2"); got.Code != http.StatusAccepted {
+		t.Fatalf("expected another key from the same address to have its own bucket, got %d", got.Code)
+	}
+	if got := send("proj_b", "key_3"); got.Code != http.StatusAccepted || got.Header().Get("RateLimit-Remaining") != "2" {
+		t.Fatalf("expected project override to apply, got %d %v", got.Code, got.Header())
+	}
+}
 
-	if !limiter.allow("192.0.2.10") {
-		t.Fatal("first request should be allowed")
+func TestRateLimitByAddressThrottlesFailedAuthentication(t *testing.T) {
+	handler := &Handler{
+		adminAPIKey: "admin-secret",
+		rateLimiter: newAPIRateLimiter(
+			newMemoryRateLimitStore(),
+			map[string]store.RateLimit{store.RateLimitClassAddress: {RequestsPerSec: 1, Burst: 2}},
+			nil,
+			nil,
+			nil,
+		),
+	}
+	reached := 0
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		reached++
+		w.WriteHeader(http.StatusOK)
+	})
+	protected := handler.rateLimitByAddress(handler.requireAdminAccess(next))
+
+	send := func(remoteAddr, adminKey string) int {
+		req := httptest.NewRequest(http.MethodGet, "/v1/admin/projects", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Retrospec-Admin", adminKey)
+		recorder := httptest.NewRecorder()
+		protected.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	for attempt := range 2 {
+		if code := send("203.0.113.9:5000", "guess"); code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: expected wrong admin key to be rejected, got %d", attempt, code)
+		}
+	}
+	if code := send("203.0.113.9:5000", "admin-secret"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected the address to be throttled before authentication, got %d", code)
 	}
-	if limiter.allow("192.0.2.10") {
-		t.Fatal("second immediate request should be rate limited")
+	if code := send("203.0.113.10:5000", "admin-secret"); code != http.StatusOK || reached != 1 {
+		t.Fatalf("expected another address to be unaffected, got %d reached=%d", code, reached)
 	}
 }
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"retrospec/services/orchestrator/internal/artifacts"
+	"retrospec/services/orchestrator/internal/logging"
+	"retrospec/services/orchestrator/internal/queue"
+)
+
+const (
+	readinessPostgres  = "postgres"
+	readinessRedis     = "redis"
+	readinessArtifacts = "artifacts"
+
+	readinessCheckTimeout = 2 * time.Second
+)
+
+var readinessComponents = []string{readinessPostgres, readinessRedis, readinessArtifacts}
+
+// ParseRequiredDependencies reads the comma-separated components whose
+// failure makes /readyz return 503.
+func ParseRequiredDependencies(list string) ([]string, error) {
+	required := make([]string, 0)
+	for _, value := range strings.Split(list, ",") {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+		known := false
+		for _, component := range readinessComponents {
+			known = known || component == value
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown dependency %q (want %s)", value, strings.Join(readinessComponents, ", "))
+		}
+		required = append(required, value)
+	}
+	return required, nil
+}
+
+// readinessCheck probes one dependency. A component running on its noop
+// fallback has no check and is reported as such rather than as healthy.
+type readinessCheck struct {
+	name     string
+	required bool
+	fallback string
+	check    func(ctx context.Context) error
+}
+
+type componentReadiness struct {
+	Status    string `json:"status"`
+	Required  bool   `json:"required"`
+	Fallback  string `json:"fallback,omitempty"`
+	LatencyMs int64  `json:"latencyMs"`
+}
+
+type readinessReport struct {
+	Status     string                        `json:"status"`
+	Components map[string]componentReadiness `json:"components"`
+}
+
+func newReadinessChecks(
+	postgresHealth func(ctx context.Context) error,
+	producer queue.Producer,
+	artifactStore artifacts.Store,
+	required []string,
+) []readinessCheck {
+	isRequired := map[string]bool{}
+	for _, name := range required {
+		isRequired[name] = true
+	}
+
+	checks := []readinessCheck{{name: readinessPostgres, required: isRequired[readinessPostgres], check: postgresHealth}}
+
+	redis := readinessCheck{name: readinessRedis, required: isRequired[readinessRedis], fallback: "noop"}
+	if checker, ok := producer.(queue.HealthChecker); ok {
+		redis.check, redis.fallback = checker.Health, ""
+	}
+
+	bucket := readinessCheck{name: readinessArtifacts, required: isRequired[readinessArtifacts], fallback: "noop"}
+	if checker, ok := artifactStore.(artifacts.HealthChecker); ok {
+		bucket.check, bucket.fallback = checker.Health, ""
+	}
+	return append(checks, redis, bucket)
+}
+
+// evaluateReadiness runs every check in parallel, each under its own timeout.
+func evaluateReadiness(ctx context.Context, checks []readinessCheck) readinessReport {
+	report := readinessReport{Status: "ready", Components: map[string]componentReadiness{}}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		wg.Add(1)
+		go func(check readinessCheck) {
+			defer wg.Done()
+
+			result := componentReadiness{Status: "up", Required: check.required, Fallback: check.fallback}
+			if check.check == nil {
+				result.Status = "down"
+			} else {
+				checkCtx, cancel := context.WithTimeout(ctx, readinessCheckTimeout)
+				started := time.Now()
+				err := check.check(checkCtx)
+				result.LatencyMs = time.Since(started).Milliseconds()
+				cancel()
+				if err != nil {
+					result.Status = "down"
+					slog.WarnContext(ctx, "readiness check failed", slog.String("component", check.name), logging.Err(err))
+				}
+			}
+
+			mu.Lock()
+			report.Components[check.name] = result
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	for _, result := range report.Components {
+		if result.Status == "up" {
+			continue
+		}
+		if result.Required {
+			report.Status = "unavailable"
+			break
+		}
+		report.Status = "degraded"
+	}
+	return report
+}
+
+func (h *Handler) readyz(w http.ResponseWriter, r *http.Request) {
+	report := evaluateReadiness(r.Context(), h.readinessChecks)
+	status := http.StatusOK
+	if report.Status == "unavailable" {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, report)
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"retrospec/services/orchestrator/internal/artifacts"
+	"retrospec/services/orchestrator/internal/queue"
+)
+
+func TestReadinessReportsNoopFallbacksAndRequiredFailures(t *testing.T) {
+	up := func(context.Context) error { return nil }
+	checks := newReadinessChecks(up, queue.NewNoopProducer(), artifacts.NewNoopStore(), []string{readinessPostgres})
+
+	report := evaluateReadiness(context.Background(), checks)
+	if report.Status != "degraded" {
+		t.Fatalf("expected degraded with optional noop fallbacks, got %s", report.Status)
+	}
+	for _, name := range []string{readinessRedis, readinessArtifacts} {
+		if component := report.Components[name]; component.Status != "down" || component.Fallback != "noop" || component.Required {
+			t.Fatalf("expected %s to report an optional noop fallback, got %+v", name, component)
+		}
+	}
+
+	checks = newReadinessChecks(up, queue.NewNoopProducer(), artifacts.NewNoopStore(), []string{readinessPostgres, readinessRedis})
+	h := &Handler{readinessChecks: checks}
+	recorder := httptest.NewRecorder()
+	h.readyz(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 when required redis is on its fallback, got %d", recorder.Code)
+	}
+	decoded := readinessReport{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &decoded); err != nil || decoded.Status != "unavailable" {
+		t.Fatalf("expected unavailable report, got %s err=%v", recorder.Body.String(), err)
+	}
+}
+
+func TestReadinessTimesOutSlowChecks(t *testing.T) {
+	hang := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	report := evaluateReadiness(context.Background(), []readinessCheck{
+		{name: readinessPostgres, required: true, check: hang},
+		{name: readinessRedis, check: func(context.Context) error { return errors.New("connection refused") }},
+	})
+	if report.Status != "unavailable" || report.Components[readinessPostgres].Status != "down" {
+		t.Fatalf("expected hung required check to fail readiness, got %+v", report)
+	}
+	if report.Components[readinessPostgres].LatencyMs < readinessCheckTimeout.Milliseconds() {
+		t.Fatalf("expected check to run until the timeout, got %dms", report.Components[readinessPostgres].LatencyMs)
+	}
+}
+
+func TestParseRequiredDependencies(t *testing.T) {
+	required, err := ParseRequiredDependencies(" Postgres, redis ,")
+	if err != nil || len(required) != 2 || required[0] != readinessPostgres || required[1] != readinessRedis {
+		t.Fatalf("unexpected parse result %v err=%v", required, err)
+	}
+	if _, err := ParseRequiredDependencies("postgres,kafka"); err == nil {
+		t.Fatalf("expected unknown dependency to be rejected")
+	}
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"retrospec/services/orchestrator/internal/logging"
+)
+
+// logRequests writes one structured line per request. Correlation fields are
+// shared with inner middleware, so the line carries the project and session
+// once they are resolved.
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		ctx := logging.WithRequest(r.Context(), middleware.GetReqID(r.Context()))
+		recorder := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		status := recorder.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		route := ""
+		if routeContext := chi.RouteContext(r.Context()); routeContext != nil {
+			route = routeContext.RoutePattern()
+		}
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.LogAttrs(ctx, level, "request completed",
+			slog.String("method", r.Method),
+			slog.String("route", route),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", status),
+			slog.Int("bytes", recorder.BytesWritten()),
+			slog.Float64("duration_ms", float64(time.Since(started).Microseconds())/1000),
+			slog.String("client_address", clientAddress(r)),
+		)
+	})
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"retrospec/services/orchestrator/internal/config"
+	"retrospec/services/orchestrator/internal/store"
+)
+
+// RuntimeSettings is the part of the configuration that can change without a
+// restart.
+type RuntimeSettings struct {
+	CORSAllowedOrigins        []string
+	RateLimits                map[string]store.RateLimit
+	ClusterPromoteMinSessions int
+	SessionRetentionDays      int
+}
+
+// ConfigReloader re-reads the configuration, applies the runtime settings and
+// reports every setting that changed.
+type ConfigReloader func(ctx context.Context) ([]config.Change, error)
+
+// ApplyRuntimeSettings swaps the settings used by subsequent requests.
+// In-flight requests finish with the values they started with.
+func (h *Handler) ApplyRuntimeSettings(settings RuntimeSettings) {
+	if h.rateLimiter != nil {
+		h.rateLimiter.setDefaults(settings.RateLimits)
+	}
+	h.runtime.Store(&settings)
+}
+
+func (h *Handler) SetConfigReloader(reload ConfigReloader) {
+	h.configReloader = reload
+}
+
+func (h *Handler) runtimeSettings() RuntimeSettings {
+	if settings := h.runtime.Load(); settings != nil {
+		return *settings
+	}
+	return RuntimeSettings{}
+}
+
+func (h *Handler) reloadConfig(w http.ResponseWriter, r *http.Request) {
+	if h.configReloader == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "config reload unavailable"})
+		return
+	}
+
+	changes, err := h.configReloader(r.Context())
+	if err != nil {
+		var problems config.Errors
+		if !errors.As(err, &problems) {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "config reload failed"})
+			return
+		}
+		messages := make([]string, 0, len(problems))
+		for _, problem := range problems {
+			messages = append(messages, problem.Error())
+		}
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{
+			"error":    "configuration invalid",
+			"problems": messages,
+		})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"changes": changes})
+}
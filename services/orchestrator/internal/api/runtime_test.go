@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"retrospec/services/orchestrator/internal/config"
+	"retrospec/services/orchestrator/internal/store"
+)
+
+func TestApplyRuntimeSettingsSwapsLimitsAndOrigins(t *testing.T) {
+	h := &Handler{
+		rateLimiter: newAPIRateLimiter(newMemoryRateLimitStore(), map[string]store.RateLimit{}, nil, nil, nil),
+	}
+	h.ApplyRuntimeSettings(RuntimeSettings{
+		CORSAllowedOrigins: []string{"https://old.example"},
+		RateLimits:         map[string]store.RateLimit{store.RateLimitClassRead: {RequestsPerSec: 1, Burst: 1}},
+	})
+	h.ApplyRuntimeSettings(RuntimeSettings{
+		CORSAllowedOrigins:        []string{"https://new.example"},
+		RateLimits:                map[string]store.RateLimit{store.RateLimitClassRead: {RequestsPerSec: 5, Burst: 10}},
+		ClusterPromoteMinSessions: 4,
+	})
+
+	if limit := h.rateLimiter.limitFor(context.Background(), "proj_a", store.RateLimitClassRead); limit.Burst != 10 {
+		t.Fatalf("expected reloaded read burst, got %+v", limit)
+	}
+	request := httptest.NewRequest(http.MethodGet, "/v1/issues", nil)
+	if h.allowCORSOrigin(request, "https://old.example") || !h.allowCORSOrigin(request, "https://new.example") {
+		t.Fatal("expected only the reloaded origin to be allowed")
+	}
+	if h.runtimeSettings().ClusterPromoteMinSessions != 4 {
+		t.Fatalf("expected reloaded promotion threshold, got %+v", h.runtimeSettings())
+	}
+}
+
+func TestReloadConfigReportsChangesAndProblems(t *testing.T) {
+	h := &Handler{}
+	h.SetConfigReloader(func(context.Context) ([]config.Change, error) {
+		return []config.Change{{Key: "RATE_LIMIT_BURST", Old: "50", New: "80", Reloadable: true}}, nil
+	})
+	recorder := httptest.NewRecorder()
+	h.reloadConfig(recorder, httptest.NewRequest(http.MethodPost, "/v1/admin/config/reload", nil))
+	decoded := struct {
+		Changes []config.Change `json:"changes"`
+	}{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &decoded); err != nil || recorder.Code != http.StatusOK || len(decoded.Changes) != 1 {
+		t.Fatalf("expected one change, got %d %s err=%v", recorder.Code, recorder.Body.String(), err)
+	}
+
+	h.SetConfigReloader(func(context.Context) ([]config.Change, error) {
+		return nil, config.Errors{errors.New(`RATE_LIMIT_BURST: invalid integer "abc"`)}
+	})
+	recorder = httptest.NewRecorder()
+	h.reloadConfig(recorder, httptest.NewRequest(http.MethodPost, "/v1/admin/config/reload", nil))
+	if recorder.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for an invalid config, got %d", recorder.Code)
+	}
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"retrospec/services/orchestrator/internal/store"
+)
+
+func scopedRequest(authenticated bool, scopes []string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/v1/sessions/session_1/events", nil)
+	ctx := context.WithValue(req.Context(), keyAuthenticatedContext, authenticated)
+	ctx = context.WithValue(ctx, apiKeyScopesContext, scopes)
+	return req.WithContext(ctx)
+}
+
+func TestRequireScopeRejectsIngestOnlyKeyOnReadRoutes(t *testing.T) {
+	handler := &Handler{ingestAPIKey: "global-key"}
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	recorder := httptest.NewRecorder()
+	handler.requireScope(store.APIKeyScopeRead)(next).ServeHTTP(recorder, scopedRequest(true, []string{store.APIKeyScopeIngest}))
+	if recorder.Code != http.StatusForbidden {
+		t.Fatalf("expected ingest-only key to be forbidden, got %d", recorder.Code)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.requireScope(store.APIKeyScopeIngest)(next).ServeHTTP(recorder, scopedRequest(true, []string{store.APIKeyScopeIngest}))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected ingest-only key to ingest, got %d", recorder.Code)
+	}
+}
+
+func TestRequireKeyScopeRejectsAnonymousRequests(t *testing.T) {
+	handler := &Handler{}
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	recorder := httptest.NewRecorder()
+	handler.requireKeyScope(store.APIKeyScopeRead)(next).ServeHTTP(recorder, scopedRequest(false, handler.anonymousScopes()))
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected anonymous request to be unauthorized, got %d", recorder.Code)
+	}
+}
+
+func TestGlobalIngestKeyIsIngestOnlyUnlessFullAccessIsEnabled(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	send := func(handler *Handler, scope string) int {
+		req := httptest.NewRequest(http.MethodGet, "/v1/issues", nil)
+		req.Header.Set("X-Retrospec-Key", "global-key")
+		recorder := httptest.NewRecorder()
+		handler.withProjectContext(handler.requireScope(scope)(next)).ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	restricted := &Handler{ingestAPIKey: "global-key"}
+	if code := send(restricted, store.APIKeyScopeIngest); code != http.StatusOK {
+		t.Fatalf("expected global key to ingest, got %d", code)
+	}
+	for _, scope := range []string{store.APIKeyScopeRead, store.APIKeyScopeTriage, store.APIKeyScopeAdmin} {
+		if code := send(restricted, scope); code != http.StatusForbidden {
+			t.Fatalf("expected global key to lack %s scope, got %d", scope, code)
+		}
+	}
+
+	fullAccess := &Handler{ingestAPIKey: "global-key", ingestAPIKeyFullAccess: true}
+	if code := send(fullAccess, store.APIKeyScopeAdmin); code != http.StatusOK {
+		t.Fatalf("expected opted-in global key to keep every scope, got %d", code)
+	}
+}
@@ -0,0 +1,294 @@
+package api
+
+import (
+	"context"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+
+	"retrospec/services/orchestrator/internal/logging"
+	"retrospec/services/orchestrator/internal/store"
+)
+
+const (
+	shareLinkContext = requestContextKey("share_link")
+
+	defaultShareLinkTTL = 7 * 24 * time.Hour
+	maxShareLinkTTL     = 90 * 24 * time.Hour
+
+	sharePasswordIterations = 210000
+	minSharePasswordLength  = 8
+)
+
+type createShareLinkRequest struct {
+	ExpiresInHours int    `json:"expiresInHours"`
+	ExpiresAt      string `json:"expiresAt"`
+	Password       string `json:"password"`
+}
+
+func (h *Handler) createSessionShareLink(w http.ResponseWriter, r *http.Request) {
+	payload := createShareLinkRequest{}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid payload"})
+			return
+		}
+	}
+
+	expiresAt, err := shareLinkExpiry(payload, time.Now().UTC())
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	passwordHash := ""
+	if payload.Password != "" {
+		if len(payload.Password) < minSharePasswordLength {
+			writeJSON(w, http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("password must be at least %d characters", minSharePasswordLength),
+			})
+			return
+		}
+		passwordHash, err = hashSharePassword(payload.Password)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "password hashing failed"})
+			return
+		}
+	}
+
+	rawToken, err := generateShareToken()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "token generation failed"})
+		return
+	}
+
+	link, err := h.store.CreateSessionShareLink(
+		r.Context(),
+		h.projectIDFromContext(r.Context()),
+		chi.URLParam(r, "sessionID"),
+		rawToken,
+		passwordHash,
+		expiresAt,
+		h.actorFromContext(r.Context()),
+	)
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
+
+	annotateAuditTarget(r.Context(), "share_link", link.ID)
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"shareLink": link,
+		"token":     rawToken,
+		"path":      "/v1/shared/" + rawToken,
+	})
+}
+
+func (h *Handler) listSessionShareLinks(w http.ResponseWriter, r *http.Request) {
+	links, err := h.store.ListSessionShareLinks(
+		r.Context(),
+		h.projectIDFromContext(r.Context()),
+		chi.URLParam(r, "sessionID"),
+	)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "share link lookup failed"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"shareLinks": links})
+}
+
+func (h *Handler) revokeSessionShareLink(w http.ResponseWriter, r *http.Request) {
+	link, err := h.store.RevokeSessionShareLink(
+		r.Context(),
+		h.projectIDFromContext(r.Context()),
+		chi.URLParam(r, "linkID"),
+		h.actorFromContext(r.Context()),
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "share link not found"})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "share link revocation failed"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"shareLink": link})
+}
+
+// withShareLink resolves the public share token into the link's project so the
+// shared routes run with the same project context as keyed requests.
+func (h *Handler) withShareLink(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		link, err := h.store.ResolveSessionShareLink(r.Context(), chi.URLParam(r, "shareToken"))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "share link not found or expired"})
+				return
+			}
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "share link lookup failed"})
+			return
+		}
+
+		annotateMetricsProject(r.Context(), link.ProjectID)
+		annotateRequestSession(r.Context(), link.ProjectID, link.SessionID)
+		ctx := context.WithValue(r.Context(), projectIDContextKey, link.ProjectID)
+		ctx = context.WithValue(ctx, shareLinkContext, link)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (h *Handler) requireSharePassword(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		link, _ := shareLinkFromContext(r.Context())
+		annotateAuditTarget(r.Context(), "session", link.SessionID)
+		if !link.PasswordProtected {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		provided := r.Header.Get("X-Retrospec-Share-Password")
+		if provided == "" {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "password required"})
+			return
+		}
+		if !verifySharePassword(link.PasswordHash, provided) {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid password"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (h *Handler) getSharedSession(w http.ResponseWriter, r *http.Request) {
+	link, _ := shareLinkFromContext(r.Context())
+	session, err := h.loadSession(r.Context(), link.ProjectID, link.SessionID)
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
+
+	viewed, err := h.store.RecordSessionShareLinkView(r.Context(), link.ID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "share link view count failed", slog.String("share_link_id", link.ID), logging.Err(err))
+		viewed = link
+	}
+
+	session.UserID = ""
+	writeJSON(w, http.StatusOK, map[string]any{
+		"session":   session,
+		"expiresAt": viewed.ExpiresAt,
+		"viewCount": viewed.ViewCount,
+	})
+}
+
+func (h *Handler) getSharedSessionEvents(w http.ResponseWriter, r *http.Request) {
+	link, _ := shareLinkFromContext(r.Context())
+	h.writeSessionEvents(w, r, link.ProjectID, link.SessionID)
+}
+
+func (h *Handler) getSharedSessionArtifact(w http.ResponseWriter, r *http.Request) {
+	link, _ := shareLinkFromContext(r.Context())
+	artifactType := strings.TrimSpace(chi.URLParam(r, "artifactType"))
+	if artifactType == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "artifactType is required"})
+		return
+	}
+
+	h.writeSessionArtifact(w, r, link.ProjectID, link.SessionID, artifactType)
+}
+
+func shareLinkFromContext(ctx context.Context) (store.SessionShareLink, bool) {
+	link, ok := ctx.Value(shareLinkContext).(store.SessionShareLink)
+	return link, ok
+}
+
+func shareLinkExpiry(payload createShareLinkRequest, now time.Time) (time.Time, error) {
+	expiresAt := now.Add(defaultShareLinkTTL)
+	switch {
+	case strings.TrimSpace(payload.ExpiresAt) != "":
+		parsed, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(payload.ExpiresAt))
+		if err != nil {
+			return time.Time{}, errors.New("expiresAt must be RFC3339 timestamp")
+		}
+		expiresAt = parsed.UTC()
+	case payload.ExpiresInHours < 0:
+		return time.Time{}, errors.New("expiresInHours must be positive")
+	case payload.ExpiresInHours > 0:
+		expiresAt = now.Add(time.Duration(payload.ExpiresInHours) * time.Hour)
+	}
+
+	if !expiresAt.After(now) {
+		return time.Time{}, errors.New("expiry must be in the future")
+	}
+	if expiresAt.Sub(now) > maxShareLinkTTL {
+		return time.Time{}, fmt.Errorf("expiry must be within %d days", int(maxShareLinkTTL.Hours()/24))
+	}
+	return expiresAt, nil
+}
+
+func generateShareToken() (string, error) {
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return "rss_" + hex.EncodeToString(raw), nil
+}
+
+// hashSharePassword encodes PBKDF2-SHA256 as pbkdf2-sha256$iterations$salt$hash.
+func hashSharePassword(password string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	derived, err := pbkdf2.Key(sha256.New, password, salt, sharePasswordIterations, 32)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join([]string{
+		"pbkdf2-sha256",
+		strconv.Itoa(sharePasswordIterations),
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(derived),
+	}, "$"), nil
+}
+
+func verifySharePassword(encoded, password string) bool {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations < 1 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	expected, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+
+	derived, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(expected))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(derived, expected) == 1
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSharePasswordRoundTrip(t *testing.T) {
+	encoded, err := hashSharePassword("correct horse")
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	if !verifySharePassword(encoded, "correct horse") {
+		t.Fatalf("expected matching password to verify")
+	}
+	if verifySharePassword(encoded, "wrong horse") {
+		t.Fatalf("expected wrong password to fail")
+	}
+	if verifySharePassword("plain-text", "plain-text") {
+		t.Fatalf("expected malformed hash to fail")
+	}
+}
+
+func TestShareLinkExpiryBounds(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	expiresAt, err := shareLinkExpiry(createShareLinkRequest{}, now)
+	if err != nil || !expiresAt.Equal(now.Add(defaultShareLinkTTL)) {
+		t.Fatalf("expected default ttl, got %v err=%v", expiresAt, err)
+	}
+
+	expiresAt, err = shareLinkExpiry(createShareLinkRequest{ExpiresInHours: 2}, now)
+	if err != nil || !expiresAt.Equal(now.Add(2*time.Hour)) {
+		t.Fatalf("expected two hour ttl, got %v err=%v", expiresAt, err)
+	}
+
+	if _, err := shareLinkExpiry(createShareLinkRequest{ExpiresInHours: 24 * 91}, now); err == nil {
+		t.Fatalf("expected expiry beyond max ttl to fail")
+	}
+	if _, err := shareLinkExpiry(createShareLinkRequest{ExpiresAt: "2025-12-31T00:00:00Z"}, now); err == nil {
+		t.Fatalf("expected past expiry to fail")
+	}
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"retrospec/services/orchestrator/internal/logging"
+	"retrospec/services/orchestrator/internal/tracing"
+)
+
+const internalCallbackPathPrefix = "/v1/internal/"
+
+// traceRequests opens a server span per request. Incoming trace context is
+// only honoured on internal callbacks, where workers continue the trace the
+// enqueue started; public callers always start a new trace.
+func traceRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if strings.HasPrefix(r.URL.Path, internalCallbackPathPrefix) {
+			ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(r.Header))
+		}
+
+		ctx, span := tracing.Tracer().Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+				attribute.String("client.address", clientAddress(r)),
+			),
+		)
+		defer span.End()
+
+		recorder := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		status := recorder.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if routeContext := chi.RouteContext(r.Context()); routeContext != nil && routeContext.RoutePattern() != "" {
+			span.SetName(r.Method + " " + routeContext.RoutePattern())
+			span.SetAttributes(attribute.String("http.route", routeContext.RoutePattern()))
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
+
+// annotateRequestSession tags the request span and log fields with the project
+// and, when known, the session it acted on.
+func annotateRequestSession(ctx context.Context, projectID, sessionID string) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.String("retrospec.project_id", projectID))
+	logging.SetProject(ctx, projectID)
+	if sessionID != "" {
+		span.SetAttributes(attribute.String("retrospec.session_id", sessionID))
+		logging.SetSession(ctx, sessionID)
+	}
+}
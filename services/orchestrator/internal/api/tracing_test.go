@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"retrospec/services/orchestrator/internal/tracing"
+)
+
+func TestTraceRequestsContinuesTraceOnInternalCallbacksOnly(t *testing.T) {
+	exporter, restore := tracing.NewInMemoryProvider()
+	t.Cleanup(restore)
+
+	router := chi.NewRouter()
+	router.Use(traceRequests)
+	router.Post("/v1/internal/analysis-reports", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	router.Post("/v1/sessions/{sessionID}/notes", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	const traceparent = "00-" + traceID + "-00f067aa0ba902b7-01"
+	for _, path := range []string{"/v1/internal/analysis-reports", "/v1/sessions/s1/notes"} {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.Header.Set("traceparent", traceparent)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	if spans[0].Name != "POST /v1/internal/analysis-reports" || spans[0].SpanContext.TraceID().String() != traceID {
+		t.Fatalf("expected internal callback to continue the worker trace, got %s trace=%s", spans[0].Name, spans[0].SpanContext.TraceID())
+	}
+	if !spans[0].Parent.IsRemote() {
+		t.Fatalf("expected internal callback span to have a remote parent")
+	}
+	if spans[1].Name != "POST /v1/sessions/{sessionID}/notes" || spans[1].SpanContext.TraceID().String() == traceID {
+		t.Fatalf("expected public request to start a new trace, got %s trace=%s", spans[1].Name, spans[1].SpanContext.TraceID())
+	}
+}
@@ -0,0 +1,446 @@
+package artifacts
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EncryptionAlgorithm         = "aes-256-gcm"
+	MetadataEncryptionKey       = "retrospec-encryption"
+	MetadataDataKeyIDKey        = "retrospec-data-key-id"
+	MetadataOriginalContentType = "retrospec-content-type"
+)
+
+var (
+	ErrDataKeyNotFound = errors.New("data key not found")
+	ErrObjectNotSealed = errors.New("object is not encrypted")
+)
+
+type projectContextKey struct{}
+
+// ObjectBackend is the raw object API the encrypting decorator writes through;
+// metadata carries the data key ID so reads never need to guess.
+type ObjectBackend interface {
+	Store
+	PutObject(ctx context.Context, objectKey string, body []byte, contentType string, metadata map[string]string) error
+	GetObject(ctx context.Context, objectKey string) ([]byte, string, map[string]string, error)
+}
+
+type DataKey struct {
+	ID          string
+	ProjectID   string
+	MasterKeyID string
+	WrappedKey  []byte
+}
+
+type DataKeyRepository interface {
+	ActiveDataKey(ctx context.Context, projectID string) (DataKey, error)
+	DataKey(ctx context.Context, keyID string) (DataKey, error)
+	CreateDataKey(ctx context.Context, key DataKey) (DataKey, error)
+	DataKeysForRewrap(ctx context.Context, projectID, activeMasterKeyID string, limit int) ([]DataKey, error)
+	RewrapDataKey(ctx context.Context, keyID, fromMasterKeyID string, key DataKey) error
+}
+
+type DataKeyRewrapper interface {
+	RewrapDataKeys(ctx context.Context, projectID string, limit int) (RewrapResult, error)
+}
+
+type RewrapResult struct {
+	ActiveMasterKeyID string `json:"activeMasterKeyId"`
+	Scanned           int    `json:"scanned"`
+	Rewrapped         int    `json:"rewrapped"`
+	Failed            int    `json:"failed"`
+}
+
+type MasterKeyring struct {
+	activeID string
+	keys     map[string][]byte
+}
+
+type masterKeyringFile struct {
+	ActiveKeyID string            `json:"activeKeyId"`
+	Keys        map[string]string `json:"keys"`
+}
+
+func WithProjectID(ctx context.Context, projectID string) context.Context {
+	return context.WithValue(ctx, projectContextKey{}, strings.TrimSpace(projectID))
+}
+
+func projectIDFromContext(ctx context.Context) string {
+	projectID, _ := ctx.Value(projectContextKey{}).(string)
+	return projectID
+}
+
+// LoadMasterKeyring reads master keys from a JSON keyring file and/or a single
+// key supplied through config. Retired keys stay in the file so existing data
+// keys can still be unwrapped until the re-wrap job has moved them over.
+func LoadMasterKeyring(keyringFile, keyID, encodedKey string) (*MasterKeyring, error) {
+	keyring := &MasterKeyring{keys: map[string][]byte{}}
+
+	if path := strings.TrimSpace(keyringFile); path != "" {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read master keyring: %w", err)
+		}
+		parsed := masterKeyringFile{}
+		if err := json.Unmarshal(raw, &parsed); err != nil {
+			return nil, fmt.Errorf("parse master keyring: %w", err)
+		}
+		for id, value := range parsed.Keys {
+			if err := keyring.add(id, value); err != nil {
+				return nil, err
+			}
+		}
+		keyring.activeID = strings.TrimSpace(parsed.ActiveKeyID)
+	}
+
+	if strings.TrimSpace(encodedKey) != "" {
+		id := strings.TrimSpace(keyID)
+		if id == "" {
+			id = "config"
+		}
+		if err := keyring.add(id, encodedKey); err != nil {
+			return nil, err
+		}
+		keyring.activeID = id
+	}
+
+	if keyring.activeID == "" {
+		return nil, fmt.Errorf("master keyring has no active key")
+	}
+	if _, ok := keyring.keys[keyring.activeID]; !ok {
+		return nil, fmt.Errorf("active master key %q is not in the keyring", keyring.activeID)
+	}
+	return keyring, nil
+}
+
+func (k *MasterKeyring) ActiveKeyID() string {
+	return k.activeID
+}
+
+func (k *MasterKeyring) add(id, encoded string) error {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return fmt.Errorf("master key id is required")
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return fmt.Errorf("master key %q is not valid base64: %w", id, err)
+	}
+	if len(key) != 32 {
+		return fmt.Errorf("master key %q must be 32 bytes", id)
+	}
+	k.keys[id] = key
+	return nil
+}
+
+func (k *MasterKeyring) wrap(dataKey DataKey, plaintext []byte) (DataKey, error) {
+	wrapped, err := sealAESGCM(k.keys[k.activeID], plaintext, dataKeyAAD(dataKey))
+	if err != nil {
+		return DataKey{}, err
+	}
+	dataKey.MasterKeyID = k.activeID
+	dataKey.WrappedKey = wrapped
+	return dataKey, nil
+}
+
+func (k *MasterKeyring) unwrap(dataKey DataKey) ([]byte, error) {
+	masterKey, ok := k.keys[dataKey.MasterKeyID]
+	if !ok {
+		return nil, fmt.Errorf("master key %q is not in the keyring", dataKey.MasterKeyID)
+	}
+	return openAESGCM(masterKey, dataKey.WrappedKey, dataKeyAAD(dataKey))
+}
+
+type EncryptedStore struct {
+	backend        ObjectBackend
+	keyring        *MasterKeyring
+	dataKeys       DataKeyRepository
+	allowPlaintext bool
+
+	mu            sync.Mutex
+	plaintextKeys map[string][]byte
+	activeKeyIDs  map[string]string
+}
+
+// NewEncryptedStore wraps backend. With allowPlaintext, objects without
+// encryption metadata (written before encryption was enabled) are still read;
+// otherwise reading them fails with ErrObjectNotSealed.
+func NewEncryptedStore(
+	backend ObjectBackend,
+	keyring *MasterKeyring,
+	dataKeys DataKeyRepository,
+	allowPlaintext bool,
+) *EncryptedStore {
+	return &EncryptedStore{
+		backend:        backend,
+		keyring:        keyring,
+		dataKeys:       dataKeys,
+		allowPlaintext: allowPlaintext,
+		plaintextKeys:  map[string][]byte{},
+		activeKeyIDs:   map[string]string{},
+	}
+}
+
+func (s *EncryptedStore) StoreJSON(ctx context.Context, objectKey string, payload json.RawMessage) error {
+	if !json.Valid(payload) {
+		return fmt.Errorf("artifact payload is not valid json: %s", objectKey)
+	}
+	return s.StoreObject(ctx, objectKey, bytes.TrimSpace(payload), "application/json")
+}
+
+func (s *EncryptedStore) StoreObject(ctx context.Context, objectKey string, body []byte, contentType string) error {
+	projectID := projectIDFromContext(ctx)
+	if projectID == "" {
+		return fmt.Errorf("project id is required to encrypt %s", objectKey)
+	}
+
+	keyID, key, err := s.activeDataKey(ctx, projectID)
+	if err != nil {
+		return fmt.Errorf("resolve data key: %w", err)
+	}
+
+	sealed, err := sealAESGCM(key, body, []byte(objectKey))
+	if err != nil {
+		return err
+	}
+
+	return s.backend.PutObject(ctx, objectKey, sealed, "application/octet-stream", map[string]string{
+		MetadataEncryptionKey:       EncryptionAlgorithm,
+		MetadataDataKeyIDKey:        keyID,
+		MetadataOriginalContentType: contentType,
+	})
+}
+
+func (s *EncryptedStore) LoadJSON(ctx context.Context, objectKey string) (json.RawMessage, error) {
+	payload, _, err := s.LoadObject(ctx, objectKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if !json.Valid(payload) {
+		return nil, fmt.Errorf("artifact is not valid json: %s", objectKey)
+	}
+
+	return json.RawMessage(bytes.TrimSpace(payload)), nil
+}
+
+func (s *EncryptedStore) LoadObject(ctx context.Context, objectKey string) ([]byte, string, error) {
+	body, contentType, metadata, err := s.backend.GetObject(ctx, objectKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	algorithm := metadata[MetadataEncryptionKey]
+	if algorithm == "" {
+		if !s.allowPlaintext {
+			return nil, "", fmt.Errorf("%w: %s", ErrObjectNotSealed, objectKey)
+		}
+		return body, contentType, nil
+	}
+	if algorithm != EncryptionAlgorithm {
+		return nil, "", fmt.Errorf("unsupported object encryption %q: %s", algorithm, objectKey)
+	}
+
+	key, err := s.dataKey(ctx, metadata[MetadataDataKeyIDKey])
+	if err != nil {
+		return nil, "", fmt.Errorf("resolve data key for %s: %w", objectKey, err)
+	}
+
+	plaintext, err := openAESGCM(key, body, []byte(objectKey))
+	if err != nil {
+		return nil, "", fmt.Errorf("decrypt %s: %w", objectKey, err)
+	}
+
+	if original := metadata[MetadataOriginalContentType]; original != "" {
+		contentType = original
+	}
+	return plaintext, contentType, nil
+}
+
+func (s *EncryptedStore) DeleteObject(ctx context.Context, objectKey string) error {
+	return s.backend.DeleteObject(ctx, objectKey)
+}
+
+func (s *EncryptedStore) Close() error {
+	return s.backend.Close()
+}
+
+func (s *EncryptedStore) EnsureLifecyclePolicy(ctx context.Context, expirationDays int, prefixes []string) error {
+	configurer, ok := s.backend.(LifecycleConfigurer)
+	if !ok {
+		return ErrNotConfigured
+	}
+	return configurer.EnsureLifecyclePolicy(ctx, expirationDays, prefixes)
+}
+
+func (s *EncryptedStore) Health(ctx context.Context) error {
+	checker, ok := s.backend.(HealthChecker)
+	if !ok {
+		return ErrNotConfigured
+	}
+	return checker.Health(ctx)
+}
+
+func (s *EncryptedStore) SetObjectRetentionHold(ctx context.Context, objectKey string, held bool) error {
+	holder, ok := s.backend.(RetentionHolder)
+	if !ok {
+		return ErrNotConfigured
+	}
+	return holder.SetObjectRetentionHold(ctx, objectKey, held)
+}
+
+// RewrapDataKeys moves a project's data keys onto the active master key. Object
+// ciphertext is untouched because the plaintext data keys do not change.
+func (s *EncryptedStore) RewrapDataKeys(ctx context.Context, projectID string, limit int) (RewrapResult, error) {
+	result := RewrapResult{ActiveMasterKeyID: s.keyring.ActiveKeyID()}
+	if limit < 1 {
+		limit = 100
+	}
+
+	keys, err := s.dataKeys.DataKeysForRewrap(ctx, projectID, s.keyring.ActiveKeyID(), limit)
+	if err != nil {
+		return result, err
+	}
+
+	for _, key := range keys {
+		result.Scanned++
+		plaintext, err := s.keyring.unwrap(key)
+		if err != nil {
+			result.Failed++
+			continue
+		}
+		rewrapped, err := s.keyring.wrap(key, plaintext)
+		if err != nil {
+			result.Failed++
+			continue
+		}
+		if err := s.dataKeys.RewrapDataKey(ctx, key.ID, key.MasterKeyID, rewrapped); err != nil {
+			result.Failed++
+			continue
+		}
+		result.Rewrapped++
+	}
+
+	return result, nil
+}
+
+func (s *EncryptedStore) activeDataKey(ctx context.Context, projectID string) (string, []byte, error) {
+	s.mu.Lock()
+	keyID := s.activeKeyIDs[projectID]
+	key := s.plaintextKeys[keyID]
+	s.mu.Unlock()
+	if keyID != "" && key != nil {
+		return keyID, key, nil
+	}
+
+	existing, err := s.dataKeys.ActiveDataKey(ctx, projectID)
+	if err != nil && !errors.Is(err, ErrDataKeyNotFound) {
+		return "", nil, err
+	}
+	if err == nil {
+		return s.cacheDataKey(projectID, existing)
+	}
+
+	plaintext := make([]byte, 32)
+	if _, err := rand.Read(plaintext); err != nil {
+		return "", nil, err
+	}
+	created, err := s.keyring.wrap(DataKey{ID: "dek_" + uuid.NewString(), ProjectID: projectID}, plaintext)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// A concurrent writer may have created the project's key first; the
+	// repository returns whichever key won.
+	stored, err := s.dataKeys.CreateDataKey(ctx, created)
+	if err != nil {
+		return "", nil, err
+	}
+	return s.cacheDataKey(projectID, stored)
+}
+
+func (s *EncryptedStore) dataKey(ctx context.Context, keyID string) ([]byte, error) {
+	keyID = strings.TrimSpace(keyID)
+	if keyID == "" {
+		return nil, ErrDataKeyNotFound
+	}
+
+	s.mu.Lock()
+	key := s.plaintextKeys[keyID]
+	s.mu.Unlock()
+	if key != nil {
+		return key, nil
+	}
+
+	stored, err := s.dataKeys.DataKey(ctx, keyID)
+	if err != nil {
+		return nil, err
+	}
+	_, key, err = s.cacheDataKey("", stored)
+	return key, err
+}
+
+func (s *EncryptedStore) cacheDataKey(projectID string, dataKey DataKey) (string, []byte, error) {
+	plaintext, err := s.keyring.unwrap(dataKey)
+	if err != nil {
+		return "", nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.plaintextKeys[dataKey.ID] = plaintext
+	if projectID != "" {
+		s.activeKeyIDs[projectID] = dataKey.ID
+	}
+	return dataKey.ID, plaintext, nil
+}
+
+func dataKeyAAD(dataKey DataKey) []byte {
+	return []byte(dataKey.ProjectID + "/" + dataKey.ID)
+}
+
+func sealAESGCM(key, plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := newAESGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func openAESGCM(key, sealed, additionalData []byte) ([]byte, error) {
+	aead, err := newAESGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,235 @@
+package artifacts
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type memoryObject struct {
+	body        []byte
+	contentType string
+	metadata    map[string]string
+}
+
+type memoryBackend struct {
+	objects map[string]memoryObject
+}
+
+func (b *memoryBackend) StoreJSON(ctx context.Context, objectKey string, payload json.RawMessage) error {
+	return b.PutObject(ctx, objectKey, payload, "application/json", nil)
+}
+
+func (b *memoryBackend) LoadJSON(ctx context.Context, objectKey string) (json.RawMessage, error) {
+	body, _, err := b.LoadObject(ctx, objectKey)
+	return body, err
+}
+
+func (b *memoryBackend) LoadObject(ctx context.Context, objectKey string) ([]byte, string, error) {
+	body, contentType, _, err := b.GetObject(ctx, objectKey)
+	return body, contentType, err
+}
+
+func (b *memoryBackend) DeleteObject(_ context.Context, objectKey string) error {
+	delete(b.objects, objectKey)
+	return nil
+}
+
+func (b *memoryBackend) Close() error {
+	return nil
+}
+
+func (b *memoryBackend) PutObject(
+	_ context.Context,
+	objectKey string,
+	body []byte,
+	contentType string,
+	metadata map[string]string,
+) error {
+	b.objects[objectKey] = memoryObject{body: append([]byte{}, body...), contentType: contentType, metadata: metadata}
+	return nil
+}
+
+func (b *memoryBackend) GetObject(_ context.Context, objectKey string) ([]byte, string, map[string]string, error) {
+	object, ok := b.objects[objectKey]
+	if !ok {
+		return nil, "", nil, os.ErrNotExist
+	}
+	return object.body, object.contentType, object.metadata, nil
+}
+
+type memoryDataKeys struct {
+	keys map[string]DataKey
+}
+
+func (m *memoryDataKeys) ActiveDataKey(_ context.Context, projectID string) (DataKey, error) {
+	for _, key := range m.keys {
+		if key.ProjectID == projectID {
+			return key, nil
+		}
+	}
+	return DataKey{}, ErrDataKeyNotFound
+}
+
+func (m *memoryDataKeys) DataKey(_ context.Context, keyID string) (DataKey, error) {
+	key, ok := m.keys[keyID]
+	if !ok {
+		return DataKey{}, ErrDataKeyNotFound
+	}
+	return key, nil
+}
+
+func (m *memoryDataKeys) CreateDataKey(_ context.Context, key DataKey) (DataKey, error) {
+	m.keys[key.ID] = key
+	return key, nil
+}
+
+func (m *memoryDataKeys) DataKeysForRewrap(_ context.Context, projectID, activeMasterKeyID string, _ int) ([]DataKey, error) {
+	keys := []DataKey{}
+	for _, key := range m.keys {
+		if key.ProjectID == projectID && key.MasterKeyID != activeMasterKeyID {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (m *memoryDataKeys) RewrapDataKey(_ context.Context, keyID, _ string, key DataKey) error {
+	m.keys[keyID] = key
+	return nil
+}
+
+func testMasterKey(fill byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{fill}, 32))
+}
+
+func TestEncryptedStoreRoundTripsAndHidesPlaintext(t *testing.T) {
+	keyring, err := LoadMasterKeyring("", "mk-1", testMasterKey(1))
+	if err != nil {
+		t.Fatalf("load keyring: %v", err)
+	}
+	backend := &memoryBackend{objects: map[string]memoryObject{}}
+	store := NewEncryptedStore(backend, keyring, &memoryDataKeys{keys: map[string]DataKey{}}, false)
+
+	ctx := WithProjectID(context.Background(), "proj_test")
+	payload := json.RawMessage(`[{"type":"input","value":"secret-value"}]`)
+	if err := store.StoreJSON(ctx, "session-events/proj_test/a.json", payload); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	stored := backend.objects["session-events/proj_test/a.json"]
+	if bytes.Contains(stored.body, []byte("secret-value")) {
+		t.Fatal("expected stored object to be encrypted")
+	}
+	if stored.metadata[MetadataDataKeyIDKey] == "" {
+		t.Fatal("expected data key id in object metadata")
+	}
+
+	loaded, err := store.LoadJSON(context.Background(), "session-events/proj_test/a.json")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !bytes.Equal(loaded, payload) {
+		t.Fatalf("unexpected payload: %s", loaded)
+	}
+}
+
+func TestEncryptedStoreReadsPlaintextObjectsOnlyWhenAllowed(t *testing.T) {
+	keyring, err := LoadMasterKeyring("", "mk-1", testMasterKey(1))
+	if err != nil {
+		t.Fatalf("load keyring: %v", err)
+	}
+	backend := &memoryBackend{objects: map[string]memoryObject{}}
+	backend.objects["replay-artifacts/a.json"] = memoryObject{body: []byte(`{"ok":true}`), contentType: "application/json"}
+
+	strict := NewEncryptedStore(backend, keyring, &memoryDataKeys{keys: map[string]DataKey{}}, false)
+	if _, _, err := strict.LoadObject(context.Background(), "replay-artifacts/a.json"); !errors.Is(err, ErrObjectNotSealed) {
+		t.Fatalf("expected plaintext object to be rejected, got %v", err)
+	}
+
+	legacy := NewEncryptedStore(backend, keyring, &memoryDataKeys{keys: map[string]DataKey{}}, true)
+	loaded, contentType, err := legacy.LoadObject(context.Background(), "replay-artifacts/a.json")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if string(loaded) != `{"ok":true}` || contentType != "application/json" {
+		t.Fatalf("unexpected passthrough result: %s %s", loaded, contentType)
+	}
+}
+
+func TestEncryptedStoreEncryptsBinaryArtifacts(t *testing.T) {
+	keyring, err := LoadMasterKeyring("", "mk-1", testMasterKey(1))
+	if err != nil {
+		t.Fatalf("load keyring: %v", err)
+	}
+	backend := &memoryBackend{objects: map[string]memoryObject{}}
+	store := NewEncryptedStore(backend, keyring, &memoryDataKeys{keys: map[string]DataKey{}}, false)
+
+	ctx := WithProjectID(context.Background(), "proj_test")
+	video := []byte("\x1aE\xdf\xa3webm-frame \n")
+	if err := store.StoreObject(ctx, "replay-artifacts/s1/full-replay.webm", video, "video/webm"); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	stored := backend.objects["replay-artifacts/s1/full-replay.webm"]
+	if bytes.Contains(stored.body, []byte("webm-frame")) {
+		t.Fatal("expected stored artifact to be encrypted")
+	}
+
+	loaded, contentType, err := store.LoadObject(context.Background(), "replay-artifacts/s1/full-replay.webm")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !bytes.Equal(loaded, video) || contentType != "video/webm" {
+		t.Fatalf("unexpected artifact: %q %s", loaded, contentType)
+	}
+}
+
+func TestEncryptedStoreRewrapKeepsObjectsReadable(t *testing.T) {
+	backend := &memoryBackend{objects: map[string]memoryObject{}}
+	dataKeys := &memoryDataKeys{keys: map[string]DataKey{}}
+
+	oldKeyring, err := LoadMasterKeyring("", "mk-1", testMasterKey(1))
+	if err != nil {
+		t.Fatalf("load keyring: %v", err)
+	}
+	ctx := WithProjectID(context.Background(), "proj_test")
+	if err := NewEncryptedStore(backend, oldKeyring, dataKeys, false).StoreJSON(ctx, "session-events/proj_test/a.json", json.RawMessage(`{"a":1}`)); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	keyringPath := filepath.Join(t.TempDir(), "keyring.json")
+	keyringFile, _ := json.Marshal(masterKeyringFile{
+		ActiveKeyID: "mk-2",
+		Keys:        map[string]string{"mk-1": testMasterKey(1), "mk-2": testMasterKey(2)},
+	})
+	if err := os.WriteFile(keyringPath, keyringFile, 0o600); err != nil {
+		t.Fatalf("write keyring: %v", err)
+	}
+	rotated, err := LoadMasterKeyring(keyringPath, "", "")
+	if err != nil {
+		t.Fatalf("load rotated keyring: %v", err)
+	}
+
+	result, err := NewEncryptedStore(backend, rotated, dataKeys, false).RewrapDataKeys(context.Background(), "proj_test", 10)
+	if err != nil || result.Rewrapped != 1 || result.Failed != 0 {
+		t.Fatalf("unexpected rewrap result: %+v err=%v", result, err)
+	}
+
+	newOnly, err := LoadMasterKeyring("", "mk-2", testMasterKey(2))
+	if err != nil {
+		t.Fatalf("load new keyring: %v", err)
+	}
+	loaded, err := NewEncryptedStore(backend, newOnly, dataKeys, false).LoadJSON(context.Background(), "session-events/proj_test/a.json")
+	if err != nil {
+		t.Fatalf("load after rewrap: %v", err)
+	}
+	if string(loaded) != `{"a":1}` {
+		t.Fatalf("unexpected payload after rewrap: %s", loaded)
+	}
+}
@@ -4,15 +4,24 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsConfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	smithymiddleware "github.com/aws/smithy-go/middleware"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"retrospec/services/orchestrator/internal/metrics"
+	"retrospec/services/orchestrator/internal/tracing"
 )
 
 type S3Store struct {
@@ -39,6 +48,7 @@ func NewS3Store(
 			o.BaseEndpoint = aws.String(endpoint)
 			o.UsePathStyle = true
 		}
+		o.APIOptions = append(o.APIOptions, addOperationMetrics)
 	})
 
 	return &S3Store{bucket: bucket, client: client}, nil
@@ -49,11 +59,27 @@ func (s *S3Store) StoreJSON(ctx context.Context, objectKey string, payload json.
 		return fmt.Errorf("artifact payload is not valid json: %s", objectKey)
 	}
 
+	return s.PutObject(ctx, objectKey, bytes.TrimSpace(payload), "application/json", nil)
+}
+
+func (s *S3Store) StoreObject(ctx context.Context, objectKey string, body []byte, contentType string) error {
+	return s.PutObject(ctx, objectKey, body, contentType, nil)
+}
+
+func (s *S3Store) PutObject(
+	ctx context.Context,
+	objectKey string,
+	body []byte,
+	contentType string,
+	metadata map[string]string,
+) error {
 	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
 		Bucket:      aws.String(s.bucket),
 		Key:         aws.String(objectKey),
-		Body:        bytes.NewReader(bytes.TrimSpace(payload)),
-		ContentType: aws.String("application/json"),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String(contentType),
+		Metadata:    metadata,
+		Tagging:     aws.String(RetentionTagKey + "=" + RetentionTagExpire),
 	})
 	if err != nil {
 		return err
@@ -76,18 +102,27 @@ func (s *S3Store) LoadJSON(ctx context.Context, objectKey string) (json.RawMessa
 }
 
 func (s *S3Store) LoadObject(ctx context.Context, objectKey string) ([]byte, string, error) {
+	payload, contentType, _, err := s.GetObject(ctx, objectKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return bytes.TrimSpace(payload), contentType, nil
+}
+
+func (s *S3Store) GetObject(ctx context.Context, objectKey string) ([]byte, string, map[string]string, error) {
 	resp, err := s.client.GetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(objectKey),
 	})
 	if err != nil {
-		return nil, "", err
+		return nil, "", nil, err
 	}
 	defer resp.Body.Close()
 
 	payload, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, "", err
+		return nil, "", nil, err
 	}
 
 	contentType := ""
@@ -95,7 +130,7 @@ func (s *S3Store) LoadObject(ctx context.Context, objectKey string) ([]byte, str
 		contentType = *resp.ContentType
 	}
 
-	return bytes.TrimSpace(payload), contentType, nil
+	return payload, contentType, resp.Metadata, nil
 }
 
 func (s *S3Store) DeleteObject(ctx context.Context, objectKey string) error {
@@ -106,6 +141,37 @@ func (s *S3Store) DeleteObject(ctx context.Context, objectKey string) error {
 	return err
 }
 
+func (s *S3Store) SetObjectRetentionHold(ctx context.Context, objectKey string, held bool) error {
+	value := RetentionTagExpire
+	if held {
+		value = RetentionTagHold
+	}
+
+	_, err := s.client.PutObjectTagging(ctx, &s3.PutObjectTaggingInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(objectKey),
+		Tagging: &types.Tagging{
+			TagSet: []types.Tag{
+				{Key: aws.String(RetentionTagKey), Value: aws.String(value)},
+			},
+		},
+	})
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchKey" {
+		return fmt.Errorf("put object retention tag: %w", ErrObjectNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("put object retention tag: %w", err)
+	}
+	return nil
+}
+
+// Health confirms the bucket exists and the credentials can reach it.
+func (s *S3Store) Health(ctx context.Context) error {
+	_, err := s.client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(s.bucket)})
+	return err
+}
+
 func (s *S3Store) EnsureLifecyclePolicy(
 	ctx context.Context,
 	expirationDays int,
@@ -129,9 +195,17 @@ func (s *S3Store) EnsureLifecyclePolicy(
 
 	for index, prefix := range normalizedPrefixes {
 		ruleID := fmt.Sprintf("retrospec-expire-%d", index+1)
-		filter := &types.LifecycleRuleFilter{}
+		// Only objects tagged for expiry are lifecycle-managed, so legal holds can
+		// exempt an object by re-tagging it.
+		expireTag := types.Tag{Key: aws.String(RetentionTagKey), Value: aws.String(RetentionTagExpire)}
+		filter := &types.LifecycleRuleFilter{Tag: &expireTag}
 		if prefix != "" {
-			filter.Prefix = aws.String(prefix)
+			filter = &types.LifecycleRuleFilter{
+				And: &types.LifecycleRuleAndOperator{
+					Prefix: aws.String(prefix),
+					Tags:   []types.Tag{expireTag},
+				},
+			}
 		}
 
 		rules = append(rules, types.LifecycleRule{
@@ -163,6 +237,37 @@ func (s *S3Store) Close() error {
 	return nil
 }
 
+// addOperationMetrics times and traces each SDK operation, retries included. It
+// runs last in the initialize step, so it wraps serialization, signing and
+// transport.
+func addOperationMetrics(stack *smithymiddleware.Stack) error {
+	operation := stack.ID()
+	return stack.Initialize.Add(smithymiddleware.InitializeMiddlewareFunc(
+		"RetrospecOperationMetrics",
+		func(
+			ctx context.Context,
+			in smithymiddleware.InitializeInput,
+			next smithymiddleware.InitializeHandler,
+		) (smithymiddleware.InitializeOutput, smithymiddleware.Metadata, error) {
+			ctx, span := tracing.Tracer().Start(ctx, "s3 "+operation,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					attribute.String("rpc.system", "aws-api"),
+					attribute.String("rpc.service", "S3"),
+					attribute.String("rpc.method", operation),
+				),
+			)
+			defer span.End()
+
+			started := time.Now()
+			out, metadata, err := next.HandleInitialize(ctx, in)
+			metrics.ObserveS3Operation(operation, started, err)
+			tracing.RecordError(span, err)
+			return out, metadata, err
+		},
+	), smithymiddleware.After)
+}
+
 func normalizeLifecyclePrefixes(prefixes []string) []string {
 	if len(prefixes) == 0 {
 		return []string{""}
@@ -8,6 +8,14 @@ import (
 
 var ErrNotConfigured = errors.New("artifact store not configured")
 
+var ErrObjectNotFound = errors.New("artifact object not found")
+
+const (
+	RetentionTagKey    = "retrospec-retention"
+	RetentionTagExpire = "expire"
+	RetentionTagHold   = "hold"
+)
+
 type Store interface {
 	StoreJSON(ctx context.Context, objectKey string, payload json.RawMessage) error
 	LoadJSON(ctx context.Context, objectKey string) (json.RawMessage, error)
@@ -16,10 +24,24 @@ type Store interface {
 	Close() error
 }
 
+// ObjectWriter stores raw bytes, such as worker-rendered replay artifacts.
+type ObjectWriter interface {
+	StoreObject(ctx context.Context, objectKey string, body []byte, contentType string) error
+}
+
 type LifecycleConfigurer interface {
 	EnsureLifecyclePolicy(ctx context.Context, expirationDays int, prefixes []string) error
 }
 
+// HealthChecker is implemented by stores backed by a remote bucket.
+type HealthChecker interface {
+	Health(ctx context.Context) error
+}
+
+type RetentionHolder interface {
+	SetObjectRetentionHold(ctx context.Context, objectKey string, held bool) error
+}
+
 type NoopStore struct{}
 
 func NewNoopStore() *NoopStore {
@@ -30,6 +52,10 @@ func (s *NoopStore) StoreJSON(_ context.Context, _ string, _ json.RawMessage) er
 	return ErrNotConfigured
 }
 
+func (s *NoopStore) StoreObject(_ context.Context, _ string, _ []byte, _ string) error {
+	return ErrNotConfigured
+}
+
 func (s *NoopStore) LoadJSON(_ context.Context, _ string) (json.RawMessage, error) {
 	return nil, ErrNotConfigured
 }
@@ -49,3 +75,7 @@ func (s *NoopStore) Close() error {
 func (s *NoopStore) EnsureLifecyclePolicy(_ context.Context, _ int, _ []string) error {
 	return ErrNotConfigured
 }
+
+func (s *NoopStore) SetObjectRetentionHold(_ context.Context, _ string, _ bool) error {
+	return ErrNotConfigured
+}
@@ -0,0 +1,424 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid bearer token")
+	ErrUnknownKey   = errors.New("bearer token signed with unknown key")
+)
+
+const (
+	clockLeeway       = 60 * time.Second
+	minRefreshBackoff = time.Minute
+)
+
+type JWTConfig struct {
+	Issuer        string
+	Audience      string
+	JWKSFile      string
+	JWKSURL       string
+	ProjectsClaim string
+	CacheTTL      time.Duration
+	HTTPClient    *http.Client
+}
+
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Projects      []string
+	Raw           map[string]any
+}
+
+// AllowsProject reports whether the token grants access to projectID; a "*"
+// entry in the projects claim grants every project.
+func (c Claims) AllowsProject(projectID string) bool {
+	for _, candidate := range c.Projects {
+		if candidate == "*" || candidate == projectID {
+			return true
+		}
+	}
+	return false
+}
+
+type JWTVerifier struct {
+	cfg JWTConfig
+
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey
+	loadedAt    time.Time
+	lastRefresh time.Time
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+func NewJWTVerifier(ctx context.Context, cfg JWTConfig) (*JWTVerifier, error) {
+	cfg.Issuer = strings.TrimSpace(cfg.Issuer)
+	cfg.JWKSFile = strings.TrimSpace(cfg.JWKSFile)
+	cfg.JWKSURL = strings.TrimSpace(cfg.JWKSURL)
+	if cfg.Issuer == "" {
+		return nil, fmt.Errorf("jwt issuer is required")
+	}
+	if cfg.JWKSFile == "" && cfg.JWKSURL == "" {
+		return nil, fmt.Errorf("jwks file or url is required")
+	}
+	if strings.TrimSpace(cfg.ProjectsClaim) == "" {
+		cfg.ProjectsClaim = "retrospec_projects"
+	}
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = 15 * time.Minute
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 5 * time.Second}
+	}
+
+	verifier := &JWTVerifier{cfg: cfg}
+	if err := verifier.refresh(ctx); err != nil {
+		return nil, err
+	}
+	return verifier, nil
+}
+
+func (v *JWTVerifier) Verify(ctx context.Context, token string) (Claims, error) {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return Claims{}, ErrInvalidToken
+	}
+
+	header := jwtHeader{}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return Claims{}, err
+	}
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return Claims{}, err
+	}
+
+	raw := map[string]any{}
+	if err := decodeSegment(parts[1], &raw); err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	if err := v.validateRegisteredClaims(raw); err != nil {
+		return Claims{}, err
+	}
+
+	claims := Claims{
+		Subject:       stringClaim(raw, "sub"),
+		Email:         stringClaim(raw, "email"),
+		EmailVerified: boolClaim(raw, "email_verified"),
+		Projects:      listClaim(raw, v.cfg.ProjectsClaim),
+		Raw:           raw,
+	}
+	if claims.Subject == "" {
+		return Claims{}, fmt.Errorf("%w: missing sub", ErrInvalidToken)
+	}
+	return claims, nil
+}
+
+func (v *JWTVerifier) validateRegisteredClaims(raw map[string]any) error {
+	if stringClaim(raw, "iss") != v.cfg.Issuer {
+		return fmt.Errorf("%w: issuer mismatch", ErrInvalidToken)
+	}
+	if audience := strings.TrimSpace(v.cfg.Audience); audience != "" {
+		found := false
+		for _, candidate := range listClaim(raw, "aud") {
+			if candidate == audience {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: audience mismatch", ErrInvalidToken)
+		}
+	}
+
+	now := time.Now()
+	exp, ok := numericClaim(raw, "exp")
+	if !ok {
+		return fmt.Errorf("%w: missing exp", ErrInvalidToken)
+	}
+	if now.After(time.Unix(exp, 0).Add(clockLeeway)) {
+		return fmt.Errorf("%w: expired", ErrInvalidToken)
+	}
+	if nbf, ok := numericClaim(raw, "nbf"); ok && now.Add(clockLeeway).Before(time.Unix(nbf, 0)) {
+		return fmt.Errorf("%w: not yet valid", ErrInvalidToken)
+	}
+	return nil
+}
+
+func (v *JWTVerifier) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	v.mu.Lock()
+	key, found := v.lookupLocked(kid)
+	stale := time.Since(v.loadedAt) > v.cfg.CacheTTL
+	shouldRefresh := (!found || stale) && time.Since(v.lastRefresh) > minRefreshBackoff
+	if shouldRefresh {
+		v.lastRefresh = time.Now()
+	}
+	v.mu.Unlock()
+
+	// Unknown kids usually mean the issuer rotated keys; refetch, but not more
+	// than once per backoff window so forged kids or an unreachable JWKS URL
+	// cannot turn every request into a fetch. Stale keys keep serving meanwhile.
+	if shouldRefresh {
+		if err := v.refresh(ctx); err != nil && !found {
+			return nil, err
+		}
+		v.mu.Lock()
+		key, found = v.lookupLocked(kid)
+		v.mu.Unlock()
+	}
+	if !found {
+		return nil, ErrUnknownKey
+	}
+	return key, nil
+}
+
+func (v *JWTVerifier) lookupLocked(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, true
+		}
+	}
+	key, ok := v.keys[kid]
+	return key, ok
+}
+
+func (v *JWTVerifier) refresh(ctx context.Context) error {
+	v.mu.Lock()
+	v.lastRefresh = time.Now()
+	v.mu.Unlock()
+
+	raw, err := v.loadJWKS(ctx)
+	if err != nil {
+		return err
+	}
+	keys, err := ParseJWKS(raw)
+	if err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	v.keys = keys
+	v.loadedAt = time.Now()
+	v.mu.Unlock()
+	return nil
+}
+
+func (v *JWTVerifier) loadJWKS(ctx context.Context) ([]byte, error) {
+	if v.cfg.JWKSFile != "" {
+		raw, err := os.ReadFile(v.cfg.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("read jwks file: %w", err)
+		}
+		return raw, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.cfg.JWKSURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := v.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch jwks: status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+func ParseJWKS(raw []byte) (map[string]crypto.PublicKey, error) {
+	document := struct {
+		Keys []jsonWebKey `json:"keys"`
+	}{}
+	if err := json.Unmarshal(raw, &document); err != nil {
+		return nil, fmt.Errorf("parse jwks: %w", err)
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, jwk := range document.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("parse jwk %q: %w", jwk.Kid, err)
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("jwks contains no signing keys")
+	}
+	return keys, nil
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		if !key.Curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("point is not on curve")
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signingInput string, signature []byte) error {
+	digest := sha256.Sum256([]byte(signingInput))
+	switch alg {
+	case "RS256":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: key type does not match alg", ErrInvalidToken)
+		}
+		if err := rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest[:], signature); err != nil {
+			return fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+		}
+		return nil
+	case "ES256":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(signature) != 64 {
+			return fmt.Errorf("%w: key type does not match alg", ErrInvalidToken)
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(ecKey, digest[:], r, s) {
+			return fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unsupported alg %q", ErrInvalidToken, alg)
+	}
+}
+
+func decodeSegment(segment string, target any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(raw)))
+	decoder.UseNumber()
+	return decoder.Decode(target)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
+
+func stringClaim(raw map[string]any, name string) string {
+	value, _ := raw[name].(string)
+	return strings.TrimSpace(value)
+}
+
+// boolClaim accepts true or "true", since some identity providers encode
+// email_verified as a string.
+func boolClaim(raw map[string]any, name string) bool {
+	switch typed := raw[name].(type) {
+	case bool:
+		return typed
+	case string:
+		return strings.EqualFold(strings.TrimSpace(typed), "true")
+	}
+	return false
+}
+
+func numericClaim(raw map[string]any, name string) (int64, bool) {
+	value, ok := raw[name].(json.Number)
+	if !ok {
+		return 0, false
+	}
+	parsed, err := value.Float64()
+	if err != nil {
+		return 0, false
+	}
+	return int64(parsed), true
+}
+
+// listClaim accepts either a JSON array of strings or a space/comma separated
+// string, since identity providers disagree on how to encode multi-valued claims.
+func listClaim(raw map[string]any, name string) []string {
+	values := []string{}
+	switch typed := raw[name].(type) {
+	case string:
+		for _, item := range strings.FieldsFunc(typed, func(r rune) bool { return r == ' ' || r == ',' }) {
+			values = append(values, strings.TrimSpace(item))
+		}
+	case []any:
+		for _, item := range typed {
+			if text, ok := item.(string); ok && strings.TrimSpace(text) != "" {
+				values = append(values, strings.TrimSpace(text))
+			}
+		}
+	}
+	return values
+}
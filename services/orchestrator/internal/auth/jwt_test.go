@@ -0,0 +1,207 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func signTestToken(t *testing.T, alg, kid string, key crypto.Signer, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var signature []byte
+	switch typed := key.(type) {
+	case *rsa.PrivateKey:
+		signed, err := rsa.SignPKCS1v15(rand.Reader, typed, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		signature = signed
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, typed, digest[:])
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func writeTestJWKS(t *testing.T, rsaKey *rsa.PrivateKey, ecKey *ecdsa.PrivateKey) string {
+	t.Helper()
+	encode := func(value *big.Int) string { return base64.RawURLEncoding.EncodeToString(value.Bytes()) }
+	document := map[string]any{
+		"keys": []map[string]string{
+			{"kid": "rsa-1", "kty": "RSA", "use": "sig", "n": encode(rsaKey.N), "e": encode(big.NewInt(int64(rsaKey.E)))},
+			{"kid": "ec-1", "kty": "EC", "crv": "P-256", "x": encode(ecKey.X), "y": encode(ecKey.Y)},
+		},
+	}
+	raw, _ := json.Marshal(document)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		t.Fatalf("write jwks: %v", err)
+	}
+	return path
+}
+
+func newTestVerifier(t *testing.T) (*JWTVerifier, *rsa.PrivateKey, *ecdsa.PrivateKey) {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ec key: %v", err)
+	}
+
+	verifier, err := NewJWTVerifier(context.Background(), JWTConfig{
+		Issuer:   "https://idp.example.test",
+		Audience: "retrospec-dashboard",
+		JWKSFile: writeTestJWKS(t, rsaKey, ecKey),
+	})
+	if err != nil {
+		t.Fatalf("verifier: %v", err)
+	}
+	return verifier, rsaKey, ecKey
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"iss":                "https://idp.example.test",
+		"aud":                []string{"retrospec-dashboard"},
+		"sub":                "user-1",
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"retrospec_projects": []string{"proj_a"},
+	}
+}
+
+func TestJWTVerifierAcceptsRS256AndES256(t *testing.T) {
+	verifier, rsaKey, ecKey := newTestVerifier(t)
+
+	for _, token := range []string{
+		signTestToken(t, "RS256", "rsa-1", rsaKey, validClaims()),
+		signTestToken(t, "ES256", "ec-1", ecKey, validClaims()),
+	} {
+		claims, err := verifier.Verify(context.Background(), token)
+		if err != nil {
+			t.Fatalf("expected token to verify: %v", err)
+		}
+		if claims.Subject != "user-1" || !claims.AllowsProject("proj_a") || claims.AllowsProject("proj_b") {
+			t.Fatalf("unexpected claims: %+v", claims)
+		}
+	}
+}
+
+func TestJWTVerifierRejectsInvalidTokens(t *testing.T) {
+	verifier, rsaKey, _ := newTestVerifier(t)
+
+	expired := validClaims()
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+	wrongIssuer := validClaims()
+	wrongIssuer["iss"] = "https://evil.example.test"
+	wrongAudience := validClaims()
+	wrongAudience["aud"] = "someone-else"
+
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	tampered := signTestToken(t, "RS256", "rsa-1", otherKey, validClaims())
+
+	for name, token := range map[string]string{
+		"expired":        signTestToken(t, "RS256", "rsa-1", rsaKey, expired),
+		"wrong issuer":   signTestToken(t, "RS256", "rsa-1", rsaKey, wrongIssuer),
+		"wrong audience": signTestToken(t, "RS256", "rsa-1", rsaKey, wrongAudience),
+		"bad signature":  tampered,
+	} {
+		if _, err := verifier.Verify(context.Background(), token); !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("%s: expected ErrInvalidToken, got %v", name, err)
+		}
+	}
+
+	if _, err := verifier.Verify(context.Background(), signTestToken(t, "RS256", "missing", rsaKey, validClaims())); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected ErrUnknownKey, got %v", err)
+	}
+}
+
+func TestJWTVerifierServesStaleKeysWithoutRefetchingInsideBackoff(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	document, err := os.ReadFile(writeTestJWKS(t, rsaKey, ecKey))
+	if err != nil {
+		t.Fatalf("read jwks: %v", err)
+	}
+
+	var fetches atomic.Int32
+	var down atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fetches.Add(1)
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write(document)
+	}))
+	defer server.Close()
+
+	verifier, err := NewJWTVerifier(context.Background(), JWTConfig{
+		Issuer:   "https://idp.example.test",
+		Audience: "retrospec-dashboard",
+		JWKSURL:  server.URL,
+		CacheTTL: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("verifier: %v", err)
+	}
+	down.Store(true)
+	time.Sleep(5 * time.Millisecond)
+
+	token := signTestToken(t, "RS256", "rsa-1", rsaKey, validClaims())
+	for range 3 {
+		if _, err := verifier.Verify(context.Background(), token); err != nil {
+			t.Fatalf("expected stale keys to keep verifying: %v", err)
+		}
+	}
+	if got := fetches.Load(); got != 1 {
+		t.Fatalf("expected no refetch inside the backoff window, got %d fetches", got)
+	}
+
+	verifier.mu.Lock()
+	verifier.lastRefresh = time.Now().Add(-2 * minRefreshBackoff)
+	verifier.mu.Unlock()
+	for range 3 {
+		if _, err := verifier.Verify(context.Background(), token); err != nil {
+			t.Fatalf("expected stale keys to survive a failed refresh: %v", err)
+		}
+	}
+	if got := fetches.Load(); got != 2 {
+		t.Fatalf("expected one refetch once the backoff passed, got %d fetches", got)
+	}
+}
+
+func TestBoolClaimAcceptsBooleanAndStringEncodings(t *testing.T) {
+	raw := map[string]any{"bool": true, "string": "TRUE", "false": "false", "number": json.Number("1")}
+	if !boolClaim(raw, "bool") || !boolClaim(raw, "string") {
+		t.Fatal("expected true and \"true\" to be accepted")
+	}
+	if boolClaim(raw, "false") || boolClaim(raw, "number") || boolClaim(raw, "missing") {
+		t.Fatal("expected other encodings to be rejected")
+	}
+}
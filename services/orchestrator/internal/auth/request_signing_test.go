@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func signedTestRequest(keys SigningKeys, keyID string, at time.Time, body []byte) SignedRequest {
+	req := SignedRequest{
+		Method:        "POST",
+		RequestURI:    "/v1/internal/replay-results",
+		KeyID:         keyID,
+		Timestamp:     strconv.FormatInt(at.Unix(), 10),
+		Nonce:         "0123456789abcdef0123",
+		ContentSHA256: ContentSHA256(body),
+	}
+	req.Signature = Sign(keys[keyID], req)
+	return req
+}
+
+func TestVerifySignedRequestAcceptsEveryConfiguredKey(t *testing.T) {
+	keys, err := ParseSigningKeys("old:first-secret, new:second-secret")
+	if err != nil {
+		t.Fatalf("parse keys: %v", err)
+	}
+	body := []byte(`{"sessionId":"s1"}`)
+	now := time.Now()
+
+	for _, keyID := range []string{"old", "new"} {
+		if err := VerifySignedRequest(keys, signedTestRequest(keys, keyID, now, body), body, now, 5*time.Minute); err != nil {
+			t.Fatalf("key %s: expected signature to verify, got %v", keyID, err)
+		}
+	}
+}
+
+func TestVerifySignedRequestRejectsTamperingAndSkew(t *testing.T) {
+	keys := SigningKeys{"k1": []byte("secret")}
+	body := []byte(`{"sessionId":"s1"}`)
+	now := time.Now()
+
+	if err := VerifySignedRequest(keys, signedTestRequest(keys, "k1", now, body), []byte(`{"sessionId":"s2"}`), now, time.Minute); !errors.Is(err, ErrSignatureInvalid) {
+		t.Fatalf("expected tampered body to fail, got %v", err)
+	}
+
+	stale := signedTestRequest(keys, "k1", now.Add(-10*time.Minute), body)
+	if err := VerifySignedRequest(keys, stale, body, now, time.Minute); !errors.Is(err, ErrSignatureExpired) {
+		t.Fatalf("expected stale timestamp to fail, got %v", err)
+	}
+
+	unknown := signedTestRequest(keys, "k1", now, body)
+	unknown.KeyID = "retired"
+	if err := VerifySignedRequest(keys, unknown, body, now, time.Minute); !errors.Is(err, ErrUnknownSigningKey) {
+		t.Fatalf("expected unknown key to fail, got %v", err)
+	}
+
+	rerouted := signedTestRequest(keys, "k1", now, body)
+	rerouted.RequestURI = "/v1/internal/analysis-reports"
+	if err := VerifySignedRequest(keys, rerouted, body, now, time.Minute); !errors.Is(err, ErrSignatureInvalid) {
+		t.Fatalf("expected signature bound to path, got %v", err)
+	}
+}
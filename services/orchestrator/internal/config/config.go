@@ -3,23 +3,59 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 )
 
 type Config struct {
 	ListenAddr                 string
+	LogLevel                   string
+	LogFormat                  string
 	DatabaseURL                string
 	RedisAddr                  string
 	ReplayQueueName            string
 	AnalysisQueueName          string
 	CORSAllowedOrigins         []string
+	TrustedProxyCIDRs          string
+	ReadinessRequired          string
+	MetricsMaxProjectLabels    int
+	TracesExporter             string
+	OTLPTracesEndpoint         string
+	TracesServiceName          string
+	TracesSampleRatio          float64
+	WorkersEnabled             bool
 	InternalAPIKey             string
+	InternalSigningKeys        string
+	InternalSignatureSkewSec   int
 	IngestAPIKey               string
-	ArtifactTokenSecret        string
+	IngestAPIKeyFullAccess     bool
+	AdminAPIKey                string
+	APIKeyRotationGraceMinutes int
+	APIKeyUnusedDays           int
+	APIKeyAuditIntervalMinutes int
+	JWTIssuer                  string
+	JWTAudience                string
+	JWTJWKSFile                string
+	JWTJWKSURL                 string
+	JWTProjectsClaim           string
+	JWTScopes                  []string
+	ForbidAnonymousReads       bool
+	ArtifactTokenKeys          string
+	ArtifactTokenActiveKeyID   string
+	ArtifactTokenSingleUse     bool
 	ArtifactTokenTTLSeconds    int
-	RateLimitRequestsPerSec    float64
-	RateLimitBurst             int
+	ErasureReceiptKeys         string
+	ErasureReceiptActiveKeyID  string
+	RateLimitIngestPerSec      float64
+	RateLimitIngestBurst       int
+	RateLimitReadPerSec        float64
+	RateLimitReadBurst         int
+	RateLimitAdminPerSec       float64
+	RateLimitAdminBurst        int
+	RateLimitAddressPerSec     float64
+	RateLimitAddressBurst      int
 	AutoCleanupIntervalMinutes int
+	RetentionTagSyncMinutes    int
 	SessionRetentionDays       int
 	S3Region                   string
 	S3Endpoint                 string
@@ -30,75 +66,208 @@ type Config struct {
 	S3LifecycleExpirationDays  int
 	S3LifecyclePrefixes        []string
 	ClusterPromoteMinSessions  int
+	EncryptionEnabled          bool
+	EncryptionKeyringFile      string
+	EncryptionMasterKeyID      string
+	EncryptionMasterKey        string
+	EncryptionRewrapMinutes    int
+	EncryptionAllowPlaintext   bool
 }
 
-func Load() Config {
-	port := envOrDefault("ORCHESTRATOR_PORT", "8080")
-
-	return Config{
-		ListenAddr:                 ":" + port,
-		DatabaseURL:                databaseURL(),
-		RedisAddr:                  redisAddr(),
-		ReplayQueueName:            envOrDefault("REPLAY_QUEUE_NAME", "replay-jobs"),
-		AnalysisQueueName:          envOrDefault("ANALYSIS_QUEUE_NAME", "analysis-jobs"),
-		CORSAllowedOrigins:         parseCSV(envOrDefault("CORS_ALLOWED_ORIGINS", "*")),
-		InternalAPIKey:             os.Getenv("INTERNAL_API_KEY"),
-		IngestAPIKey:               os.Getenv("INGEST_API_KEY"),
-		ArtifactTokenSecret:        artifactTokenSecret(),
-		ArtifactTokenTTLSeconds:    envOrDefaultInt("ARTIFACT_TOKEN_TTL_SECONDS", 300),
-		RateLimitRequestsPerSec:    envOrDefaultFloat("RATE_LIMIT_REQUESTS_PER_SEC", 25),
-		RateLimitBurst:             envOrDefaultInt("RATE_LIMIT_BURST", 50),
-		AutoCleanupIntervalMinutes: envOrDefaultInt("AUTO_CLEANUP_INTERVAL_MINUTES", 0),
-		SessionRetentionDays:       envOrDefaultInt("SESSION_RETENTION_DAYS", 7),
-		S3Region:                   envOrDefault("S3_REGION", "us-east-1"),
-		S3Endpoint:                 os.Getenv("S3_ENDPOINT"),
-		S3AccessKey:                envOrDefault("S3_ACCESS_KEY", ""),
-		S3SecretKey:                envOrDefault("S3_SECRET_KEY", ""),
-		S3Bucket:                   envOrDefault("S3_BUCKET", ""),
-		S3LifecycleEnabled:         envOrDefaultBool("S3_LIFECYCLE_ENABLED", false),
-		S3LifecycleExpirationDays:  envOrDefaultInt("S3_LIFECYCLE_EXPIRATION_DAYS", 7),
-		S3LifecyclePrefixes:        parseLifecyclePrefixes(envOrDefault("S3_LIFECYCLE_PREFIXES", "session-events/,replay-artifacts/")),
-		ClusterPromoteMinSessions:  envOrDefaultInt("CLUSTER_PROMOTE_MIN_SESSIONS", 2),
+// Load resolves every setting from the environment, then the optional
+// CONFIG_FILE, then its default, and validates the result. All problems are
+// returned together as Errors.
+func Load() (Config, error) {
+	cfg, _, err := Inspect(os.Getenv(FileEnv))
+	return cfg, err
+}
+
+// Inspect loads like Load from the given config file (none when path is
+// empty) and also returns where each setting came from, secrets redacted.
+func Inspect(path string) (Config, []Setting, error) {
+	return load(os.LookupEnv, path)
+}
+
+func load(lookupEnv func(string) (string, bool), path string) (Config, []Setting, error) {
+	l := &loader{env: lookupEnv, settings: map[string]Setting{}}
+	if path != "" {
+		file, err := readFile(path)
+		if err != nil {
+			return Config{}, nil, Errors{err}
+		}
+		l.file = file
+	}
+
+	port := l.int("ORCHESTRATOR_PORT", 8080)
+	rateLimitPerSec := l.float("RATE_LIMIT_REQUESTS_PER_SEC", 25)
+	rateLimitBurst := l.int("RATE_LIMIT_BURST", 50)
+	internalAPIKey := l.string("INTERNAL_API_KEY", "")
+
+	cfg := Config{
+		ListenAddr:                 ":" + strconv.Itoa(port),
+		LogLevel:                   l.string("LOG_LEVEL", "info"),
+		LogFormat:                  l.string("LOG_FORMAT", "json"),
+		DatabaseURL:                l.databaseURL(),
+		RedisAddr:                  l.redisAddr(),
+		ReplayQueueName:            l.string("REPLAY_QUEUE_NAME", "replay-jobs"),
+		AnalysisQueueName:          l.string("ANALYSIS_QUEUE_NAME", "analysis-jobs"),
+		CORSAllowedOrigins:         parseCSV(l.string("CORS_ALLOWED_ORIGINS", "*")),
+		TrustedProxyCIDRs:          l.string("TRUSTED_PROXY_CIDRS", ""),
+		ReadinessRequired:          l.string("READINESS_REQUIRED_DEPENDENCIES", "postgres,redis,artifacts"),
+		MetricsMaxProjectLabels:    l.int("METRICS_MAX_PROJECT_LABELS", 50),
+		TracesExporter:             l.tracesExporter(),
+		OTLPTracesEndpoint:         l.otlpTracesEndpoint(),
+		TracesServiceName:          l.string("OTEL_SERVICE_NAME", "retrospec-orchestrator"),
+		TracesSampleRatio:          l.float("OTEL_TRACES_SAMPLER_RATIO", 1),
+		WorkersEnabled:             l.bool("WORKERS_ENABLED", true),
+		InternalAPIKey:             internalAPIKey,
+		InternalSigningKeys:        l.internalSigningKeys(internalAPIKey),
+		InternalSignatureSkewSec:   l.int("INTERNAL_SIGNATURE_MAX_SKEW_SECONDS", 300),
+		IngestAPIKey:               l.string("INGEST_API_KEY", ""),
+		IngestAPIKeyFullAccess:     l.bool("INGEST_API_KEY_FULL_ACCESS", false),
+		AdminAPIKey:                l.string("ADMIN_API_KEY", ""),
+		APIKeyRotationGraceMinutes: l.int("API_KEY_ROTATION_GRACE_MINUTES", 1440),
+		APIKeyUnusedDays:           l.int("API_KEY_UNUSED_DAYS", 90),
+		APIKeyAuditIntervalMinutes: l.int("API_KEY_AUDIT_INTERVAL_MINUTES", 60),
+		JWTIssuer:                  l.string("JWT_ISSUER", ""),
+		JWTAudience:                l.string("JWT_AUDIENCE", ""),
+		JWTJWKSFile:                l.string("JWT_JWKS_FILE", ""),
+		JWTJWKSURL:                 l.string("JWT_JWKS_URL", ""),
+		JWTProjectsClaim:           l.string("JWT_PROJECTS_CLAIM", "retrospec_projects"),
+		JWTScopes:                  parseScopes(l.string("JWT_SCOPES", "read")),
+		ForbidAnonymousReads:       l.bool("FORBID_ANONYMOUS_READS", false),
+		ArtifactTokenKeys:          l.artifactTokenKeys(internalAPIKey),
+		ArtifactTokenActiveKeyID:   l.string("ARTIFACT_TOKEN_ACTIVE_KEY_ID", ""),
+		ArtifactTokenSingleUse:     l.bool("ARTIFACT_TOKEN_SINGLE_USE", false),
+		ArtifactTokenTTLSeconds:    l.int("ARTIFACT_TOKEN_TTL_SECONDS", 300),
+		ErasureReceiptKeys:         l.string("ERASURE_RECEIPT_KEYS", ""),
+		ErasureReceiptActiveKeyID:  l.string("ERASURE_RECEIPT_ACTIVE_KEY_ID", ""),
+		RateLimitIngestPerSec:      l.float("RATE_LIMIT_INGEST_REQUESTS_PER_SEC", rateLimitPerSec),
+		RateLimitIngestBurst:       l.int("RATE_LIMIT_INGEST_BURST", rateLimitBurst),
+		RateLimitReadPerSec:        l.float("RATE_LIMIT_READ_REQUESTS_PER_SEC", rateLimitPerSec),
+		RateLimitReadBurst:         l.int("RATE_LIMIT_READ_BURST", rateLimitBurst),
+		RateLimitAdminPerSec:       l.float("RATE_LIMIT_ADMIN_REQUESTS_PER_SEC", rateLimitPerSec),
+		RateLimitAdminBurst:        l.int("RATE_LIMIT_ADMIN_BURST", rateLimitBurst),
+		RateLimitAddressPerSec:     l.float("RATE_LIMIT_ADDRESS_REQUESTS_PER_SEC", 4*rateLimitPerSec),
+		RateLimitAddressBurst:      l.int("RATE_LIMIT_ADDRESS_BURST", 4*rateLimitBurst),
+		AutoCleanupIntervalMinutes: l.int("AUTO_CLEANUP_INTERVAL_MINUTES", 0),
+		RetentionTagSyncMinutes:    l.int("RETENTION_TAG_SYNC_INTERVAL_MINUTES", 15),
+		SessionRetentionDays:       l.int("SESSION_RETENTION_DAYS", 7),
+		S3Region:                   l.string("S3_REGION", "us-east-1"),
+		S3Endpoint:                 l.string("S3_ENDPOINT", ""),
+		S3AccessKey:                l.string("S3_ACCESS_KEY", ""),
+		S3SecretKey:                l.string("S3_SECRET_KEY", ""),
+		S3Bucket:                   l.string("S3_BUCKET", ""),
+		S3LifecycleEnabled:         l.bool("S3_LIFECYCLE_ENABLED", false),
+		S3LifecycleExpirationDays:  l.int("S3_LIFECYCLE_EXPIRATION_DAYS", 7),
+		S3LifecyclePrefixes:        parseLifecyclePrefixes(l.string("S3_LIFECYCLE_PREFIXES", "session-events/,replay-artifacts/")),
+		ClusterPromoteMinSessions:  l.int("CLUSTER_PROMOTE_MIN_SESSIONS", 2),
+		EncryptionEnabled:          l.bool("ARTIFACT_ENCRYPTION_ENABLED", false),
+		EncryptionKeyringFile:      l.string("ARTIFACT_ENCRYPTION_KEYRING_FILE", ""),
+		EncryptionMasterKeyID:      l.string("ARTIFACT_ENCRYPTION_MASTER_KEY_ID", ""),
+		EncryptionMasterKey:        l.string("ARTIFACT_ENCRYPTION_MASTER_KEY", ""),
+		EncryptionRewrapMinutes:    l.int("ARTIFACT_ENCRYPTION_REWRAP_INTERVAL_MINUTES", 60),
+		EncryptionAllowPlaintext:   l.bool("ARTIFACT_ENCRYPTION_ALLOW_PLAINTEXT_READS", false),
+	}
+	if port < 1 || port > 65535 {
+		l.fail("ORCHESTRATOR_PORT", "must be between 1 and 65535")
 	}
+	l.unknownFileKeys()
+	cfg.validate(l)
+
+	if len(l.errs) > 0 {
+		return cfg, l.effective(), l.errs
+	}
+	return cfg, l.effective(), nil
 }
 
-func artifactTokenSecret() string {
-	if value := strings.TrimSpace(os.Getenv("ARTIFACT_TOKEN_SECRET")); value != "" {
+// internalSigningKeys returns INTERNAL_SIGNING_KEYS (keyId:secret,...), or
+// INTERNAL_API_KEY under key ID "default" for single-key deployments.
+func (l *loader) internalSigningKeys(internalAPIKey string) string {
+	if value := strings.TrimSpace(l.string("INTERNAL_SIGNING_KEYS", "")); value != "" {
 		return value
 	}
-	if value := strings.TrimSpace(os.Getenv("INTERNAL_API_KEY")); value != "" {
-		return value
+	if value := strings.TrimSpace(internalAPIKey); value != "" {
+		return "default:" + value
 	}
 	return ""
 }
 
-func databaseURL() string {
-	if value := os.Getenv("DATABASE_URL"); value != "" {
-		return value
+// artifactTokenKeys returns ARTIFACT_TOKEN_KEYS (keyId:secret,...), or the
+// single ARTIFACT_TOKEN_SECRET / INTERNAL_API_KEY under key ID "default".
+func (l *loader) artifactTokenKeys(internalAPIKey string) string {
+	keys := strings.TrimSpace(l.string("ARTIFACT_TOKEN_KEYS", ""))
+	secret := strings.TrimSpace(l.string("ARTIFACT_TOKEN_SECRET", ""))
+	if keys != "" {
+		return keys
+	}
+	if secret != "" {
+		return "default:" + secret
+	}
+	if value := strings.TrimSpace(internalAPIKey); value != "" {
+		return "default:" + value
 	}
+	return ""
+}
 
-	host := envOrDefault("POSTGRES_HOST", "localhost")
-	port := envOrDefault("POSTGRES_PORT", "5432")
-	user := envOrDefault("POSTGRES_USER", "retrospec")
-	password := envOrDefault("POSTGRES_PASSWORD", "retrospec")
-	database := envOrDefault("POSTGRES_DB", "retrospec")
+// tracesExporter honours OTEL_TRACES_EXPORTER and otherwise exports over OTLP
+// only when an endpoint is configured.
+func (l *loader) tracesExporter() string {
+	if value := strings.TrimSpace(l.string("OTEL_TRACES_EXPORTER", "")); value != "" {
+		return strings.ToLower(value)
+	}
+	if l.otlpTracesEndpoint() != "" {
+		return "otlp"
+	}
+	return "none"
+}
 
-	return fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable", user, password, host, port, database)
+// otlpTracesEndpoint returns the full traces URL. The generic endpoint is a
+// base URL, so the standard /v1/traces path is appended to it.
+func (l *loader) otlpTracesEndpoint() string {
+	tracesEndpoint := strings.TrimSpace(l.string("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", ""))
+	endpoint := strings.TrimSpace(l.string("OTEL_EXPORTER_OTLP_ENDPOINT", ""))
+	if tracesEndpoint != "" {
+		return tracesEndpoint
+	}
+	if endpoint != "" {
+		return strings.TrimRight(endpoint, "/") + "/v1/traces"
+	}
+	return ""
 }
 
-func envOrDefault(key, fallback string) string {
-	if value := os.Getenv(key); value != "" {
+func (l *loader) databaseURL() string {
+	value := l.string("DATABASE_URL", "")
+	host := l.string("POSTGRES_HOST", "localhost")
+	port := l.string("POSTGRES_PORT", "5432")
+	user := l.string("POSTGRES_USER", "retrospec")
+	password := l.string("POSTGRES_PASSWORD", "retrospec")
+	database := l.string("POSTGRES_DB", "retrospec")
+	if value != "" {
 		return value
 	}
-	return fallback
+
+	return fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable", user, password, host, port, database)
 }
 
-func redisAddr() string {
-	host := envOrDefault("REDIS_HOST", "localhost")
-	port := envOrDefault("REDIS_PORT", "6379")
+func (l *loader) redisAddr() string {
+	host := l.string("REDIS_HOST", "localhost")
+	port := l.string("REDIS_PORT", "6379")
 	return fmt.Sprintf("%s:%s", host, port)
 }
 
+// parseScopes splits a comma-separated scope list. Unlike parseCSV it never
+// falls back to "*", so a blank list is left for validate to reject.
+func parseScopes(value string) []string {
+	scopes := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if trimmed := strings.ToLower(strings.TrimSpace(item)); trimmed != "" {
+			scopes = append(scopes, trimmed)
+		}
+	}
+	return scopes
+}
+
 func parseCSV(value string) []string {
 	values := strings.Split(value, ",")
 	result := make([]string, 0, len(values))
@@ -131,45 +300,3 @@ func parseLifecyclePrefixes(value string) []string {
 	}
 	return prefixes
 }
-
-func envOrDefaultInt(key string, fallback int) int {
-	value := os.Getenv(key)
-	if value == "" {
-		return fallback
-	}
-
-	var parsed int
-	if _, err := fmt.Sscanf(value, "%d", &parsed); err != nil {
-		return fallback
-	}
-	return parsed
-}
-
-func envOrDefaultFloat(key string, fallback float64) float64 {
-	value := os.Getenv(key)
-	if value == "" {
-		return fallback
-	}
-
-	var parsed float64
-	if _, err := fmt.Sscanf(value, "%f", &parsed); err != nil {
-		return fallback
-	}
-	return parsed
-}
-
-func envOrDefaultBool(key string, fallback bool) bool {
-	value := strings.TrimSpace(strings.ToLower(os.Getenv(key)))
-	if value == "" {
-		return fallback
-	}
-
-	switch value {
-	case "1", "true", "yes", "y", "on":
-		return true
-	case "0", "false", "no", "n", "off":
-		return false
-	default:
-		return fallback
-	}
-}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func fakeEnv(values map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := values[key]
+		return value, ok
+	}
+}
+
+func TestLoadCollectsEveryInvalidValue(t *testing.T) {
+	_, _, err := load(fakeEnv(map[string]string{
+		"RATE_LIMIT_BURST":          "abc",
+		"ORCHESTRATOR_PORT":         "99999",
+		"FORBID_ANONYMOUS_READS":    "maybe",
+		"OTEL_TRACES_SAMPLER_RATIO": "2",
+	}), "")
+
+	var problems Errors
+	if !errors.As(err, &problems) {
+		t.Fatalf("expected config.Errors, got %v", err)
+	}
+	for _, key := range []string{"RATE_LIMIT_BURST", "ORCHESTRATOR_PORT", "FORBID_ANONYMOUS_READS", "OTEL_TRACES_SAMPLER_RATIO", "INTERNAL_API_KEY"} {
+		if !strings.Contains(err.Error(), key+":") {
+			t.Fatalf("expected a problem for %s, got %v", key, err)
+		}
+	}
+
+	_, _, err = load(fakeEnv(map[string]string{"WORKERS_ENABLED": "false"}), "")
+	if err != nil {
+		t.Fatalf("expected defaults without workers to be valid, got %v", err)
+	}
+}
+
+func TestLoadReadsFileWithEnvOverridesAndRedactsSecrets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orchestrator.yaml")
+	document := "internal_api_key: file-secret\nrate_limit_burst: 80\nlog_level: debug\ncors_allowed_origins:\n  - https://a.example\n  - https://b.example\n"
+	if err := os.WriteFile(path, []byte(document), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, settings, err := load(fakeEnv(map[string]string{
+		"LOG_LEVEL":    "warn",
+		"DATABASE_URL": "postgres://retrospec:hunter2@db:5432/retrospec",
+	}), path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.LogLevel != "warn" || cfg.RateLimitIngestBurst != 80 || cfg.InternalSigningKeys != "default:file-secret" {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+	if len(cfg.CORSAllowedOrigins) != 2 || cfg.CORSAllowedOrigins[1] != "https://b.example" {
+		t.Fatalf("expected list origins from file, got %v", cfg.CORSAllowedOrigins)
+	}
+
+	byKey := map[string]Setting{}
+	for _, setting := range settings {
+		byKey[setting.Key] = setting
+	}
+	if setting := byKey["INTERNAL_API_KEY"]; setting.Value != redacted || setting.Source != SourceFile {
+		t.Fatalf("expected redacted file secret, got %+v", setting)
+	}
+	if setting := byKey["LOG_LEVEL"]; setting.Value != "warn" || setting.Source != SourceEnv {
+		t.Fatalf("expected env override, got %+v", setting)
+	}
+	if setting := byKey["DATABASE_URL"]; strings.Contains(setting.Value, "hunter2") {
+		t.Fatalf("expected database password redacted, got %+v", setting)
+	}
+}
+
+func TestLoadRejectsUnknownFileKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orchestrator.toml")
+	if err := os.WriteFile(path, []byte("WORKERS_ENABLED = false\nRATE_LIMT_BURST = 10\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err := load(fakeEnv(nil), path)
+	if err == nil || !strings.Contains(err.Error(), "unknown setting RATE_LIMT_BURST") {
+		t.Fatalf("expected unknown key error, got %v", err)
+	}
+}
+
+func TestDiffMarksReloadableSettings(t *testing.T) {
+	previous := []Setting{{Key: "RATE_LIMIT_BURST", Value: "50"}, {Key: "REDIS_HOST", Value: "localhost"}, {Key: "LOG_LEVEL", Value: "info"}}
+	next := []Setting{{Key: "RATE_LIMIT_BURST", Value: "80"}, {Key: "REDIS_HOST", Value: "redis"}, {Key: "LOG_LEVEL", Value: "info"}}
+
+	changes := Diff(previous, next)
+	if len(changes) != 2 {
+		t.Fatalf("expected two changes, got %+v", changes)
+	}
+	if changes[0].Key != "RATE_LIMIT_BURST" || !changes[0].Reloadable || changes[0].New != "80" {
+		t.Fatalf("unexpected rate limit change %+v", changes[0])
+	}
+	if changes[1].Key != "REDIS_HOST" || changes[1].Reloadable {
+		t.Fatalf("expected redis host to need a restart, got %+v", changes[1])
+	}
+}
+
+func TestDiffReportsRotatedSecretsRedacted(t *testing.T) {
+	settingsFor := func(secret string) []Setting {
+		t.Helper()
+		_, settings, err := load(fakeEnv(map[string]string{"WORKERS_ENABLED": "false", "ERASURE_RECEIPT_KEYS": "r1:" + secret}), "")
+		if err != nil {
+			t.Fatalf("load: %v", err)
+		}
+		return settings
+	}
+
+	changes := Diff(settingsFor("old-secret"), settingsFor("new-secret"))
+	if len(changes) != 1 || changes[0].Key != "ERASURE_RECEIPT_KEYS" {
+		t.Fatalf("expected the rotated secret to be reported, got %+v", changes)
+	}
+	if changes[0].Old != redacted || changes[0].New != redacted {
+		t.Fatalf("expected rotated secret values to stay redacted, got %+v", changes[0])
+	}
+	if changes := Diff(settingsFor("same-secret"), settingsFor("same-secret")); len(changes) != 0 {
+		t.Fatalf("expected an unchanged secret not to be reported, got %+v", changes)
+	}
+}
+
+func TestLoadRejectsInvalidAddressRateAndJWTScopes(t *testing.T) {
+	base := map[string]string{"WORKERS_ENABLED": "false"}
+	check := func(overrides map[string]string, key, message string) {
+		t.Helper()
+		env := map[string]string{}
+		for name, value := range base {
+			env[name] = value
+		}
+		for name, value := range overrides {
+			env[name] = value
+		}
+		_, _, err := load(fakeEnv(env), "")
+		if err == nil || !strings.Contains(err.Error(), key+": "+message) {
+			t.Fatalf("expected %s: %s, got %v", key, message, err)
+		}
+	}
+
+	check(map[string]string{"RATE_LIMIT_ADDRESS_REQUESTS_PER_SEC": "0"}, "RATE_LIMIT_ADDRESS_REQUESTS_PER_SEC", "must be positive")
+	check(map[string]string{"RATE_LIMIT_ADDRESS_REQUESTS_PER_SEC": "-1"}, "RATE_LIMIT_ADDRESS_REQUESTS_PER_SEC", "must be positive")
+	check(map[string]string{"JWT_SCOPES": "read,amdin"}, "JWT_SCOPES", `unknown scope "amdin"`)
+	check(map[string]string{"JWT_SCOPES": "*"}, "JWT_SCOPES", `unknown scope "*"`)
+	check(map[string]string{"JWT_SCOPES": " , "}, "JWT_SCOPES", "must list at least one of")
+
+	cfg, _, err := load(fakeEnv(map[string]string{"WORKERS_ENABLED": "false", "JWT_SCOPES": "Read, triage"}), "")
+	if err != nil {
+		t.Fatalf("expected known scopes to be valid, got %v", err)
+	}
+	if strings.Join(cfg.JWTScopes, ",") != "read,triage" {
+		t.Fatalf("expected normalized scopes, got %v", cfg.JWTScopes)
+	}
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// readFile parses a flat YAML or TOML document whose keys are the environment
+// variable names (case-insensitive). Lists are joined with commas.
+func readFile(path string) (map[string]string, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config file: %w", err)
+	}
+
+	document := map[string]any{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(contents, &document)
+	case ".toml":
+		err = toml.Unmarshal(contents, &document)
+	default:
+		return nil, fmt.Errorf("config file: %s must end in .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config file: %w", err)
+	}
+
+	values := make(map[string]string, len(document))
+	for key, raw := range document {
+		value, err := fileValue(raw)
+		if err != nil {
+			return nil, fmt.Errorf("config file: %s %w", key, err)
+		}
+		values[strings.ToUpper(strings.TrimSpace(key))] = value
+	}
+	return values, nil
+}
+
+func fileValue(raw any) (string, error) {
+	switch value := raw.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	case bool, int, int64, uint64, float64:
+		return fmt.Sprint(value), nil
+	case []any:
+		items := make([]string, 0, len(value))
+		for _, item := range value {
+			text, err := fileValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, text)
+		}
+		return strings.Join(items, ","), nil
+	default:
+		return "", fmt.Errorf("must be a scalar or list, got %T", raw)
+	}
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FileEnv names the optional YAML or TOML config file. Environment variables
+// override values from the file.
+const FileEnv = "CONFIG_FILE"
+
+const (
+	SourceEnv     = "env"
+	SourceFile    = "file"
+	SourceDefault = "default"
+
+	redacted = "[redacted]"
+)
+
+var secretKeys = map[string]bool{
+	"INTERNAL_API_KEY":               true,
+	"INTERNAL_SIGNING_KEYS":          true,
+	"INGEST_API_KEY":                 true,
+	"ADMIN_API_KEY":                  true,
+	"ARTIFACT_TOKEN_KEYS":            true,
+	"ARTIFACT_TOKEN_SECRET":          true,
+	"ERASURE_RECEIPT_KEYS":           true,
+	"POSTGRES_PASSWORD":              true,
+	"S3_ACCESS_KEY":                  true,
+	"S3_SECRET_KEY":                  true,
+	"ARTIFACT_ENCRYPTION_MASTER_KEY": true,
+}
+
+// Setting is one resolved configuration key, safe to print.
+type Setting struct {
+	Key    string
+	Value  string
+	Source string
+	// digest is a SHA-256 of the raw value, so Diff notices a rotated secret
+	// whose redacted Value stays the same.
+	digest string
+}
+
+// Errors collects every problem found while loading a config.
+type Errors []error
+
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+type loader struct {
+	env      func(string) (string, bool)
+	file     map[string]string
+	settings map[string]Setting
+	errs     Errors
+}
+
+func (l *loader) fail(key, format string, args ...any) {
+	l.errs = append(l.errs, fmt.Errorf("%s: "+format, append([]any{key}, args...)...))
+}
+
+// lookup returns the value for key and records where it came from. Empty
+// values count as unset, as they always have for environment variables.
+func (l *loader) lookup(key, fallback string) (string, bool) {
+	value, source := fallback, SourceDefault
+	if envValue, ok := l.env(key); ok && envValue != "" {
+		value, source = envValue, SourceEnv
+	} else if fileValue := l.file[key]; fileValue != "" {
+		value, source = fileValue, SourceFile
+	}
+	l.settings[key] = Setting{Key: key, Value: redact(key, value), Source: source, digest: digestValue(value)}
+	return value, source != SourceDefault
+}
+
+func (l *loader) string(key, fallback string) string {
+	value, _ := l.lookup(key, fallback)
+	return value
+}
+
+func (l *loader) int(key string, fallback int) int {
+	value, ok := l.lookup(key, strconv.Itoa(fallback))
+	if !ok {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		l.fail(key, "invalid integer %q", value)
+		return fallback
+	}
+	return parsed
+}
+
+func (l *loader) float(key string, fallback float64) float64 {
+	value, ok := l.lookup(key, strconv.FormatFloat(fallback, 'f', -1, 64))
+	if !ok {
+		return fallback
+	}
+	parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		l.fail(key, "invalid number %q", value)
+		return fallback
+	}
+	return parsed
+}
+
+func (l *loader) bool(key string, fallback bool) bool {
+	value, ok := l.lookup(key, strconv.FormatBool(fallback))
+	if !ok {
+		return fallback
+	}
+	switch strings.TrimSpace(strings.ToLower(value)) {
+	case "1", "true", "yes", "y", "on":
+		return true
+	case "0", "false", "no", "n", "off":
+		return false
+	default:
+		l.fail(key, "invalid boolean %q", value)
+		return fallback
+	}
+}
+
+// unknownFileKeys flags file entries no setting reads, which are almost
+// always typos.
+func (l *loader) unknownFileKeys() {
+	for _, key := range sortedKeys(l.file) {
+		if _, ok := l.settings[key]; !ok {
+			l.errs = append(l.errs, fmt.Errorf("config file: unknown setting %s", key))
+		}
+	}
+}
+
+func (l *loader) effective() []Setting {
+	settings := make([]Setting, 0, len(l.settings))
+	for _, key := range sortedKeys(l.settings) {
+		settings = append(settings, l.settings[key])
+	}
+	return settings
+}
+
+func digestValue(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+func redact(key, value string) string {
+	if value == "" {
+		return ""
+	}
+	if secretKeys[key] {
+		return redacted
+	}
+	if key == "DATABASE_URL" {
+		if parsed, err := url.Parse(value); err == nil {
+			return parsed.Redacted()
+		}
+		return redacted
+	}
+	return value
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
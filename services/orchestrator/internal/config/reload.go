@@ -0,0 +1,68 @@
+package config
+
+import "strings"
+
+// reloadableKeys are applied by a reload; other changes need a restart.
+var reloadableKeys = map[string]bool{
+	"CORS_ALLOWED_ORIGINS":                        true,
+	"RATE_LIMIT_REQUESTS_PER_SEC":                 true,
+	"RATE_LIMIT_BURST":                            true,
+	"RATE_LIMIT_INGEST_REQUESTS_PER_SEC":          true,
+	"RATE_LIMIT_INGEST_BURST":                     true,
+	"RATE_LIMIT_READ_REQUESTS_PER_SEC":            true,
+	"RATE_LIMIT_READ_BURST":                       true,
+	"RATE_LIMIT_ADMIN_REQUESTS_PER_SEC":           true,
+	"RATE_LIMIT_ADMIN_BURST":                      true,
+	"RATE_LIMIT_ADDRESS_REQUESTS_PER_SEC":         true,
+	"RATE_LIMIT_ADDRESS_BURST":                    true,
+	"CLUSTER_PROMOTE_MIN_SESSIONS":                true,
+	"SESSION_RETENTION_DAYS":                      true,
+	"AUTO_CLEANUP_INTERVAL_MINUTES":               true,
+	"RETENTION_TAG_SYNC_INTERVAL_MINUTES":         true,
+	"ARTIFACT_ENCRYPTION_REWRAP_INTERVAL_MINUTES": true,
+	"API_KEY_AUDIT_INTERVAL_MINUTES":              true,
+	"API_KEY_UNUSED_DAYS":                         true,
+}
+
+// Change is one setting whose value differs between two loads. Values are
+// redacted like Setting values.
+type Change struct {
+	Key        string `json:"key"`
+	Old        string `json:"old"`
+	New        string `json:"new"`
+	Reloadable bool   `json:"reloadable"`
+}
+
+func Reloadable(key string) bool {
+	return reloadableKeys[strings.ToUpper(key)]
+}
+
+// Diff lists settings whose value changed from previous to next, in key order.
+// Raw values are compared by digest, so rotated secrets are reported too.
+func Diff(previous, next []Setting) []Change {
+	before := make(map[string]Setting, len(previous))
+	for _, setting := range previous {
+		before[setting.Key] = setting
+	}
+	after := make(map[string]Setting, len(next))
+	for _, setting := range next {
+		after[setting.Key] = setting
+	}
+
+	keys := map[string]bool{}
+	for key := range before {
+		keys[key] = true
+	}
+	for key := range after {
+		keys[key] = true
+	}
+
+	changes := []Change{}
+	for _, key := range sortedKeys(keys) {
+		if before[key].Value == after[key].Value && before[key].digest == after[key].digest {
+			continue
+		}
+		changes = append(changes, Change{Key: key, Old: before[key].Value, New: after[key].Value, Reloadable: reloadableKeys[key]})
+	}
+	return changes
+}
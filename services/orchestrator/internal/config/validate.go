@@ -0,0 +1,92 @@
+package config
+
+import (
+	"strings"
+
+	"retrospec/services/orchestrator/internal/store"
+)
+
+func (c Config) validate(l *loader) {
+	switch strings.ToLower(strings.TrimSpace(c.LogLevel)) {
+	case "debug", "info", "warn", "error":
+	default:
+		l.fail("LOG_LEVEL", "must be debug, info, warn or error")
+	}
+	switch strings.ToLower(strings.TrimSpace(c.LogFormat)) {
+	case "json", "text":
+	default:
+		l.fail("LOG_FORMAT", "must be json or text")
+	}
+	switch c.TracesExporter {
+	case "none", "otlp":
+	default:
+		l.fail("OTEL_TRACES_EXPORTER", "must be none or otlp")
+	}
+	if c.TracesSampleRatio < 0 || c.TracesSampleRatio > 1 {
+		l.fail("OTEL_TRACES_SAMPLER_RATIO", "must be between 0 and 1")
+	}
+
+	if c.WorkersEnabled && c.InternalSigningKeys == "" {
+		l.fail("INTERNAL_API_KEY", "INTERNAL_API_KEY or INTERNAL_SIGNING_KEYS is required for worker callbacks (set WORKERS_ENABLED=false to run without workers)")
+	}
+	if c.JWTIssuer != "" && c.JWTJWKSFile == "" && c.JWTJWKSURL == "" {
+		l.fail("JWT_ISSUER", "requires JWT_JWKS_FILE or JWT_JWKS_URL")
+	}
+	if len(c.JWTScopes) == 0 {
+		l.fail("JWT_SCOPES", "must list at least one of %s", strings.Join(store.AllAPIKeyScopes, ", "))
+	}
+	for _, scope := range c.JWTScopes {
+		if !store.HasAPIKeyScope(store.AllAPIKeyScopes, scope) {
+			l.fail("JWT_SCOPES", "unknown scope %q (must be one of %s)", scope, strings.Join(store.AllAPIKeyScopes, ", "))
+		}
+	}
+	if c.EncryptionEnabled && c.EncryptionKeyringFile == "" && c.EncryptionMasterKey == "" {
+		l.fail("ARTIFACT_ENCRYPTION_ENABLED", "requires ARTIFACT_ENCRYPTION_KEYRING_FILE or ARTIFACT_ENCRYPTION_MASTER_KEY")
+	}
+
+	positive := map[string]int{
+		"INTERNAL_SIGNATURE_MAX_SKEW_SECONDS": c.InternalSignatureSkewSec,
+		"ARTIFACT_TOKEN_TTL_SECONDS":          c.ArtifactTokenTTLSeconds,
+		"SESSION_RETENTION_DAYS":              c.SessionRetentionDays,
+		"CLUSTER_PROMOTE_MIN_SESSIONS":        c.ClusterPromoteMinSessions,
+		"RATE_LIMIT_INGEST_BURST":             c.RateLimitIngestBurst,
+		"RATE_LIMIT_READ_BURST":               c.RateLimitReadBurst,
+		"RATE_LIMIT_ADMIN_BURST":              c.RateLimitAdminBurst,
+		"RATE_LIMIT_ADDRESS_BURST":            c.RateLimitAddressBurst,
+	}
+	if c.S3LifecycleEnabled {
+		positive["S3_LIFECYCLE_EXPIRATION_DAYS"] = c.S3LifecycleExpirationDays
+	}
+	for _, key := range sortedKeys(positive) {
+		if positive[key] < 1 {
+			l.fail(key, "must be positive")
+		}
+	}
+
+	nonNegative := map[string]int{
+		"METRICS_MAX_PROJECT_LABELS":                  c.MetricsMaxProjectLabels,
+		"API_KEY_ROTATION_GRACE_MINUTES":              c.APIKeyRotationGraceMinutes,
+		"API_KEY_UNUSED_DAYS":                         c.APIKeyUnusedDays,
+		"API_KEY_AUDIT_INTERVAL_MINUTES":              c.APIKeyAuditIntervalMinutes,
+		"AUTO_CLEANUP_INTERVAL_MINUTES":               c.AutoCleanupIntervalMinutes,
+		"RETENTION_TAG_SYNC_INTERVAL_MINUTES":         c.RetentionTagSyncMinutes,
+		"ARTIFACT_ENCRYPTION_REWRAP_INTERVAL_MINUTES": c.EncryptionRewrapMinutes,
+	}
+	for _, key := range sortedKeys(nonNegative) {
+		if nonNegative[key] < 0 {
+			l.fail(key, "must not be negative")
+		}
+	}
+
+	rates := map[string]float64{
+		"RATE_LIMIT_INGEST_REQUESTS_PER_SEC":  c.RateLimitIngestPerSec,
+		"RATE_LIMIT_READ_REQUESTS_PER_SEC":    c.RateLimitReadPerSec,
+		"RATE_LIMIT_ADMIN_REQUESTS_PER_SEC":   c.RateLimitAdminPerSec,
+		"RATE_LIMIT_ADDRESS_REQUESTS_PER_SEC": c.RateLimitAddressPerSec,
+	}
+	for _, key := range sortedKeys(rates) {
+		if rates[key] <= 0 {
+			l.fail(key, "must be positive")
+		}
+	}
+}
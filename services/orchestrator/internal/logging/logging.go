@@ -0,0 +1,144 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	RequestIDKey  = "request_id"
+	ProjectIDKey  = "project_id"
+	SessionIDKey  = "session_id"
+	ClusterKeyKey = "cluster_key"
+	ErrorKey      = "error"
+)
+
+type fieldsContextKey struct{}
+
+// fields is shared by pointer so values resolved deep in the middleware chain
+// (project, session) also reach the request log written on the way out.
+type fields struct {
+	mu         sync.Mutex
+	requestID  string
+	projectID  string
+	sessionID  string
+	clusterKey string
+}
+
+// New builds a logger for format "json" or "text" at level debug, info, warn
+// or error. Records pick up correlation fields from their context.
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	var slogLevel slog.Level
+	if err := slogLevel.UnmarshalText([]byte(strings.TrimSpace(level))); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", level)
+	}
+
+	options := &slog.HandlerOptions{Level: slogLevel}
+	var handler slog.Handler
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", "json":
+		handler = slog.NewJSONHandler(w, options)
+	case "text":
+		handler = slog.NewTextHandler(w, options)
+	default:
+		return nil, fmt.Errorf("invalid log format %q", format)
+	}
+	return slog.New(contextHandler{Handler: handler}), nil
+}
+
+// Setup installs logger as the slog default; the standard library logger then
+// writes through it too.
+func Setup(w io.Writer, level, format string) error {
+	logger, err := New(w, level, format)
+	if err != nil {
+		return err
+	}
+	log.SetFlags(0)
+	slog.SetDefault(logger)
+	return nil
+}
+
+// WithRequest starts the correlation fields for one request.
+func WithRequest(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, fieldsContextKey{}, &fields{requestID: requestID})
+}
+
+func SetProject(ctx context.Context, projectID string) {
+	update(ctx, func(f *fields) { f.projectID = projectID })
+}
+
+func SetSession(ctx context.Context, sessionID string) {
+	update(ctx, func(f *fields) { f.sessionID = sessionID })
+}
+
+func SetClusterKey(ctx context.Context, clusterKey string) {
+	update(ctx, func(f *fields) { f.clusterKey = clusterKey })
+}
+
+// Err is the error attribute every failure log uses.
+func Err(err error) slog.Attr {
+	if err == nil {
+		return slog.String(ErrorKey, "")
+	}
+	return slog.String(ErrorKey, err.Error())
+}
+
+func update(ctx context.Context, apply func(*fields)) {
+	if f, ok := ctx.Value(fieldsContextKey{}).(*fields); ok {
+		f.mu.Lock()
+		apply(f)
+		f.mu.Unlock()
+	}
+}
+
+// contextHandler adds the request's correlation fields and trace IDs to each
+// record, skipping keys the call site already set.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if ctx == nil {
+		return h.Handler.Handle(ctx, record)
+	}
+
+	present := map[string]bool{}
+	record.Attrs(func(attr slog.Attr) bool {
+		present[attr.Key] = true
+		return true
+	})
+	add := func(key, value string) {
+		if value != "" && !present[key] {
+			record.AddAttrs(slog.String(key, value))
+		}
+	}
+
+	if f, ok := ctx.Value(fieldsContextKey{}).(*fields); ok {
+		f.mu.Lock()
+		add(RequestIDKey, f.requestID)
+		add(ProjectIDKey, f.projectID)
+		add(SessionIDKey, f.sessionID)
+		add(ClusterKeyKey, f.clusterKey)
+		f.mu.Unlock()
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		add("trace_id", spanContext.TraceID().String())
+		add("span_id", spanContext.SpanID().String())
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLoggerAddsRequestFieldsFromContext(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := New(&out, "info", "json")
+	if err != nil {
+		t.Fatalf("new logger failed: %v", err)
+	}
+
+	ctx := WithRequest(context.Background(), "req-1")
+	SetProject(ctx, "proj_a")
+	SetSession(ctx, "sess_a")
+	SetClusterKey(ctx, "cluster_a")
+	logger.ErrorContext(ctx, "replay job enqueue failed", slog.String(SessionIDKey, "sess_override"), Err(errors.New("redis down")))
+
+	if strings.Count(out.String(), `"session_id"`) != 1 {
+		t.Fatalf("expected a single session_id field, got %s", out.String())
+	}
+	record := map[string]any{}
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf("expected json log line, got %q: %v", out.String(), err)
+	}
+	expected := map[string]string{
+		"msg":         "replay job enqueue failed",
+		"level":       "ERROR",
+		RequestIDKey:  "req-1",
+		ProjectIDKey:  "proj_a",
+		SessionIDKey:  "sess_override",
+		ClusterKeyKey: "cluster_a",
+		ErrorKey:      "redis down",
+	}
+	for key, value := range expected {
+		if record[key] != value {
+			t.Fatalf("expected %s=%q, got %v in %s", key, value, record[key], out.String())
+		}
+	}
+}
+
+func TestLoggerHonoursLevelAndFormat(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := New(&out, "warn", "text")
+	if err != nil {
+		t.Fatalf("new logger failed: %v", err)
+	}
+	logger.Info("dropped")
+	logger.Warn("kept")
+	if strings.Contains(out.String(), "dropped") || !strings.Contains(out.String(), "msg=kept") {
+		t.Fatalf("expected only the warn record in text format, got %q", out.String())
+	}
+
+	if _, err := New(&out, "verbose", "json"); err == nil {
+		t.Fatalf("expected invalid level to be rejected")
+	}
+	if _, err := New(&out, "info", "xml"); err == nil {
+		t.Fatalf("expected invalid format to be rejected")
+	}
+}
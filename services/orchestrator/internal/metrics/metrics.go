@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+var Registry = prometheus.NewRegistry()
+
+var (
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "retrospec_db_query_duration_seconds",
+		Help:    "Postgres query duration by store method.",
+		Buckets: []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+	}, []string{"query", "result"})
+
+	s3OperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "retrospec_s3_operation_duration_seconds",
+		Help:    "Artifact bucket operation duration, including SDK retries.",
+		Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"operation", "result"})
+
+	enqueueDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "retrospec_queue_enqueue_duration_seconds",
+		Help:    "Time to append a job to its Redis stream.",
+		Buckets: []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 1},
+	}, []string{"queue", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		dbQueryDuration,
+		s3OperationDuration,
+		enqueueDuration,
+	)
+}
+
+func ObserveDBQuery(query string, started time.Time, err error) {
+	dbQueryDuration.WithLabelValues(query, result(err)).Observe(time.Since(started).Seconds())
+}
+
+func ObserveS3Operation(operation string, started time.Time, err error) {
+	s3OperationDuration.WithLabelValues(operation, result(err)).Observe(time.Since(started).Seconds())
+}
+
+func ObserveEnqueue(queue string, started time.Time, err error) {
+	enqueueDuration.WithLabelValues(queue, result(err)).Observe(time.Since(started).Seconds())
+}
+
+func result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
@@ -1,6 +1,10 @@
 package queue
 
-import "context"
+import (
+	"context"
+	"strings"
+	"time"
+)
 
 type ReplayJob struct {
 	ProjectID       string `json:"projectId"`
@@ -10,6 +14,8 @@ type ReplayJob struct {
 	TriggerKind     string `json:"triggerKind"`
 	Route           string `json:"route"`
 	Site            string `json:"site"`
+	Lane            Lane   `json:"lane,omitempty"`
+	Traceparent     string `json:"traceparent,omitempty"`
 }
 
 type AnalysisJob struct {
@@ -21,17 +27,83 @@ type AnalysisJob struct {
 	TriggerKind     string   `json:"triggerKind"`
 	Route           string   `json:"route"`
 	Site            string   `json:"site"`
+	Lane            Lane     `json:"lane,omitempty"`
+	Traceparent     string   `json:"traceparent,omitempty"`
+}
+
+// Lane is a job's priority within its queue. Each lane is its own stream so
+// workers can favour urgent jobs without a burst of low-value ones ahead of
+// them.
+type Lane string
+
+const (
+	LaneHigh    Lane = "high"
+	LaneDefault Lane = "default"
+	LaneLow     Lane = "low"
+)
+
+// Lanes lists lanes from highest to lowest priority.
+var Lanes = []Lane{LaneHigh, LaneDefault, LaneLow}
+
+// NormalizeLane maps unknown or empty values to LaneDefault.
+func NormalizeLane(raw string) Lane {
+	switch lane := Lane(strings.ToLower(strings.TrimSpace(raw))); lane {
+	case LaneHigh, LaneLow:
+		return lane
+	default:
+		return LaneDefault
+	}
+}
+
+// Promote returns the next higher lane, staying at LaneHigh.
+func (l Lane) Promote() Lane {
+	if NormalizeLane(string(l)) == LaneLow {
+		return LaneDefault
+	}
+	return LaneHigh
 }
 
+// Demote returns the next lower lane, staying at LaneLow.
+func (l Lane) Demote() Lane {
+	if NormalizeLane(string(l)) == LaneHigh {
+		return LaneDefault
+	}
+	return LaneLow
+}
+
+// LaneStream names the stream backing lane. The default lane keeps the base
+// queue name so jobs enqueued before lanes existed are still consumed.
+func LaneStream(queueName string, lane Lane) string {
+	lane = NormalizeLane(string(lane))
+	if lane == LaneDefault {
+		return queueName
+	}
+	return queueName + ":lane:" + string(lane)
+}
+
+// QueueStats stream depth and pending counts are totals across lanes; the
+// lane maps break them down. Retry and dead-letter keys are shared by all
+// lanes of a queue.
 type QueueStats struct {
-	ReplayStreamDepth   int64 `json:"replayStreamDepth"`
-	ReplayPending       int64 `json:"replayPending"`
-	ReplayRetryDepth    int64 `json:"replayRetryDepth"`
-	ReplayFailedDepth   int64 `json:"replayFailedDepth"`
-	AnalysisStreamDepth int64 `json:"analysisStreamDepth"`
-	AnalysisPending     int64 `json:"analysisPending"`
-	AnalysisRetryDepth  int64 `json:"analysisRetryDepth"`
-	AnalysisFailedDepth int64 `json:"analysisFailedDepth"`
+	ReplayStreamDepth   int64              `json:"replayStreamDepth"`
+	ReplayPending       int64              `json:"replayPending"`
+	ReplayRetryDepth    int64              `json:"replayRetryDepth"`
+	ReplayFailedDepth   int64              `json:"replayFailedDepth"`
+	ReplayLanes         map[Lane]LaneStats `json:"replayLanes"`
+	AnalysisStreamDepth int64              `json:"analysisStreamDepth"`
+	AnalysisPending     int64              `json:"analysisPending"`
+	AnalysisRetryDepth  int64              `json:"analysisRetryDepth"`
+	AnalysisFailedDepth int64              `json:"analysisFailedDepth"`
+	AnalysisLanes       map[Lane]LaneStats `json:"analysisLanes"`
+}
+
+// LaneStats describes one lane stream. OldestAgeSeconds is the age of the
+// oldest entry still in the stream, waiting or in flight; a growing value on
+// a lower lane while higher lanes stay busy means it is being starved.
+type LaneStats struct {
+	StreamDepth      int64   `json:"streamDepth"`
+	Pending          int64   `json:"pending"`
+	OldestAgeSeconds float64 `json:"oldestAgeSeconds"`
 }
 
 type DeadLetterQueueKind string
@@ -49,6 +121,60 @@ type DeadLetterRedriveResult struct {
 	RemainingFailed int64               `json:"remainingFailed"`
 }
 
+// DeadLetterSelectiveRedriveResult reports a filtered redrive. Entries are
+// the matched entries, redriven or, on a dry run, that would be. Truncated is
+// set when the scan window ran out before limit was reached, leaving newer
+// entries unexamined.
+type DeadLetterSelectiveRedriveResult struct {
+	DeadLetterRedriveResult
+	DryRun    bool              `json:"dryRun"`
+	Scanned   int               `json:"scanned"`
+	Matched   int               `json:"matched"`
+	Truncated bool              `json:"truncated"`
+	Entries   []DeadLetterEntry `json:"entries"`
+}
+
+// DeadLetterFilter selects dead-letter entries by their parsed fields. Empty
+// fields match everything; a time bound excludes entries without a parsable
+// failedAt.
+type DeadLetterFilter struct {
+	ProjectID     string    `json:"projectId"`
+	SessionID     string    `json:"sessionId"`
+	TriggerKind   string    `json:"triggerKind"`
+	ErrorContains string    `json:"errorContains"`
+	FailedAfter   time.Time `json:"failedAfter"`
+	FailedBefore  time.Time `json:"failedBefore"`
+}
+
+func (f DeadLetterFilter) Matches(entry DeadLetterEntry) bool {
+	if f.ProjectID != "" && entry.ProjectID != f.ProjectID {
+		return false
+	}
+	if f.SessionID != "" && entry.SessionID != f.SessionID {
+		return false
+	}
+	if f.TriggerKind != "" && entry.TriggerKind != f.TriggerKind {
+		return false
+	}
+	if f.ErrorContains != "" && !strings.Contains(strings.ToLower(entry.Error), strings.ToLower(f.ErrorContains)) {
+		return false
+	}
+	if f.FailedAfter.IsZero() && f.FailedBefore.IsZero() {
+		return true
+	}
+	failedAt, err := time.Parse(time.RFC3339Nano, entry.FailedAt)
+	if err != nil {
+		return false
+	}
+	if !f.FailedAfter.IsZero() && failedAt.Before(f.FailedAfter) {
+		return false
+	}
+	if !f.FailedBefore.IsZero() && !failedAt.Before(f.FailedBefore) {
+		return false
+	}
+	return true
+}
+
 type DeadLetterEntry struct {
 	FailedAt    string `json:"failedAt"`
 	Error       string `json:"error"`
@@ -97,10 +223,40 @@ type StatsProvider interface {
 	QueueStats(ctx context.Context) (QueueStats, error)
 }
 
+type HealthChecker interface {
+	Health(ctx context.Context) error
+}
+
+// NonceStore records single-use values; RememberNonce reports false when the
+// key was already seen within ttl.
+type NonceStore interface {
+	RememberNonce(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+// RateLimitDecision is the outcome of a single rate limit check.
+type RateLimitDecision struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+	ResetAfter time.Duration
+}
+
+// RateLimitStore applies a GCRA limit of requestsPerSec with the given burst
+// to key, shared by every replica that uses the same store.
+type RateLimitStore interface {
+	AllowRate(ctx context.Context, key string, requestsPerSec float64, burst int) (RateLimitDecision, error)
+}
+
 type DeadLetterRedriver interface {
 	RedriveDeadLetters(ctx context.Context, queueKind DeadLetterQueueKind, limit int) (DeadLetterRedriveResult, error)
 }
 
+// DeadLetterSelectiveRedriver redrives only entries matching a filter,
+// oldest first, leaving the rest in place and in order.
+type DeadLetterSelectiveRedriver interface {
+	RedriveMatchingDeadLetters(ctx context.Context, queueKind DeadLetterQueueKind, filter DeadLetterFilter, limit int, dryRun bool) (DeadLetterSelectiveRedriveResult, error)
+}
+
 type DeadLetterInspector interface {
 	ListDeadLetters(ctx context.Context, queueKind DeadLetterQueueKind, scope DeadLetterScope, offset int, limit int) (DeadLetterListResult, error)
 }
@@ -138,6 +294,17 @@ func (p *NoopProducer) RedriveDeadLetters(_ context.Context, queueKind DeadLette
 	}, nil
 }
 
+func (p *NoopProducer) RedriveMatchingDeadLetters(_ context.Context, queueKind DeadLetterQueueKind, _ DeadLetterFilter, limit int, dryRun bool) (DeadLetterSelectiveRedriveResult, error) {
+	return DeadLetterSelectiveRedriveResult{
+		DeadLetterRedriveResult: DeadLetterRedriveResult{
+			QueueKind: queueKind,
+			Requested: limit,
+		},
+		DryRun:  dryRun,
+		Entries: []DeadLetterEntry{},
+	}, nil
+}
+
 func (p *NoopProducer) ListDeadLetters(_ context.Context, queueKind DeadLetterQueueKind, scope DeadLetterScope, offset int, limit int) (DeadLetterListResult, error) {
 	if scope == "" {
 		scope = DeadLetterScopeFailed
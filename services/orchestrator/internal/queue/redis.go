@@ -5,12 +5,18 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"retrospec/services/orchestrator/internal/metrics"
+	"retrospec/services/orchestrator/internal/tracing"
 )
 
 type RedisProducer struct {
@@ -43,52 +49,146 @@ func NewRedisProducer(addr, replayQueueName, analysisQueueName string) (*RedisPr
 	}, nil
 }
 
-func (p *RedisProducer) EnqueueReplayJob(ctx context.Context, job ReplayJob) error {
+func (p *RedisProducer) EnqueueReplayJob(ctx context.Context, job ReplayJob) (err error) {
+	job.Lane = NormalizeLane(string(job.Lane))
+	stream := LaneStream(p.replayQueueName, job.Lane)
+	ctx, span := startEnqueueSpan(ctx, stream, job.ProjectID, job.SessionID)
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
 	if err := p.ensureStreamQueues(ctx); err != nil {
 		return err
 	}
 
+	job.Traceparent = tracing.Traceparent(ctx)
 	payload, err := json.Marshal(job)
 	if err != nil {
 		return err
 	}
 
-	if err := p.client.XAdd(ctx, &redis.XAddArgs{
-		Stream: p.replayQueueName,
+	started := time.Now()
+	err = p.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: stream,
 		Values: map[string]any{
 			"payload": string(payload),
 		},
-	}).Err(); err != nil {
+	}).Err()
+	metrics.ObserveEnqueue("replay", started, err)
+	if err != nil {
 		return fmt.Errorf("enqueue replay job: %w", err)
 	}
 	return nil
 }
 
-func (p *RedisProducer) EnqueueAnalysisJob(ctx context.Context, job AnalysisJob) error {
+func (p *RedisProducer) EnqueueAnalysisJob(ctx context.Context, job AnalysisJob) (err error) {
+	job.Lane = NormalizeLane(string(job.Lane))
+	stream := LaneStream(p.analysisQueueName, job.Lane)
+	ctx, span := startEnqueueSpan(ctx, stream, job.ProjectID, job.SessionID)
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
 	if err := p.ensureStreamQueues(ctx); err != nil {
 		return err
 	}
 
+	job.Traceparent = tracing.Traceparent(ctx)
 	payload, err := json.Marshal(job)
 	if err != nil {
 		return err
 	}
 
-	if err := p.client.XAdd(ctx, &redis.XAddArgs{
-		Stream: p.analysisQueueName,
+	started := time.Now()
+	err = p.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: stream,
 		Values: map[string]any{
 			"payload": string(payload),
 		},
-	}).Err(); err != nil {
+	}).Err()
+	metrics.ObserveEnqueue("analysis", started, err)
+	if err != nil {
 		return fmt.Errorf("enqueue analysis job: %w", err)
 	}
 	return nil
 }
 
+// startEnqueueSpan opens the producer span whose context is carried to the
+// worker as the job's traceparent.
+func startEnqueueSpan(ctx context.Context, queueName, projectID, sessionID string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "enqueue "+queueName,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "redis"),
+			attribute.String("messaging.destination.name", queueName),
+			attribute.String("retrospec.project_id", projectID),
+			attribute.String("retrospec.session_id", sessionID),
+		),
+	)
+}
+
+func (p *RedisProducer) Health(ctx context.Context) error {
+	return p.client.Ping(ctx).Err()
+}
+
 func (p *RedisProducer) Close() error {
 	return p.client.Close()
 }
 
+func (p *RedisProducer) RememberNonce(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return p.client.SetNX(ctx, "retrospec:nonce:"+key, "1", ttl).Result()
+}
+
+// gcraScript stores each key's theoretical arrival time in milliseconds and
+// uses the Redis clock so replicas with skewed clocks share one schedule.
+var gcraScript = redis.NewScript(`
+local emission = tonumber(ARGV[1])
+local tolerance = tonumber(ARGV[2])
+local clock = redis.call('TIME')
+local now = tonumber(clock[1]) * 1000 + math.floor(tonumber(clock[2]) / 1000)
+local tat = tonumber(redis.call('GET', KEYS[1]))
+if not tat or tat < now then
+  tat = now
+end
+local nextTat = tat + emission
+local ahead = nextTat - now
+if ahead > tolerance then
+  return {0, 0, math.ceil(ahead - tolerance), math.ceil(tat - now)}
+end
+redis.call('SET', KEYS[1], string.format('%.3f', nextTat), 'PX', math.ceil(ahead))
+return {1, math.floor((tolerance - ahead) / emission), 0, math.ceil(ahead)}
+`)
+
+func (p *RedisProducer) AllowRate(ctx context.Context, key string, requestsPerSec float64, burst int) (RateLimitDecision, error) {
+	if requestsPerSec <= 0 || burst <= 0 {
+		return RateLimitDecision{}, errors.New("rate limit must be positive")
+	}
+
+	emission := 1000 / requestsPerSec
+	values, err := gcraScript.Run(
+		ctx,
+		p.client,
+		[]string{"retrospec:ratelimit:" + key},
+		emission,
+		emission*float64(burst),
+	).Int64Slice()
+	if err != nil {
+		return RateLimitDecision{}, fmt.Errorf("rate limit check: %w", err)
+	}
+	if len(values) != 4 {
+		return RateLimitDecision{}, fmt.Errorf("rate limit check: unexpected reply %v", values)
+	}
+
+	return RateLimitDecision{
+		Allowed:    values[0] == 1,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+		ResetAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
+
 func (p *RedisProducer) QueueStats(ctx context.Context) (QueueStats, error) {
 	if err := p.ensureStreamQueues(ctx); err != nil {
 		return QueueStats{}, err
@@ -108,10 +208,12 @@ func (p *RedisProducer) QueueStats(ctx context.Context) (QueueStats, error) {
 		ReplayPending:       replay.pendingDepth,
 		ReplayRetryDepth:    replay.retryDepth,
 		ReplayFailedDepth:   replay.failedDepth,
+		ReplayLanes:         replay.lanes,
 		AnalysisStreamDepth: analysis.streamDepth,
 		AnalysisPending:     analysis.pendingDepth,
 		AnalysisRetryDepth:  analysis.retryDepth,
 		AnalysisFailedDepth: analysis.failedDepth,
+		AnalysisLanes:       analysis.lanes,
 	}, nil
 }
 
@@ -159,7 +261,7 @@ func (p *RedisProducer) RedriveDeadLetters(ctx context.Context, queueKind DeadLe
 		}
 
 		if err := p.client.XAdd(ctx, &redis.XAddArgs{
-			Stream: queueName,
+			Stream: LaneStream(queueName, payloadLane(payload)),
 			Values: map[string]any{
 				"payload": payload,
 			},
@@ -185,6 +287,140 @@ func (p *RedisProducer) RedriveDeadLetters(ctx context.Context, queueKind DeadLe
 	return result, nil
 }
 
+// RedriveMatchingDeadLetters scans the oldest maxDeadLetterScan failed
+// entries from the tail and redrives up to limit matches. Each match is
+// removed by value, so entries pushed or redriven concurrently are never
+// lost or doubled, and non-matching entries keep their order. A match whose
+// enqueue fails is restored next to its original neighbour.
+func (p *RedisProducer) RedriveMatchingDeadLetters(
+	ctx context.Context,
+	queueKind DeadLetterQueueKind,
+	filter DeadLetterFilter,
+	limit int,
+	dryRun bool,
+) (DeadLetterSelectiveRedriveResult, error) {
+	if err := p.ensureStreamQueues(ctx); err != nil {
+		return DeadLetterSelectiveRedriveResult{}, err
+	}
+
+	queueName, err := p.deadLetterQueueName(queueKind)
+	if err != nil {
+		return DeadLetterSelectiveRedriveResult{}, err
+	}
+
+	normalizedLimit := limit
+	if normalizedLimit < 1 {
+		normalizedLimit = 1
+	}
+	if normalizedLimit > 500 {
+		normalizedLimit = 500
+	}
+
+	failedKey := queueName + ":failed"
+	unprocessableKey := failedKey + ":unprocessable"
+	result := DeadLetterSelectiveRedriveResult{
+		DeadLetterRedriveResult: DeadLetterRedriveResult{
+			QueueKind: queueKind,
+			Requested: normalizedLimit,
+		},
+		DryRun:  dryRun,
+		Entries: []DeadLetterEntry{},
+	}
+
+	// One extra row is read past the window so the newest scanned entry has a
+	// neighbour to be restored after, and so a longer list is detected.
+	rows, err := p.client.LRange(ctx, failedKey, -(maxDeadLetterScan + 1), -1).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return result, fmt.Errorf("scan dead-letter entries: %w", err)
+	}
+	first := 0
+	if len(rows) > maxDeadLetterScan {
+		first = 1
+	}
+
+	for index := len(rows) - 1; index >= first && result.Matched < normalizedLimit; index-- {
+		raw := rows[index]
+		result.Scanned++
+		entry := parseDeadLetterEntry(raw)
+		if !filter.Matches(entry) {
+			continue
+		}
+		result.Matched++
+		result.Entries = append(result.Entries, entry)
+
+		payload, ok := extractDeadLetterPayload(raw)
+		if dryRun {
+			if !ok {
+				result.Skipped++
+			}
+			continue
+		}
+
+		removed, err := p.client.LRem(ctx, failedKey, -1, raw).Result()
+		if err != nil {
+			return result, fmt.Errorf("remove dead-letter entry: %w", err)
+		}
+		if removed == 0 {
+			// Another redrive or purge took it first.
+			result.Matched--
+			result.Entries = result.Entries[:len(result.Entries)-1]
+			continue
+		}
+
+		if !ok {
+			if err := p.client.LPush(ctx, unprocessableKey, raw).Err(); err != nil {
+				return result, fmt.Errorf("store unprocessable dead-letter entry: %w", err)
+			}
+			result.Skipped++
+			continue
+		}
+
+		if err := p.client.XAdd(ctx, &redis.XAddArgs{
+			Stream: LaneStream(queueName, payloadLane(payload)),
+			Values: map[string]any{
+				"payload": payload,
+			},
+		}).Err(); err != nil {
+			if restoreErr := p.restoreDeadLetter(ctx, failedKey, raw, rows, index); restoreErr != nil {
+				return result, fmt.Errorf("redrive enqueue failed: %v (restore failed: %w)", err, restoreErr)
+			}
+			return result, fmt.Errorf("redrive enqueue failed: %w", err)
+		}
+		result.Redriven++
+	}
+	result.Truncated = first > 0 && result.Matched < normalizedLimit
+
+	remainingFailed, err := p.client.LLen(ctx, failedKey).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return result, fmt.Errorf("remaining dead-letter depth: %w", err)
+	}
+	result.RemainingFailed = remainingFailed
+
+	return result, nil
+}
+
+// restoreDeadLetter puts raw back where rows[index] sat: after its newer
+// neighbour, else before its older one. rows runs newest to oldest, and the
+// list end is only used when both neighbours have since been removed.
+func (p *RedisProducer) restoreDeadLetter(ctx context.Context, failedKey, raw string, rows []string, index int) error {
+	if index > 0 {
+		inserted, err := p.client.LInsertAfter(ctx, failedKey, rows[index-1], raw).Result()
+		if err != nil || inserted > 0 {
+			return err
+		}
+	}
+	if index+1 < len(rows) {
+		inserted, err := p.client.LInsertBefore(ctx, failedKey, rows[index+1], raw).Result()
+		if err != nil || inserted > 0 {
+			return err
+		}
+	}
+	if index == 0 {
+		return p.client.LPush(ctx, failedKey, raw).Err()
+	}
+	return p.client.RPush(ctx, failedKey, raw).Err()
+}
+
 func (p *RedisProducer) ListDeadLetters(ctx context.Context, queueKind DeadLetterQueueKind, scope DeadLetterScope, offset int, limit int) (DeadLetterListResult, error) {
 	if err := p.ensureStreamQueues(ctx); err != nil {
 		return DeadLetterListResult{}, err
@@ -313,6 +549,10 @@ func (p *RedisProducer) PurgeDeadLetters(ctx context.Context, queueKind DeadLett
 	}, nil
 }
 
+// maxDeadLetterScan bounds how many of the oldest failed entries a selective
+// redrive inspects.
+const maxDeadLetterScan = 5000
+
 func (p *RedisProducer) deadLetterQueueName(queueKind DeadLetterQueueKind) (string, error) {
 	switch queueKind {
 	case DeadLetterQueueReplay:
@@ -422,6 +662,16 @@ func fillDeadLetterJobMetadata(entry *DeadLetterEntry, payload string) bool {
 	return true
 }
 
+// payloadLane reads the lane a job was enqueued with; payloads from before
+// lanes existed go to the default lane.
+func payloadLane(payload string) Lane {
+	envelope := struct {
+		Lane string `json:"lane"`
+	}{}
+	_ = json.Unmarshal([]byte(payload), &envelope)
+	return NormalizeLane(envelope.Lane)
+}
+
 func mapString(values map[string]any, key string) string {
 	value, ok := values[key]
 	if !ok {
@@ -442,11 +692,13 @@ func (p *RedisProducer) ensureStreamQueues(ctx context.Context) error {
 		return nil
 	}
 
-	if err := p.ensureStreamQueue(ctx, p.replayQueueName); err != nil {
-		return fmt.Errorf("ensure replay queue stream: %w", err)
-	}
-	if err := p.ensureStreamQueue(ctx, p.analysisQueueName); err != nil {
-		return fmt.Errorf("ensure analysis queue stream: %w", err)
+	for _, lane := range Lanes {
+		if err := p.ensureStreamQueue(ctx, LaneStream(p.replayQueueName, lane)); err != nil {
+			return fmt.Errorf("ensure replay queue stream: %w", err)
+		}
+		if err := p.ensureStreamQueue(ctx, LaneStream(p.analysisQueueName, lane)); err != nil {
+			return fmt.Errorf("ensure analysis queue stream: %w", err)
+		}
 	}
 
 	p.queuesEnsured = true
@@ -495,7 +747,7 @@ func (p *RedisProducer) ensureStreamQueue(ctx context.Context, queueName string)
 			return fmt.Errorf("cleanup legacy queue key: %w", err)
 		}
 		if migrated > 0 {
-			log.Printf("migrated legacy list queue to stream queue=%s entries=%d", queueName, migrated)
+			slog.InfoContext(ctx, "migrated legacy list queue to stream", slog.String("queue", queueName), slog.Int("entries", migrated))
 		}
 		return nil
 	default:
@@ -508,50 +760,89 @@ type queueDepth struct {
 	pendingDepth int64
 	retryDepth   int64
 	failedDepth  int64
+	lanes        map[Lane]LaneStats
 }
 
 func (p *RedisProducer) queueStatsFor(ctx context.Context, queueName string) (queueDepth, error) {
-	streamDepth, err := p.client.XLen(ctx, queueName).Result()
+	depth := queueDepth{lanes: make(map[Lane]LaneStats, len(Lanes))}
+	for _, lane := range Lanes {
+		stats, err := p.laneStatsFor(ctx, LaneStream(queueName, lane))
+		if err != nil {
+			return queueDepth{}, fmt.Errorf("%s lane: %w", lane, err)
+		}
+		depth.lanes[lane] = stats
+		depth.streamDepth += stats.StreamDepth
+		depth.pendingDepth += stats.Pending
+	}
+
+	retryDepth, err := p.client.ZCard(ctx, queueName+":retry").Result()
+	if err != nil && err != redis.Nil {
+		return queueDepth{}, err
+	}
+	if errors.Is(err, redis.Nil) {
+		retryDepth = 0
+	}
+
+	failedDepth, err := p.client.LLen(ctx, queueName+":failed").Result()
 	if err != nil && err != redis.Nil {
 		return queueDepth{}, err
 	}
+	if errors.Is(err, redis.Nil) {
+		failedDepth = 0
+	}
+
+	depth.retryDepth = retryDepth
+	depth.failedDepth = failedDepth
+	return depth, nil
+}
+
+// laneStatsFor relies on workers deleting entries once acknowledged, so the
+// first entry left in the stream is the oldest job not yet finished.
+func (p *RedisProducer) laneStatsFor(ctx context.Context, streamName string) (LaneStats, error) {
+	streamDepth, err := p.client.XLen(ctx, streamName).Result()
+	if err != nil && err != redis.Nil {
+		return LaneStats{}, err
+	}
 	if errors.Is(err, redis.Nil) {
 		streamDepth = 0
 	}
 
 	pendingDepth := int64(0)
-	groupName := fmt.Sprintf("%s:group", queueName)
-	pendingResult, pendingErr := p.client.XPending(ctx, queueName, groupName).Result()
+	groupName := fmt.Sprintf("%s:group", streamName)
+	pendingResult, pendingErr := p.client.XPending(ctx, streamName, groupName).Result()
 	if pendingErr == nil {
 		pendingDepth = pendingResult.Count
 	} else {
 		details := pendingErr.Error()
-		if details != "NOGROUP No such key '"+queueName+"' or consumer group '"+groupName+"'" &&
+		if details != "NOGROUP No such key '"+streamName+"' or consumer group '"+groupName+"'" &&
 			details != "ERR The XGROUP subcommand requires the key to exist" {
-			return queueDepth{}, pendingErr
+			return LaneStats{}, pendingErr
 		}
 	}
 
-	retryDepth, err := p.client.ZCard(ctx, queueName+":retry").Result()
-	if err != nil && err != redis.Nil {
-		return queueDepth{}, err
-	}
-	if errors.Is(err, redis.Nil) {
-		retryDepth = 0
+	stats := LaneStats{StreamDepth: streamDepth, Pending: pendingDepth}
+	if streamDepth == 0 {
+		return stats, nil
 	}
-
-	failedDepth, err := p.client.LLen(ctx, queueName+":failed").Result()
+	oldest, err := p.client.XRangeN(ctx, streamName, "-", "+", 1).Result()
 	if err != nil && err != redis.Nil {
-		return queueDepth{}, err
+		return LaneStats{}, err
 	}
-	if errors.Is(err, redis.Nil) {
-		failedDepth = 0
+	if len(oldest) > 0 {
+		if enqueuedAt, ok := streamEntryTime(oldest[0].ID); ok {
+			stats.OldestAgeSeconds = max(time.Since(enqueuedAt).Seconds(), 0)
+		}
 	}
+	return stats, nil
+}
 
-	return queueDepth{
-		streamDepth:  streamDepth,
-		pendingDepth: pendingDepth,
-		retryDepth:   retryDepth,
-		failedDepth:  failedDepth,
-	}, nil
+// streamEntryTime reads the millisecond timestamp Redis puts in generated
+// stream entry IDs.
+func streamEntryTime(id string) (time.Time, bool) {
+	millis, _, _ := strings.Cut(id, "-")
+	value, err := strconv.ParseInt(millis, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(value), true
 }
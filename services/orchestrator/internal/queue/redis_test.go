@@ -6,9 +6,13 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/alicebob/miniredis/v2"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"retrospec/services/orchestrator/internal/tracing"
 )
 
 func TestRedisProducerMigratesLegacyListToStream(t *testing.T) {
@@ -426,6 +430,172 @@ func TestRedisProducerPurgeDeadLetters(t *testing.T) {
 	}
 }
 
+func TestRedisProducerRedriveMatchingDeadLettersKeepsOthersInOrder(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ctx := context.Background()
+	replayQueue := "replay-jobs"
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+
+	producer, err := NewRedisProducer(mr.Addr(), replayQueue, "analysis-jobs")
+	if err != nil {
+		t.Fatalf("new producer failed: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = producer.Close()
+	})
+
+	seeded := []struct {
+		sessionID string
+		details   string
+	}{
+		{"session-1", "render timeout"},
+		{"session-2", "s3 access denied"},
+		{"session-3", "Render Timeout after 120s"},
+		{"session-4", "s3 access denied"},
+	}
+	for _, seed := range seeded {
+		payload := `{"projectId":"proj_test","sessionId":"` + seed.sessionID + `","triggerKind":"js_exception"}`
+		entry, err := marshalFailedEntry(payload, seed.details)
+		if err != nil {
+			t.Fatalf("marshal failed entry: %v", err)
+		}
+		if err := client.LPush(ctx, replayQueue+":failed", entry).Err(); err != nil {
+			t.Fatalf("seed failed entry: %v", err)
+		}
+	}
+	filter := DeadLetterFilter{ErrorContains: "render timeout", FailedBefore: time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)}
+
+	preview, err := producer.RedriveMatchingDeadLetters(ctx, DeadLetterQueueReplay, filter, 10, true)
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if preview.Matched != 2 || preview.Redriven != 0 || preview.Scanned != 4 || preview.Truncated || preview.Entries[0].SessionID != "session-1" {
+		t.Fatalf("unexpected dry run: %+v", preview)
+	}
+	if depth := client.LLen(ctx, replayQueue+":failed").Val(); depth != 4 {
+		t.Fatalf("expected dry run to leave dead letters, got depth %d", depth)
+	}
+
+	result, err := producer.RedriveMatchingDeadLetters(ctx, DeadLetterQueueReplay, filter, 10, false)
+	if err != nil {
+		t.Fatalf("selective redrive failed: %v", err)
+	}
+	if result.Redriven != 2 || result.RemainingFailed != 2 {
+		t.Fatalf("unexpected selective redrive: %+v", result)
+	}
+
+	rows, err := client.XRange(ctx, replayQueue, "-", "+").Result()
+	if err != nil || len(rows) != 2 {
+		t.Fatalf("expected two redriven stream rows, got %d err=%v", len(rows), err)
+	}
+	if payload, _ := rows[0].Values["payload"].(string); !strings.Contains(payload, "session-1") {
+		t.Fatalf("expected oldest match redriven first, got %v", rows[0].Values["payload"])
+	}
+
+	remaining := client.LRange(ctx, replayQueue+":failed", 0, -1).Val()
+	if len(remaining) != 2 || !strings.Contains(remaining[0], "session-4") || !strings.Contains(remaining[1], "session-2") {
+		t.Fatalf("expected non-matching entries untouched in order, got %v", remaining)
+	}
+}
+
+func TestRedisProducerRedriveMatchingDeadLettersRestoresFailedEnqueueInPlace(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ctx := context.Background()
+	replayQueue := "replay-jobs"
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+
+	producer, err := NewRedisProducer(mr.Addr(), replayQueue, "analysis-jobs")
+	if err != nil {
+		t.Fatalf("new producer failed: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = producer.Close()
+	})
+
+	for _, seed := range []struct{ sessionID, lane string }{{"session-1", ""}, {"session-2", "high"}, {"session-3", ""}} {
+		payload := `{"projectId":"proj_test","sessionId":"` + seed.sessionID + `","lane":"` + seed.lane + `"}`
+		entry, err := marshalFailedEntry(payload, "render timeout")
+		if err != nil {
+			t.Fatalf("marshal failed entry: %v", err)
+		}
+		if err := client.LPush(ctx, replayQueue+":failed", entry).Err(); err != nil {
+			t.Fatalf("seed failed entry: %v", err)
+		}
+	}
+	before := client.LRange(ctx, replayQueue+":failed", 0, -1).Val()
+
+	filter := DeadLetterFilter{SessionID: "session-2"}
+	if _, err := producer.RedriveMatchingDeadLetters(ctx, DeadLetterQueueReplay, filter, 10, true); err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+
+	// A non-stream value on the high lane makes the enqueue of session-2 fail.
+	if err := client.Set(ctx, LaneStream(replayQueue, LaneHigh), "blocked", 0).Err(); err != nil {
+		t.Fatalf("block lane stream: %v", err)
+	}
+
+	if _, err := producer.RedriveMatchingDeadLetters(ctx, DeadLetterQueueReplay, filter, 10, false); err == nil || !strings.Contains(err.Error(), "redrive enqueue failed") {
+		t.Fatalf("expected redrive to report the failed enqueue, got %v", err)
+	}
+
+	after := client.LRange(ctx, replayQueue+":failed", 0, -1).Val()
+	if strings.Join(after, "\n") != strings.Join(before, "\n") {
+		t.Fatalf("expected failed entry restored in place, got %v want %v", after, before)
+	}
+}
+
+func TestRedisProducerRedriveMatchingDeadLettersReportsTruncatedScan(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ctx := context.Background()
+	replayQueue := "replay-jobs"
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+
+	producer, err := NewRedisProducer(mr.Addr(), replayQueue, "analysis-jobs")
+	if err != nil {
+		t.Fatalf("new producer failed: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = producer.Close()
+	})
+
+	entries := make([]any, 0, maxDeadLetterScan+1)
+	for index := 0; index <= maxDeadLetterScan; index++ {
+		details := "s3 access denied"
+		if index == maxDeadLetterScan {
+			details = "render timeout"
+		}
+		entry, err := marshalFailedEntry(fmt.Sprintf(`{"projectId":"proj_test","sessionId":"session-%d"}`, index), details)
+		if err != nil {
+			t.Fatalf("marshal failed entry: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := client.LPush(ctx, replayQueue+":failed", entries...).Err(); err != nil {
+		t.Fatalf("seed failed entries: %v", err)
+	}
+
+	filter := DeadLetterFilter{ErrorContains: "render timeout"}
+	result, err := producer.RedriveMatchingDeadLetters(ctx, DeadLetterQueueReplay, filter, 10, true)
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if result.Scanned != maxDeadLetterScan || result.Matched != 0 || !result.Truncated {
+		t.Fatalf("expected the newest match to be beyond the scan window, got scanned=%d matched=%d truncated=%v", result.Scanned, result.Matched, result.Truncated)
+	}
+}
+
 func marshalFailedEntry(payload string, details string) (string, error) {
 	entry := map[string]any{
 		"failedAt": "2026-01-01T00:00:00Z",
@@ -439,3 +609,220 @@ func marshalFailedEntry(payload string, details string) (string, error) {
 	}
 	return string(encoded), nil
 }
+
+func TestRedisProducerAllowRateSharesBurstAcrossProducers(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ctx := context.Background()
+
+	first, err := NewRedisProducer(mr.Addr(), "replay-jobs", "analysis-jobs")
+	if err != nil {
+		t.Fatalf("new producer failed: %v", err)
+	}
+	t.Cleanup(func() { _ = first.Close() })
+	second, err := NewRedisProducer(mr.Addr(), "replay-jobs", "analysis-jobs")
+	if err != nil {
+		t.Fatalf("new producer failed: %v", err)
+	}
+	t.Cleanup(func() { _ = second.Close() })
+
+	for i, producer := range []*RedisProducer{first, second} {
+		decision, err := producer.AllowRate(ctx, "read:proj_a:api_key:key_1", 1, 2)
+		if err != nil {
+			t.Fatalf("allow rate failed: %v", err)
+		}
+		if !decision.Allowed || decision.Remaining != 1-i {
+			t.Fatalf("request %d: expected allowed with %d remaining, got %+v", i, 1-i, decision)
+		}
+	}
+
+	decision, err := first.AllowRate(ctx, "read:proj_a:api_key:key_1", 1, 2)
+	if err != nil {
+		t.Fatalf("allow rate failed: %v", err)
+	}
+	if decision.Allowed || decision.RetryAfter <= 0 {
+		t.Fatalf("expected third request to be limited with retry-after, got %+v", decision)
+	}
+
+	decision, err = first.AllowRate(ctx, "read:proj_a:api_key:key_2", 1, 2)
+	if err != nil {
+		t.Fatalf("allow rate failed: %v", err)
+	}
+	if !decision.Allowed {
+		t.Fatalf("expected a different key to have its own bucket, got %+v", decision)
+	}
+}
+
+func TestRedisProducerInjectsTraceparent(t *testing.T) {
+	exporter, restore := tracing.NewInMemoryProvider()
+	t.Cleanup(restore)
+
+	mr := miniredis.RunT(t)
+	producer, err := NewRedisProducer(mr.Addr(), "replay-jobs", "analysis-jobs")
+	if err != nil {
+		t.Fatalf("new producer failed: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = producer.Close()
+	})
+
+	ctx, parent := tracing.Tracer().Start(context.Background(), "ingest")
+	if err := producer.EnqueueAnalysisJob(ctx, AnalysisJob{
+		ProjectID:       "proj_test",
+		SessionID:       "session_traced",
+		EventsObjectKey: "events/traced.json",
+		MarkerOffsetsMs: []int{500},
+		TriggerKind:     "api_error",
+	}); err != nil {
+		t.Fatalf("enqueue analysis job failed: %v", err)
+	}
+	parent.End()
+
+	rows, err := producer.client.XRange(context.Background(), "analysis-jobs", "-", "+").Result()
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("expected one analysis row, got %d err=%v", len(rows), err)
+	}
+	job := AnalysisJob{}
+	if err := json.Unmarshal([]byte(rows[0].Values["payload"].(string)), &job); err != nil {
+		t.Fatalf("decode payload failed: %v", err)
+	}
+
+	var enqueueSpan tracetest.SpanStub
+	for _, span := range exporter.GetSpans() {
+		if span.Name == "enqueue analysis-jobs" {
+			enqueueSpan = span
+		}
+	}
+	if !enqueueSpan.SpanContext.IsValid() {
+		t.Fatalf("expected enqueue span, got %+v", exporter.GetSpans())
+	}
+	if enqueueSpan.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Fatalf("expected enqueue span to be a child of the request span")
+	}
+	expected := fmt.Sprintf("00-%s-%s-01", enqueueSpan.SpanContext.TraceID(), enqueueSpan.SpanContext.SpanID())
+	if job.Traceparent != expected {
+		t.Fatalf("expected traceparent %s, got %s", expected, job.Traceparent)
+	}
+}
+
+func TestRedisProducerEnqueuesIntoLaneStreams(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ctx := context.Background()
+	replayQueue := "replay-jobs"
+	analysisQueue := "analysis-jobs"
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+
+	producer, err := NewRedisProducer(mr.Addr(), replayQueue, analysisQueue)
+	if err != nil {
+		t.Fatalf("new producer failed: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = producer.Close()
+	})
+
+	for _, job := range []AnalysisJob{
+		{ProjectID: "proj_test", SessionID: "crash", TriggerKind: "js_exception", Lane: LaneHigh},
+		{ProjectID: "proj_test", SessionID: "click-1", TriggerKind: "ui_no_effect", Lane: LaneLow},
+		{ProjectID: "proj_test", SessionID: "click-2", TriggerKind: "ui_no_effect", Lane: LaneLow},
+		{ProjectID: "proj_test", SessionID: "unset", TriggerKind: "validation_failed"},
+	} {
+		if err := producer.EnqueueAnalysisJob(ctx, job); err != nil {
+			t.Fatalf("enqueue analysis job failed: %v", err)
+		}
+	}
+
+	expectedDepths := map[Lane]int64{LaneHigh: 1, LaneDefault: 1, LaneLow: 2}
+	for lane, expected := range expectedDepths {
+		depth, err := client.XLen(ctx, LaneStream(analysisQueue, lane)).Result()
+		if err != nil {
+			t.Fatalf("xlen %s failed: %v", lane, err)
+		}
+		if depth != expected {
+			t.Fatalf("expected %d entries in %s lane, got %d", expected, lane, depth)
+		}
+	}
+	if LaneStream(analysisQueue, LaneDefault) != analysisQueue {
+		t.Fatalf("expected default lane to use the base stream")
+	}
+
+	rows, err := client.XRange(ctx, LaneStream(analysisQueue, LaneDefault), "-", "+").Result()
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("default lane xrange failed: rows=%d err=%v", len(rows), err)
+	}
+	if payload, _ := rows[0].Values["payload"].(string); !strings.Contains(payload, `"lane":"default"`) {
+		t.Fatalf("expected payload to record its lane, got %s", payload)
+	}
+
+	if err := client.XGroupCreateMkStream(ctx, LaneStream(analysisQueue, LaneLow), LaneStream(analysisQueue, LaneLow)+":group", "0").Err(); err != nil {
+		t.Fatalf("create low lane group failed: %v", err)
+	}
+	if err := client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    LaneStream(analysisQueue, LaneLow) + ":group",
+		Consumer: "test-consumer",
+		Streams:  []string{LaneStream(analysisQueue, LaneLow), ">"},
+		Count:    1,
+	}).Err(); err != nil {
+		t.Fatalf("low lane read failed: %v", err)
+	}
+
+	stats, err := producer.QueueStats(ctx)
+	if err != nil {
+		t.Fatalf("queue stats failed: %v", err)
+	}
+	if stats.AnalysisStreamDepth != 4 || stats.AnalysisPending != 1 {
+		t.Fatalf("unexpected analysis totals: %+v", stats)
+	}
+	low := stats.AnalysisLanes[LaneLow]
+	if low.StreamDepth != 2 || low.Pending != 1 || low.OldestAgeSeconds < 0 {
+		t.Fatalf("unexpected low lane stats: %+v", low)
+	}
+	if high := stats.AnalysisLanes[LaneHigh]; high.StreamDepth != 1 || high.Pending != 0 {
+		t.Fatalf("unexpected high lane stats: %+v", high)
+	}
+	if len(stats.ReplayLanes) != len(Lanes) {
+		t.Fatalf("expected replay stats for every lane, got %+v", stats.ReplayLanes)
+	}
+}
+
+func TestRedisProducerRedrivesIntoRecordedLane(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ctx := context.Background()
+	replayQueue := "replay-jobs"
+	analysisQueue := "analysis-jobs"
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+
+	producer, err := NewRedisProducer(mr.Addr(), replayQueue, analysisQueue)
+	if err != nil {
+		t.Fatalf("new producer failed: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = producer.Close()
+	})
+
+	envelope, _ := json.Marshal(map[string]any{
+		"failedAt": time.Now().UTC().Format(time.RFC3339Nano),
+		"error":    "boom",
+		"payload":  `{"projectId":"proj_test","sessionId":"crash","lane":"high"}`,
+	})
+	if err := client.LPush(ctx, replayQueue+":failed", string(envelope)).Err(); err != nil {
+		t.Fatalf("seed dead letter failed: %v", err)
+	}
+
+	result, err := producer.RedriveDeadLetters(ctx, DeadLetterQueueReplay, 10)
+	if err != nil || result.Redriven != 1 {
+		t.Fatalf("redrive failed: %+v err=%v", result, err)
+	}
+	if depth, _ := client.XLen(ctx, LaneStream(replayQueue, LaneHigh)).Result(); depth != 1 {
+		t.Fatalf("expected redriven job in high lane, got depth %d", depth)
+	}
+	if depth, _ := client.XLen(ctx, replayQueue).Result(); depth != 0 {
+		t.Fatalf("expected default lane to stay empty, got depth %d", depth)
+	}
+}
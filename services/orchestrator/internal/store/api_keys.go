@@ -0,0 +1,218 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	APIKeyScopeIngest = "ingest"
+	APIKeyScopeRead   = "read"
+	APIKeyScopeTriage = "triage"
+	APIKeyScopeAdmin  = "admin"
+)
+
+const projectAPIKeyColumns = `id, project_id, label, status, scopes, created_at, last_used_at, expires_at,
+	(expires_at IS NOT NULL AND expires_at <= NOW()) AS expired, rotated_to_key_id, flagged_unused_at`
+
+var AllAPIKeyScopes = []string{APIKeyScopeIngest, APIKeyScopeRead, APIKeyScopeTriage, APIKeyScopeAdmin}
+
+func (p *Postgres) ResolveAPIKey(ctx context.Context, rawKey string) (ResolvedAPIKey, error) {
+	key := strings.TrimSpace(rawKey)
+	if key == "" {
+		return ResolvedAPIKey{}, pgx.ErrNoRows
+	}
+
+	resolved := ResolvedAPIKey{}
+	err := p.pool.QueryRow(
+		ctx,
+		`UPDATE project_api_keys
+		 SET last_used_at = NOW(),
+		     flagged_unused_at = NULL
+		 WHERE key_hash = $1
+		   AND status = 'active'
+		   AND (expires_at IS NULL OR expires_at > NOW())
+		 RETURNING id, project_id, scopes`,
+		hashAPIKey(key),
+	).Scan(&resolved.ID, &resolved.ProjectID, &resolved.Scopes)
+	if err != nil {
+		return ResolvedAPIKey{}, err
+	}
+
+	return resolved, nil
+}
+
+// RotateProjectAPIKey issues a successor with the same label and scopes and
+// shortens the predecessor's lifetime to the grace window, so clients can roll
+// over without an outage.
+func (p *Postgres) RotateProjectAPIKey(
+	ctx context.Context,
+	projectID string,
+	keyID string,
+	rawKey string,
+	grace time.Duration,
+	successorExpiresAt *time.Time,
+) (ProjectAPIKey, ProjectAPIKey, error) {
+	projectID = normalizeProjectID(projectID)
+	if grace < 0 {
+		grace = 0
+	}
+
+	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return ProjectAPIKey{}, ProjectAPIKey{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		label  string
+		scopes []string
+	)
+	err = tx.QueryRow(
+		ctx,
+		`SELECT label, scopes
+		 FROM project_api_keys
+		 WHERE project_id = $1
+		   AND id = $2
+		   AND status = 'active'
+		   AND (expires_at IS NULL OR expires_at > NOW())
+		   AND rotated_to_key_id = ''
+		 FOR UPDATE`,
+		projectID,
+		strings.TrimSpace(keyID),
+	).Scan(&label, &scopes)
+	if err != nil {
+		return ProjectAPIKey{}, ProjectAPIKey{}, err
+	}
+
+	successor, err := scanProjectAPIKey(tx.QueryRow(
+		ctx,
+		`INSERT INTO project_api_keys (id, project_id, key_hash, label, status, scopes, expires_at)
+		 VALUES ($1, $2, $3, $4, 'active', $5::text[], $6)
+		 RETURNING `+projectAPIKeyColumns,
+		"key_"+uuid.NewString(),
+		projectID,
+		hashAPIKey(strings.TrimSpace(rawKey)),
+		label,
+		scopes,
+		successorExpiresAt,
+	))
+	if err != nil {
+		return ProjectAPIKey{}, ProjectAPIKey{}, err
+	}
+
+	predecessor, err := scanProjectAPIKey(tx.QueryRow(
+		ctx,
+		`UPDATE project_api_keys
+		 SET expires_at = LEAST(COALESCE(expires_at, NOW() + $3::interval), NOW() + $3::interval),
+		     rotated_to_key_id = $4
+		 WHERE project_id = $1
+		   AND id = $2
+		 RETURNING `+projectAPIKeyColumns,
+		projectID,
+		strings.TrimSpace(keyID),
+		fmt.Sprintf("%d seconds", int64(grace/time.Second)),
+		successor.ID,
+	))
+	if err != nil {
+		return ProjectAPIKey{}, ProjectAPIKey{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return ProjectAPIKey{}, ProjectAPIKey{}, err
+	}
+
+	return successor, predecessor, nil
+}
+
+func (p *Postgres) FlagUnusedAPIKeys(ctx context.Context, unusedDays int) ([]ProjectAPIKey, error) {
+	if unusedDays < 1 {
+		return nil, fmt.Errorf("unusedDays must be >= 1")
+	}
+
+	rows, err := p.pool.Query(
+		ctx,
+		`UPDATE project_api_keys
+		 SET flagged_unused_at = NOW()
+		 WHERE status = 'active'
+		   AND flagged_unused_at IS NULL
+		   AND (expires_at IS NULL OR expires_at > NOW())
+		   AND COALESCE(last_used_at, created_at) < NOW() - make_interval(days => $1)
+		 RETURNING `+projectAPIKeyColumns,
+		unusedDays,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]ProjectAPIKey, 0)
+	for rows.Next() {
+		apiKey, err := scanProjectAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, apiKey)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return keys, nil
+}
+
+func scanProjectAPIKey(row pgx.Row) (ProjectAPIKey, error) {
+	apiKey := ProjectAPIKey{}
+	err := row.Scan(
+		&apiKey.ID,
+		&apiKey.ProjectID,
+		&apiKey.Label,
+		&apiKey.Status,
+		&apiKey.Scopes,
+		&apiKey.CreatedAt,
+		&apiKey.LastUsedAt,
+		&apiKey.ExpiresAt,
+		&apiKey.Expired,
+		&apiKey.RotatedToKeyID,
+		&apiKey.FlaggedUnusedAt,
+	)
+	if err != nil {
+		return ProjectAPIKey{}, err
+	}
+	return apiKey, nil
+}
+
+func NormalizeAPIKeyScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return []string{APIKeyScopeIngest}, nil
+	}
+
+	seen := map[string]struct{}{}
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if !HasAPIKeyScope(AllAPIKeyScopes, scope) {
+			return nil, fmt.Errorf("unknown api key scope %q", scope)
+		}
+		if _, exists := seen[scope]; exists {
+			continue
+		}
+		seen[scope] = struct{}{}
+		normalized = append(normalized, scope)
+	}
+	return normalized, nil
+}
+
+func HasAPIKeyScope(scopes []string, scope string) bool {
+	for _, candidate := range scopes {
+		if candidate == scope {
+			return true
+		}
+	}
+	return false
+}
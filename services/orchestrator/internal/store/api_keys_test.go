@@ -0,0 +1,165 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const testProjectID = "proj_default"
+
+// newTestPostgres applies every migration to a throwaway schema in the
+// database named by RETROSPEC_TEST_DATABASE_URL, and skips without one.
+func newTestPostgres(t *testing.T) *Postgres {
+	t.Helper()
+	databaseURL := os.Getenv("RETROSPEC_TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("RETROSPEC_TEST_DATABASE_URL is not set")
+	}
+
+	ctx := context.Background()
+	schema := fmt.Sprintf("retrospec_test_%d", time.Now().UnixNano())
+	admin, err := pgx.Connect(ctx, databaseURL)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer admin.Close(ctx)
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		cleanup, err := pgx.Connect(context.Background(), databaseURL)
+		if err != nil {
+			return
+		}
+		defer cleanup.Close(context.Background())
+		_, _ = cleanup.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+	})
+
+	poolConfig, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		t.Fatalf("parse database url: %v", err)
+	}
+	poolConfig.ConnConfig.RuntimeParams["search_path"] = schema
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		t.Fatalf("open pool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	migrations, err := filepath.Glob(filepath.Join("..", "..", "db", "migrations", "*.sql"))
+	if err != nil || len(migrations) == 0 {
+		t.Fatalf("find migrations: %v", err)
+	}
+	sort.Strings(migrations)
+	for _, migration := range migrations {
+		sql, err := os.ReadFile(migration)
+		if err != nil {
+			t.Fatalf("read %s: %v", migration, err)
+		}
+		if _, err := pool.Exec(ctx, string(sql), pgx.QueryExecModeSimpleProtocol); err != nil {
+			t.Fatalf("apply %s: %v", filepath.Base(migration), err)
+		}
+	}
+
+	return &Postgres{pool: pool}
+}
+
+func TestRotatedAPIKeyWorksOnlyWithinGraceWindow(t *testing.T) {
+	p := newTestPostgres(t)
+	ctx := context.Background()
+
+	original, err := p.CreateAPIKeyForProject(ctx, testProjectID, "sdk", "rsk_original", nil, nil)
+	if err != nil {
+		t.Fatalf("create key: %v", err)
+	}
+	successor, predecessor, err := p.RotateProjectAPIKey(ctx, testProjectID, original.ID, "rsk_successor", time.Hour, nil)
+	if err != nil {
+		t.Fatalf("rotate key: %v", err)
+	}
+	if predecessor.RotatedToKeyID != successor.ID || predecessor.ExpiresAt == nil {
+		t.Fatalf("expected predecessor to point at successor and expire, got %+v", predecessor)
+	}
+
+	if _, err := p.ResolveAPIKey(ctx, "rsk_original"); err != nil {
+		t.Fatalf("expected old key to resolve inside the grace window: %v", err)
+	}
+	if resolved, err := p.ResolveAPIKey(ctx, "rsk_successor"); err != nil || resolved.ID != successor.ID {
+		t.Fatalf("expected successor to resolve, got %+v err=%v", resolved, err)
+	}
+
+	if _, err := p.pool.Exec(ctx, `UPDATE project_api_keys SET expires_at = NOW() - INTERVAL '1 second' WHERE id = $1`, original.ID); err != nil {
+		t.Fatalf("end grace window: %v", err)
+	}
+	if _, err := p.ResolveAPIKey(ctx, "rsk_original"); !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("expected old key to be rejected after the grace window, got %v", err)
+	}
+	if _, _, err := p.RotateProjectAPIKey(ctx, testProjectID, original.ID, "rsk_again", time.Hour, nil); !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("expected expired key not to rotate again, got %v", err)
+	}
+}
+
+func TestExpiredAPIKeyIsRejected(t *testing.T) {
+	p := newTestPostgres(t)
+	ctx := context.Background()
+
+	expiresAt := time.Now().Add(-time.Minute)
+	apiKey, err := p.CreateAPIKeyForProject(ctx, testProjectID, "ci", "rsk_expired", []string{APIKeyScopeRead}, &expiresAt)
+	if err != nil {
+		t.Fatalf("create key: %v", err)
+	}
+	if !apiKey.Expired {
+		t.Fatalf("expected key to be reported as expired, got %+v", apiKey)
+	}
+	if _, err := p.ResolveAPIKey(ctx, "rsk_expired"); !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("expected expired key to be rejected, got %v", err)
+	}
+}
+
+func TestFlagUnusedAPIKeysFlagsStaleKeysUntilUsed(t *testing.T) {
+	p := newTestPostgres(t)
+	ctx := context.Background()
+
+	stale, err := p.CreateAPIKeyForProject(ctx, testProjectID, "stale", "rsk_stale", nil, nil)
+	if err != nil {
+		t.Fatalf("create stale key: %v", err)
+	}
+	if _, err := p.CreateAPIKeyForProject(ctx, testProjectID, "fresh", "rsk_fresh", nil, nil); err != nil {
+		t.Fatalf("create fresh key: %v", err)
+	}
+	if _, err := p.pool.Exec(ctx, `UPDATE project_api_keys SET last_used_at = NOW() - INTERVAL '100 days' WHERE id = $1`, stale.ID); err != nil {
+		t.Fatalf("age key: %v", err)
+	}
+
+	flagged, err := p.FlagUnusedAPIKeys(ctx, 90)
+	if err != nil {
+		t.Fatalf("flag unused keys: %v", err)
+	}
+	if len(flagged) != 1 || flagged[0].ID != stale.ID || flagged[0].FlaggedUnusedAt == nil {
+		t.Fatalf("expected only the stale key to be flagged, got %+v", flagged)
+	}
+	if again, err := p.FlagUnusedAPIKeys(ctx, 90); err != nil || len(again) != 0 {
+		t.Fatalf("expected flagged key not to be reported twice, got %+v err=%v", again, err)
+	}
+
+	if _, err := p.ResolveAPIKey(ctx, "rsk_stale"); err != nil {
+		t.Fatalf("expected flagged key to keep working: %v", err)
+	}
+	keys, err := p.ListProjectAPIKeys(ctx, testProjectID)
+	if err != nil {
+		t.Fatalf("list keys: %v", err)
+	}
+	for _, apiKey := range keys {
+		if apiKey.ID == stale.ID && apiKey.FlaggedUnusedAt != nil {
+			t.Fatalf("expected use to clear the unused flag, got %+v", apiKey)
+		}
+	}
+}
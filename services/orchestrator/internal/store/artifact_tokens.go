@@ -0,0 +1,75 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// RevokeArtifactToken records a token ID as revoked until the token's own
+// expiry; revoking an already revoked token returns the existing record.
+func (p *Postgres) RevokeArtifactToken(
+	ctx context.Context,
+	projectID,
+	tokenID string,
+	expiresAt time.Time,
+	reason,
+	revokedBy string,
+) (ArtifactTokenRevocation, error) {
+	revocation := ArtifactTokenRevocation{}
+	err := p.pool.QueryRow(
+		ctx,
+		`INSERT INTO artifact_token_revocations (token_id, project_id, reason, revoked_by, expires_at)
+		 VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (token_id) DO UPDATE
+		 SET expires_at = GREATEST(artifact_token_revocations.expires_at, EXCLUDED.expires_at)
+		 WHERE artifact_token_revocations.project_id = EXCLUDED.project_id
+		 RETURNING token_id, project_id, reason, revoked_by, expires_at, revoked_at`,
+		strings.TrimSpace(tokenID),
+		normalizeProjectID(projectID),
+		strings.TrimSpace(reason),
+		strings.TrimSpace(revokedBy),
+		expiresAt,
+	).Scan(
+		&revocation.TokenID,
+		&revocation.ProjectID,
+		&revocation.Reason,
+		&revocation.RevokedBy,
+		&revocation.ExpiresAt,
+		&revocation.RevokedAt,
+	)
+	if err != nil {
+		return ArtifactTokenRevocation{}, err
+	}
+
+	return revocation, nil
+}
+
+func (p *Postgres) IsArtifactTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	var revoked bool
+	err := p.pool.QueryRow(
+		ctx,
+		`SELECT TRUE FROM artifact_token_revocations WHERE token_id = $1`,
+		strings.TrimSpace(tokenID),
+	).Scan(&revoked)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	return revoked, err
+}
+
+// PurgeExpiredArtifactTokenRevocations drops revocations for tokens that have
+// expired on their own and can no longer verify.
+func (p *Postgres) PurgeExpiredArtifactTokenRevocations(ctx context.Context) (int64, error) {
+	tag, err := p.pool.Exec(
+		ctx,
+		`DELETE FROM artifact_token_revocations WHERE expires_at < NOW() - INTERVAL '1 day'`,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
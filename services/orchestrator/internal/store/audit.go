@@ -0,0 +1,114 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const maxAuditEventLimit = 10000
+
+// GlobalAuditProjectID scopes audit events for actions that belong to no
+// project, such as admin routes, so they can still be listed.
+const GlobalAuditProjectID = "_global"
+
+func (p *Postgres) InsertAuditEvent(ctx context.Context, event AuditEvent) error {
+	if strings.TrimSpace(event.Action) == "" || strings.TrimSpace(event.Outcome) == "" {
+		return fmt.Errorf("action and outcome are required")
+	}
+
+	_, err := p.pool.Exec(
+		ctx,
+		`INSERT INTO audit_events (
+		   project_id, actor, action, target_type, target_id, request_id, outcome, status_code, method, path, client_address
+		 ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		firstNonEmptyText(event.ProjectID, GlobalAuditProjectID),
+		firstNonEmptyText(event.Actor, "anonymous"),
+		strings.TrimSpace(event.Action),
+		strings.TrimSpace(event.TargetType),
+		strings.TrimSpace(event.TargetID),
+		strings.TrimSpace(event.RequestID),
+		strings.TrimSpace(event.Outcome),
+		event.StatusCode,
+		event.Method,
+		event.Path,
+		strings.TrimSpace(event.ClientAddr),
+	)
+	return err
+}
+
+func (p *Postgres) ListAuditEvents(ctx context.Context, filter AuditEventFilter) ([]AuditEvent, error) {
+	limit := filter.Limit
+	if limit < 1 {
+		limit = 100
+	}
+	if limit > maxAuditEventLimit {
+		limit = maxAuditEventLimit
+	}
+
+	rows, err := p.pool.Query(
+		ctx,
+		`SELECT id, project_id, actor, action, target_type, target_id, request_id, outcome, status_code, method, path, client_address, created_at
+		 FROM audit_events
+		 WHERE project_id = $1
+		   AND ($2::text = '' OR action = $2::text OR action LIKE $2::text || '.%')
+		   AND ($3::text = '' OR actor = $3::text)
+		   AND ($4::text = '' OR target_id = $4::text)
+		   AND ($5::text = '' OR outcome = $5::text)
+		   AND ($6::timestamptz IS NULL OR created_at >= $6::timestamptz)
+		   AND ($7::timestamptz IS NULL OR created_at < $7::timestamptz)
+		   AND ($8::bigint = 0 OR id < $8::bigint)
+		 ORDER BY id DESC
+		 LIMIT $9`,
+		normalizeProjectID(filter.ProjectID),
+		strings.TrimSpace(filter.Action),
+		strings.TrimSpace(filter.Actor),
+		strings.TrimSpace(filter.TargetID),
+		strings.TrimSpace(filter.Outcome),
+		filter.Since,
+		filter.Until,
+		filter.BeforeID,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]AuditEvent, 0)
+	for rows.Next() {
+		event := AuditEvent{}
+		if err := rows.Scan(
+			&event.ID,
+			&event.ProjectID,
+			&event.Actor,
+			&event.Action,
+			&event.TargetType,
+			&event.TargetID,
+			&event.RequestID,
+			&event.Outcome,
+			&event.StatusCode,
+			&event.Method,
+			&event.Path,
+			&event.ClientAddr,
+			&event.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return events, nil
+}
+
+func firstNonEmptyText(values ...string) string {
+	for _, value := range values {
+		if trimmed := strings.TrimSpace(value); trimmed != "" {
+			return trimmed
+		}
+	}
+	return ""
+}
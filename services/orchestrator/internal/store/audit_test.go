@@ -0,0 +1,26 @@
+package store
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAuditEventsWithoutProjectAreListedInGlobalScope(t *testing.T) {
+	p := newTestPostgres(t)
+	ctx := context.Background()
+
+	if err := p.InsertAuditEvent(ctx, AuditEvent{Actor: "admin_api", Action: "config.reload", Outcome: "success", StatusCode: 200}); err != nil {
+		t.Fatalf("insert event: %v", err)
+	}
+
+	events, err := p.ListAuditEvents(ctx, AuditEventFilter{ProjectID: GlobalAuditProjectID, Action: "config.reload"})
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if len(events) != 1 || events[0].ProjectID != GlobalAuditProjectID {
+		t.Fatalf("expected config.reload in the global scope, got %+v", events)
+	}
+	if defaults, err := p.ListAuditEvents(ctx, AuditEventFilter{ProjectID: testProjectID}); err != nil || len(defaults) != 0 {
+		t.Fatalf("expected default project listing to stay empty, got %+v err=%v", defaults, err)
+	}
+}
@@ -0,0 +1,149 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const encryptionDataKeyColumns = `id, project_id, master_key_id, wrapped_key, status, created_at, rewrapped_at`
+
+func (p *Postgres) GetActiveEncryptionDataKey(ctx context.Context, projectID string) (EncryptionDataKey, error) {
+	row := p.pool.QueryRow(
+		ctx,
+		`SELECT `+encryptionDataKeyColumns+`
+		 FROM encryption_data_keys
+		 WHERE project_id = $1
+		   AND status = 'active'`,
+		normalizeProjectID(projectID),
+	)
+	return scanEncryptionDataKey(row)
+}
+
+func (p *Postgres) GetEncryptionDataKey(ctx context.Context, keyID string) (EncryptionDataKey, error) {
+	row := p.pool.QueryRow(
+		ctx,
+		`SELECT `+encryptionDataKeyColumns+`
+		 FROM encryption_data_keys
+		 WHERE id = $1`,
+		strings.TrimSpace(keyID),
+	)
+	return scanEncryptionDataKey(row)
+}
+
+func (p *Postgres) CreateEncryptionDataKey(ctx context.Context, key EncryptionDataKey) (EncryptionDataKey, error) {
+	key.ProjectID = normalizeProjectID(key.ProjectID)
+	key.ID = strings.TrimSpace(key.ID)
+	key.MasterKeyID = strings.TrimSpace(key.MasterKeyID)
+	if key.ID == "" || key.MasterKeyID == "" || len(key.WrappedKey) == 0 {
+		return EncryptionDataKey{}, fmt.Errorf("id, masterKeyId and wrappedKey are required")
+	}
+
+	row := p.pool.QueryRow(
+		ctx,
+		`INSERT INTO encryption_data_keys (id, project_id, master_key_id, wrapped_key, status)
+		 VALUES ($1, $2, $3, $4, 'active')
+		 ON CONFLICT (project_id) WHERE status = 'active' DO NOTHING
+		 RETURNING `+encryptionDataKeyColumns,
+		key.ID,
+		key.ProjectID,
+		key.MasterKeyID,
+		key.WrappedKey,
+	)
+	created, err := scanEncryptionDataKey(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return p.GetActiveEncryptionDataKey(ctx, key.ProjectID)
+	}
+	return created, err
+}
+
+func (p *Postgres) ListEncryptionDataKeysForRewrap(
+	ctx context.Context,
+	projectID string,
+	activeMasterKeyID string,
+	limit int,
+) ([]EncryptionDataKey, error) {
+	if limit < 1 {
+		limit = 100
+	}
+
+	rows, err := p.pool.Query(
+		ctx,
+		`SELECT `+encryptionDataKeyColumns+`
+		 FROM encryption_data_keys
+		 WHERE project_id = $1
+		   AND master_key_id <> $2
+		 ORDER BY created_at ASC
+		 LIMIT $3`,
+		normalizeProjectID(projectID),
+		strings.TrimSpace(activeMasterKeyID),
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]EncryptionDataKey, 0)
+	for rows.Next() {
+		key, err := scanEncryptionDataKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return keys, nil
+}
+
+func (p *Postgres) RewrapEncryptionDataKey(
+	ctx context.Context,
+	keyID string,
+	fromMasterKeyID string,
+	toMasterKeyID string,
+	wrappedKey []byte,
+) error {
+	commandTag, err := p.pool.Exec(
+		ctx,
+		`UPDATE encryption_data_keys
+		 SET master_key_id = $3,
+		     wrapped_key = $4,
+		     rewrapped_at = NOW()
+		 WHERE id = $1
+		   AND master_key_id = $2`,
+		strings.TrimSpace(keyID),
+		strings.TrimSpace(fromMasterKeyID),
+		strings.TrimSpace(toMasterKeyID),
+		wrappedKey,
+	)
+	if err != nil {
+		return err
+	}
+	if commandTag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func scanEncryptionDataKey(row pgx.Row) (EncryptionDataKey, error) {
+	key := EncryptionDataKey{}
+	err := row.Scan(
+		&key.ID,
+		&key.ProjectID,
+		&key.MasterKeyID,
+		&key.WrappedKey,
+		&key.Status,
+		&key.CreatedAt,
+		&key.RewrappedAt,
+	)
+	if err != nil {
+		return EncryptionDataKey{}, err
+	}
+	return key, nil
+}
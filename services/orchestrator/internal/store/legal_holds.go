@@ -0,0 +1,314 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	LegalHoldTargetSession      = "session"
+	LegalHoldTargetIssueCluster = "issue_cluster"
+)
+
+const legalHoldColumns = `id, project_id, target_type, target_id, reason, created_by, expires_at, created_at,
+	released_at, released_by,
+	(released_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())) AS active`
+
+// activeLegalHoldSessionFilter matches sessions (aliased s) that an unreleased,
+// unexpired hold covers directly or through one of their marker cluster keys.
+const activeLegalHoldSessionFilter = `EXISTS (
+	SELECT 1
+	FROM legal_holds lh
+	WHERE lh.project_id = s.project_id
+	  AND lh.released_at IS NULL
+	  AND (lh.expires_at IS NULL OR lh.expires_at > NOW())
+	  AND (
+	    (lh.target_type = 'session' AND lh.target_id = s.id)
+	    OR (
+	      lh.target_type = 'issue_cluster'
+	      AND EXISTS (
+	        SELECT 1
+	        FROM error_markers hem
+	        WHERE hem.session_id = s.id
+	          AND hem.cluster_key = lh.target_id
+	      )
+	    )
+	  )
+)`
+
+func (p *Postgres) PlaceLegalHold(
+	ctx context.Context,
+	projectID string,
+	targetType string,
+	targetID string,
+	reason string,
+	createdBy string,
+	expiresAt *time.Time,
+) (LegalHold, error) {
+	projectID = normalizeProjectID(projectID)
+	targetType = normalizeLegalHoldTargetType(targetType)
+	targetID = strings.TrimSpace(targetID)
+	reason = strings.TrimSpace(reason)
+	createdBy = strings.TrimSpace(createdBy)
+	if targetType == "" {
+		return LegalHold{}, fmt.Errorf("targetType must be session or issue_cluster")
+	}
+	if targetID == "" || reason == "" || createdBy == "" {
+		return LegalHold{}, fmt.Errorf("targetId, reason and createdBy are required")
+	}
+
+	row := p.pool.QueryRow(
+		ctx,
+		`INSERT INTO legal_holds (id, project_id, target_type, target_id, reason, created_by, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 RETURNING `+legalHoldColumns,
+		"hold_"+uuid.NewString(),
+		projectID,
+		targetType,
+		targetID,
+		reason,
+		createdBy,
+		expiresAt,
+	)
+	return scanLegalHold(row)
+}
+
+func (p *Postgres) ListLegalHolds(
+	ctx context.Context,
+	projectID string,
+	targetType string,
+	includeInactive bool,
+) ([]LegalHold, error) {
+	projectID = normalizeProjectID(projectID)
+	targetType = normalizeLegalHoldTargetType(targetType)
+
+	rows, err := p.pool.Query(
+		ctx,
+		`SELECT `+legalHoldColumns+`
+		 FROM legal_holds
+		 WHERE project_id = $1
+		   AND ($2::text = '' OR target_type = $2::text)
+		   AND (
+		     $3::bool
+		     OR (released_at IS NULL AND (expires_at IS NULL OR expires_at > NOW()))
+		   )
+		 ORDER BY created_at DESC`,
+		projectID,
+		targetType,
+		includeInactive,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	holds := make([]LegalHold, 0)
+	for rows.Next() {
+		hold, err := scanLegalHold(rows)
+		if err != nil {
+			return nil, err
+		}
+		holds = append(holds, hold)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return holds, nil
+}
+
+func (p *Postgres) ReleaseLegalHold(
+	ctx context.Context,
+	projectID string,
+	holdID string,
+	releasedBy string,
+) (LegalHold, error) {
+	projectID = normalizeProjectID(projectID)
+	releasedBy = strings.TrimSpace(releasedBy)
+	if releasedBy == "" {
+		return LegalHold{}, fmt.Errorf("releasedBy is required")
+	}
+
+	row := p.pool.QueryRow(
+		ctx,
+		`UPDATE legal_holds
+		 SET released_at = COALESCE(released_at, NOW()),
+		     released_by = CASE WHEN released_at IS NULL THEN $3 ELSE released_by END
+		 WHERE project_id = $1
+		   AND id = $2
+		 RETURNING `+legalHoldColumns,
+		projectID,
+		strings.TrimSpace(holdID),
+		releasedBy,
+	)
+	return scanLegalHold(row)
+}
+
+func (p *Postgres) ListLegalHoldSessionIDs(ctx context.Context, hold LegalHold) ([]string, error) {
+	projectID := normalizeProjectID(hold.ProjectID)
+
+	sessionIDs := []string{}
+	err := p.pool.QueryRow(
+		ctx,
+		`SELECT COALESCE(array_agg(DISTINCT s.id), '{}'::text[])
+		 FROM sessions s
+		 WHERE s.project_id = $1
+		   AND (
+		     ($2::text = 'session' AND s.id = $3)
+		     OR (
+		       $2::text = 'issue_cluster'
+		       AND EXISTS (
+		         SELECT 1
+		         FROM error_markers em
+		         WHERE em.session_id = s.id
+		           AND em.cluster_key = $3
+		       )
+		     )
+		   )`,
+		projectID,
+		hold.TargetType,
+		hold.TargetID,
+	).Scan(&sessionIDs)
+	if err != nil {
+		return nil, err
+	}
+	return sessionIDs, nil
+}
+
+func (p *Postgres) SessionUnderLegalHold(ctx context.Context, projectID, sessionID string) (bool, error) {
+	held := false
+	err := p.pool.QueryRow(
+		ctx,
+		`SELECT EXISTS (
+		   SELECT 1
+		   FROM sessions s
+		   WHERE s.project_id = $1
+		     AND s.id = $2
+		     AND `+activeLegalHoldSessionFilter+`
+		 )`,
+		normalizeProjectID(projectID),
+		strings.TrimSpace(sessionID),
+	).Scan(&held)
+	if err != nil {
+		return false, err
+	}
+	return held, nil
+}
+
+// RetentionTagDrift is a session whose objects may not carry the retention tag
+// its hold state calls for: never tagged, or held and released since.
+type RetentionTagDrift struct {
+	ProjectID    string
+	SessionID    string
+	ObjectKeys   []string
+	Held         bool
+	RetentionTag string
+}
+
+// ListRetentionTagDrift returns up to limit sessions whose recorded retention
+// tag differs from their current hold state. Holds that lapse through
+// expires_at show up here without any release call.
+func (p *Postgres) ListRetentionTagDrift(ctx context.Context, limit int) ([]RetentionTagDrift, error) {
+	if limit < 1 {
+		limit = 500
+	}
+
+	rows, err := p.pool.Query(
+		ctx,
+		`SELECT project_id, id, object_keys, held, COALESCE(retention_tag, '')
+		 FROM (
+		   SELECT s.project_id,
+		          s.id,
+		          s.retention_tag,
+		          s.created_at,
+		          array_remove(
+		            array_prepend(
+		              NULLIF(s.events_object_key, ''),
+		              COALESCE((
+		                SELECT array_agg(sa.artifact_key)
+		                FROM session_artifacts sa
+		                WHERE sa.project_id = s.project_id
+		                  AND sa.session_id = s.id
+		                  AND sa.artifact_key <> ''
+		              ), '{}'::text[])
+		            ),
+		            NULL
+		          ) AS object_keys,
+		          `+activeLegalHoldSessionFilter+` AS held
+		   FROM sessions s
+		 ) tagged
+		 WHERE retention_tag IS DISTINCT FROM CASE WHEN held THEN 'hold' ELSE 'expire' END
+		 ORDER BY created_at
+		 LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	drift := make([]RetentionTagDrift, 0)
+	for rows.Next() {
+		entry := RetentionTagDrift{}
+		if err := rows.Scan(&entry.ProjectID, &entry.SessionID, &entry.ObjectKeys, &entry.Held, &entry.RetentionTag); err != nil {
+			return nil, err
+		}
+		drift = append(drift, entry)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return drift, nil
+}
+
+// MarkSessionRetentionTag records the tag applied to all of a session's
+// objects. An empty tag marks the session unknown so the next sync retries it.
+func (p *Postgres) MarkSessionRetentionTag(ctx context.Context, projectID, sessionID, tag string) error {
+	_, err := p.pool.Exec(
+		ctx,
+		`UPDATE sessions
+		 SET retention_tag = NULLIF($3, '')
+		 WHERE project_id = $1
+		   AND id = $2`,
+		normalizeProjectID(projectID),
+		strings.TrimSpace(sessionID),
+		strings.TrimSpace(tag),
+	)
+	return err
+}
+
+func scanLegalHold(row pgx.Row) (LegalHold, error) {
+	hold := LegalHold{}
+	err := row.Scan(
+		&hold.ID,
+		&hold.ProjectID,
+		&hold.TargetType,
+		&hold.TargetID,
+		&hold.Reason,
+		&hold.CreatedBy,
+		&hold.ExpiresAt,
+		&hold.CreatedAt,
+		&hold.ReleasedAt,
+		&hold.ReleasedBy,
+		&hold.Active,
+	)
+	if err != nil {
+		return LegalHold{}, err
+	}
+	return hold, nil
+}
+
+func normalizeLegalHoldTargetType(targetType string) string {
+	switch strings.TrimSpace(targetType) {
+	case LegalHoldTargetSession, LegalHoldTargetIssueCluster:
+		return strings.TrimSpace(targetType)
+	default:
+		return ""
+	}
+}
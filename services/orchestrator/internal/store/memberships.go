@@ -0,0 +1,349 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	RoleViewer  = "viewer"
+	RoleTriager = "triager"
+	RoleAdmin   = "admin"
+)
+
+var ErrLastProjectAdmin = errors.New("project must keep at least one active admin")
+
+const projectMembershipColumns = `pm.project_id, pm.user_id, u.email, u.display_name, pm.role, pm.status,
+	pm.invited_by, pm.created_at, pm.accepted_at`
+
+func RoleScopes(role string) []string {
+	switch role {
+	case RoleViewer:
+		return []string{APIKeyScopeRead}
+	case RoleTriager:
+		return []string{APIKeyScopeRead, APIKeyScopeTriage}
+	case RoleAdmin:
+		return AllAPIKeyScopes
+	default:
+		return nil
+	}
+}
+
+func NormalizeRole(role string) string {
+	role = strings.ToLower(strings.TrimSpace(role))
+	if RoleScopes(role) == nil {
+		return ""
+	}
+	return role
+}
+
+// ResolveUserForSubject maps a verified bearer identity to a user row. The
+// first login links an invited user by email and activates their invitations,
+// but only when the identity provider has verified that email; otherwise any
+// account asserting the address could claim the invitation.
+func (p *Postgres) ResolveUserForSubject(ctx context.Context, subject, email string, emailVerified bool) (User, error) {
+	subject = strings.TrimSpace(subject)
+	email = strings.TrimSpace(email)
+	if !emailVerified {
+		email = ""
+	}
+	if subject == "" {
+		return User{}, fmt.Errorf("subject is required")
+	}
+
+	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return User{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	user, err := scanUser(tx.QueryRow(
+		ctx,
+		`UPDATE users
+		 SET last_seen_at = NOW()
+		 WHERE external_subject = $1
+		 RETURNING id, email, display_name, COALESCE(external_subject, ''), created_at, last_seen_at`,
+		subject,
+	))
+	if errors.Is(err, pgx.ErrNoRows) && email != "" {
+		user, err = scanUser(tx.QueryRow(
+			ctx,
+			`UPDATE users
+			 SET external_subject = $2,
+			     last_seen_at = NOW()
+			 WHERE LOWER(email) = LOWER($1)
+			   AND external_subject IS NULL
+			 RETURNING id, email, display_name, COALESCE(external_subject, ''), created_at, last_seen_at`,
+			email,
+			subject,
+		))
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return User{}, err
+	}
+	if err != nil {
+		return User{}, err
+	}
+
+	if _, err := tx.Exec(
+		ctx,
+		`UPDATE project_memberships
+		 SET status = 'active',
+		     accepted_at = NOW()
+		 WHERE user_id = $1
+		   AND status = 'invited'`,
+		user.ID,
+	); err != nil {
+		return User{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return User{}, err
+	}
+	return user, nil
+}
+
+func (p *Postgres) GetProjectMembership(ctx context.Context, projectID, userID string) (ProjectMembership, error) {
+	row := p.pool.QueryRow(
+		ctx,
+		`SELECT `+projectMembershipColumns+`
+		 FROM project_memberships pm
+		 JOIN users u ON u.id = pm.user_id
+		 WHERE pm.project_id = $1
+		   AND pm.user_id = $2`,
+		normalizeProjectID(projectID),
+		strings.TrimSpace(userID),
+	)
+	return scanProjectMembership(row)
+}
+
+func (p *Postgres) ListUserProjectIDs(ctx context.Context, userID string) ([]string, error) {
+	projectIDs := []string{}
+	err := p.pool.QueryRow(
+		ctx,
+		`SELECT COALESCE(array_agg(project_id ORDER BY project_id), '{}'::text[])
+		 FROM project_memberships
+		 WHERE user_id = $1
+		   AND status = 'active'`,
+		strings.TrimSpace(userID),
+	).Scan(&projectIDs)
+	if err != nil {
+		return nil, err
+	}
+	return projectIDs, nil
+}
+
+func (p *Postgres) ListProjectMemberships(ctx context.Context, projectID string) ([]ProjectMembership, error) {
+	rows, err := p.pool.Query(
+		ctx,
+		`SELECT `+projectMembershipColumns+`
+		 FROM project_memberships pm
+		 JOIN users u ON u.id = pm.user_id
+		 WHERE pm.project_id = $1
+		 ORDER BY pm.created_at ASC`,
+		normalizeProjectID(projectID),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	memberships := make([]ProjectMembership, 0)
+	for rows.Next() {
+		membership, err := scanProjectMembership(rows)
+		if err != nil {
+			return nil, err
+		}
+		memberships = append(memberships, membership)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return memberships, nil
+}
+
+// InviteProjectMember creates the user on first invite and upserts the
+// membership, so re-inviting an existing member changes their role.
+func (p *Postgres) InviteProjectMember(
+	ctx context.Context,
+	projectID string,
+	email string,
+	displayName string,
+	role string,
+	invitedBy string,
+) (ProjectMembership, error) {
+	projectID = normalizeProjectID(projectID)
+	email = strings.TrimSpace(email)
+	role = NormalizeRole(role)
+	if email == "" || !strings.Contains(email, "@") {
+		return ProjectMembership{}, fmt.Errorf("a valid email is required")
+	}
+	if role == "" {
+		return ProjectMembership{}, fmt.Errorf("role must be one of: viewer, triager, admin")
+	}
+
+	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return ProjectMembership{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	userID := ""
+	err = tx.QueryRow(
+		ctx,
+		`INSERT INTO users (id, email, display_name)
+		 VALUES ($1, $2, $3)
+		 ON CONFLICT ((LOWER(email))) DO UPDATE
+		 SET display_name = CASE WHEN EXCLUDED.display_name <> '' THEN EXCLUDED.display_name ELSE users.display_name END
+		 RETURNING id`,
+		"user_"+uuid.NewString(),
+		email,
+		strings.TrimSpace(displayName),
+	).Scan(&userID)
+	if err != nil {
+		return ProjectMembership{}, err
+	}
+
+	if role != RoleAdmin {
+		if err := ensureOtherActiveAdmin(ctx, tx, projectID, userID); err != nil {
+			return ProjectMembership{}, err
+		}
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`INSERT INTO project_memberships (project_id, user_id, role, status, invited_by, accepted_at)
+		 SELECT $1, u.id, $3,
+		        CASE WHEN u.external_subject IS NULL THEN 'invited' ELSE 'active' END,
+		        $4,
+		        CASE WHEN u.external_subject IS NULL THEN NULL ELSE NOW() END
+		 FROM users u
+		 WHERE u.id = $2
+		 ON CONFLICT (project_id, user_id) DO UPDATE
+		 SET role = EXCLUDED.role`,
+		projectID,
+		userID,
+		role,
+		strings.TrimSpace(invitedBy),
+	)
+	if err != nil {
+		return ProjectMembership{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return ProjectMembership{}, err
+	}
+	return p.GetProjectMembership(ctx, projectID, userID)
+}
+
+func (p *Postgres) RemoveProjectMember(ctx context.Context, projectID, userID string) (ProjectMembership, error) {
+	projectID = normalizeProjectID(projectID)
+	userID = strings.TrimSpace(userID)
+
+	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return ProjectMembership{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	membership, err := scanProjectMembership(tx.QueryRow(
+		ctx,
+		`SELECT `+projectMembershipColumns+`
+		 FROM project_memberships pm
+		 JOIN users u ON u.id = pm.user_id
+		 WHERE pm.project_id = $1
+		   AND pm.user_id = $2
+		 FOR UPDATE OF pm`,
+		projectID,
+		userID,
+	))
+	if err != nil {
+		return ProjectMembership{}, err
+	}
+	if err := ensureOtherActiveAdmin(ctx, tx, projectID, userID); err != nil {
+		return ProjectMembership{}, err
+	}
+
+	if _, err := tx.Exec(
+		ctx,
+		`DELETE FROM project_memberships WHERE project_id = $1 AND user_id = $2`,
+		projectID,
+		userID,
+	); err != nil {
+		return ProjectMembership{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return ProjectMembership{}, err
+	}
+	return membership, nil
+}
+
+// ensureOtherActiveAdmin blocks demoting or removing userID when they are the
+// project's only active admin; projects without member admins are unaffected.
+func ensureOtherActiveAdmin(ctx context.Context, tx pgx.Tx, projectID, userID string) error {
+	var isAdmin bool
+	var otherAdmins int
+	err := tx.QueryRow(
+		ctx,
+		`SELECT
+		   EXISTS (
+		     SELECT 1 FROM project_memberships
+		     WHERE project_id = $1 AND user_id = $2 AND role = 'admin' AND status = 'active'
+		   ),
+		   (
+		     SELECT COUNT(*) FROM project_memberships
+		     WHERE project_id = $1 AND user_id <> $2 AND role = 'admin' AND status = 'active'
+		   )`,
+		projectID,
+		userID,
+	).Scan(&isAdmin, &otherAdmins)
+	if err != nil {
+		return err
+	}
+	if isAdmin && otherAdmins == 0 {
+		return ErrLastProjectAdmin
+	}
+	return nil
+}
+
+func scanUser(row pgx.Row) (User, error) {
+	user := User{}
+	err := row.Scan(
+		&user.ID,
+		&user.Email,
+		&user.DisplayName,
+		&user.ExternalSubject,
+		&user.CreatedAt,
+		&user.LastSeenAt,
+	)
+	if err != nil {
+		return User{}, err
+	}
+	return user, nil
+}
+
+func scanProjectMembership(row pgx.Row) (ProjectMembership, error) {
+	membership := ProjectMembership{}
+	err := row.Scan(
+		&membership.ProjectID,
+		&membership.UserID,
+		&membership.Email,
+		&membership.DisplayName,
+		&membership.Role,
+		&membership.Status,
+		&membership.InvitedBy,
+		&membership.CreatedAt,
+		&membership.AcceptedAt,
+	)
+	if err != nil {
+		return ProjectMembership{}, err
+	}
+	return membership, nil
+}
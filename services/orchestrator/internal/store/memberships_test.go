@@ -0,0 +1,55 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestRoleScopesSeparateViewersFromTriageAndAdmin(t *testing.T) {
+	viewer := RoleScopes(RoleViewer)
+	if !HasAPIKeyScope(viewer, APIKeyScopeRead) || HasAPIKeyScope(viewer, APIKeyScopeTriage) || HasAPIKeyScope(viewer, APIKeyScopeAdmin) {
+		t.Fatalf("unexpected viewer scopes: %v", viewer)
+	}
+
+	triager := RoleScopes(RoleTriager)
+	if !HasAPIKeyScope(triager, APIKeyScopeTriage) || HasAPIKeyScope(triager, APIKeyScopeAdmin) {
+		t.Fatalf("unexpected triager scopes: %v", triager)
+	}
+
+	if !HasAPIKeyScope(RoleScopes(RoleAdmin), APIKeyScopeAdmin) {
+		t.Fatal("expected admin role to carry admin scope")
+	}
+	if NormalizeRole("owner") != "" || NormalizeRole(" Viewer ") != RoleViewer {
+		t.Fatal("unexpected role normalization")
+	}
+}
+
+func TestResolveUserForSubjectLinksInvitesOnlyByVerifiedEmail(t *testing.T) {
+	p := newTestPostgres(t)
+	ctx := context.Background()
+
+	invited, err := p.InviteProjectMember(ctx, testProjectID, "victim@example.com", "", RoleAdmin, "admin_api")
+	if err != nil {
+		t.Fatalf("invite member: %v", err)
+	}
+
+	if _, err := p.ResolveUserForSubject(ctx, "attacker", "victim@example.com", false); !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("expected unverified email not to link the invitation, got %v", err)
+	}
+	membership, err := p.GetProjectMembership(ctx, testProjectID, invited.UserID)
+	if err != nil || membership.Status != "invited" {
+		t.Fatalf("expected invitation to stay pending, got %+v err=%v", membership, err)
+	}
+
+	user, err := p.ResolveUserForSubject(ctx, "victim", "Victim@example.com", true)
+	if err != nil || user.ID != invited.UserID {
+		t.Fatalf("expected verified email to link the invited user, got %+v err=%v", user, err)
+	}
+	membership, err = p.GetProjectMembership(ctx, testProjectID, invited.UserID)
+	if err != nil || membership.Status != "active" {
+		t.Fatalf("expected invitation to activate, got %+v err=%v", membership, err)
+	}
+}
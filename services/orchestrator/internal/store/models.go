@@ -3,17 +3,19 @@ package store
 import "time"
 
 type Session struct {
-	ID              string             `json:"id"`
-	ProjectID       string             `json:"projectId"`
-	Site            string             `json:"site"`
-	Route           string             `json:"route"`
-	StartedAt       time.Time          `json:"startedAt"`
-	DurationMs      int                `json:"durationMs"`
-	EventsObjectKey string             `json:"eventsObjectKey"`
-	CreatedAt       time.Time          `json:"createdAt"`
-	Markers         []ErrorMark        `json:"markers"`
-	Artifacts       []SessionArtifact  `json:"artifacts"`
-	ReportCard      *SessionReportCard `json:"reportCard,omitempty"`
+	ID              string                  `json:"id"`
+	ProjectID       string                  `json:"projectId"`
+	UserID          string                  `json:"userId,omitempty"`
+	Site            string                  `json:"site"`
+	Route           string                  `json:"route"`
+	StartedAt       time.Time               `json:"startedAt"`
+	DurationMs      int                     `json:"durationMs"`
+	EventsObjectKey string                  `json:"eventsObjectKey"`
+	CreatedAt       time.Time               `json:"createdAt"`
+	Markers         []ErrorMark             `json:"markers"`
+	Artifacts       []SessionArtifact       `json:"artifacts"`
+	ReportCard      *SessionReportCard      `json:"reportCard,omitempty"`
+	Pipeline        *SessionPipelineTimings `json:"pipeline,omitempty"`
 }
 
 type ErrorMark struct {
@@ -111,6 +113,7 @@ type IngestPayload struct {
 
 type SessionInput struct {
 	ID              string    `json:"id"`
+	UserID          string    `json:"userId"`
 	Site            string    `json:"site"`
 	Route           string    `json:"route"`
 	StartedAt       time.Time `json:"startedAt"`
@@ -132,19 +135,38 @@ type PromoteResult struct {
 }
 
 type Project struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Site      string    `json:"site"`
-	CreatedAt time.Time `json:"createdAt"`
+	ID             string               `json:"id"`
+	Name           string               `json:"name"`
+	Site           string               `json:"site"`
+	AllowedOrigins []string             `json:"allowedOrigins"`
+	RateLimits     map[string]RateLimit `json:"rateLimits"`
+	Tier           string               `json:"tier"`
+	CreatedAt      time.Time            `json:"createdAt"`
+}
+
+type RateLimit struct {
+	RequestsPerSec float64 `json:"requestsPerSec"`
+	Burst          int     `json:"burst"`
 }
 
 type ProjectAPIKey struct {
-	ID         string     `json:"id"`
-	ProjectID  string     `json:"projectId"`
-	Label      string     `json:"label"`
-	Status     string     `json:"status"`
-	CreatedAt  time.Time  `json:"createdAt"`
-	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	ID              string     `json:"id"`
+	ProjectID       string     `json:"projectId"`
+	Label           string     `json:"label"`
+	Status          string     `json:"status"`
+	Scopes          []string   `json:"scopes"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	LastUsedAt      *time.Time `json:"lastUsedAt,omitempty"`
+	ExpiresAt       *time.Time `json:"expiresAt,omitempty"`
+	Expired         bool       `json:"expired"`
+	RotatedToKeyID  string     `json:"rotatedToKeyId,omitempty"`
+	FlaggedUnusedAt *time.Time `json:"flaggedUnusedAt,omitempty"`
+}
+
+type ResolvedAPIKey struct {
+	ID        string
+	ProjectID string
+	Scopes    []string
 }
 
 type ArtifactWindow struct {
@@ -182,6 +204,33 @@ type SessionReportCard struct {
 	UpdatedAt          time.Time `json:"updatedAt"`
 }
 
+// SessionPipelineTimings holds the first time a session reached each stage;
+// nil stages have not been reached.
+type SessionPipelineTimings struct {
+	IngestedAt         time.Time  `json:"ingestedAt"`
+	AnalysisEnqueuedAt *time.Time `json:"analysisEnqueuedAt"`
+	TextReportedAt     *time.Time `json:"textReportedAt"`
+	ReplayEnqueuedAt   *time.Time `json:"replayEnqueuedAt"`
+	ReplayReportedAt   *time.Time `json:"replayReportedAt"`
+	VerdictAt          *time.Time `json:"verdictAt"`
+}
+
+type PipelineStageLatency struct {
+	Stage string  `json:"stage"`
+	Count int     `json:"count"`
+	P50Ms float64 `json:"p50Ms"`
+	P90Ms float64 `json:"p90Ms"`
+	P95Ms float64 `json:"p95Ms"`
+	P99Ms float64 `json:"p99Ms"`
+}
+
+type PipelineLatencyReport struct {
+	Since           time.Time              `json:"since"`
+	Sessions        int                    `json:"sessions"`
+	AwaitingVerdict int                    `json:"awaitingVerdict"`
+	Stages          []PipelineStageLatency `json:"stages"`
+}
+
 type CleanupResult struct {
 	DeletedSessions            int      `json:"deletedSessions"`
 	DeletedIssueClusters       int      `json:"deletedIssueClusters"`
@@ -191,5 +240,129 @@ type CleanupResult struct {
 	DeletedArtifactObjectKeys  []string `json:"-"`
 	FailedEventObjectDelete    int      `json:"failedEventObjectDelete"`
 	FailedArtifactObjectDelete int      `json:"failedArtifactObjectDelete"`
+	HeldSessions               int      `json:"heldSessions"`
 	RetentionDays              int      `json:"retentionDays"`
 }
+
+type PrivacyErasureJob struct {
+	ID                        string     `json:"id"`
+	ProjectID                 string     `json:"projectId"`
+	SubjectUserID             string     `json:"subjectUserId,omitempty"`
+	RequestedSessionIDs       []string   `json:"requestedSessionIds"`
+	Status                    string     `json:"status"`
+	RequestedBy               string     `json:"requestedBy"`
+	ErasedSessionIDs          []string   `json:"erasedSessionIds"`
+	DeletedSessions           int        `json:"deletedSessions"`
+	DeletedMarkers            int        `json:"deletedMarkers"`
+	DeletedReportCards        int        `json:"deletedReportCards"`
+	DeletedArtifacts          int        `json:"deletedArtifacts"`
+	DeletedFeedbackEvents     int        `json:"deletedFeedbackEvents"`
+	DeletedEventObjectKeys    []string   `json:"deletedEventObjectKeys"`
+	DeletedArtifactObjectKeys []string   `json:"deletedArtifactObjectKeys"`
+	FailedObjectKeys          []string   `json:"failedObjectKeys"`
+	AffectedClusterKeys       []string   `json:"affectedClusterKeys"`
+	HeldSessionIDs            []string   `json:"heldSessionIds"`
+	Error                     string     `json:"error,omitempty"`
+	ReceiptDigest             string     `json:"receiptDigest"`
+	ReceiptKeyID              string     `json:"receiptKeyId"`
+	CreatedAt                 time.Time  `json:"createdAt"`
+	CompletedAt               *time.Time `json:"completedAt,omitempty"`
+}
+
+type LegalHold struct {
+	ID         string     `json:"id"`
+	ProjectID  string     `json:"projectId"`
+	TargetType string     `json:"targetType"`
+	TargetID   string     `json:"targetId"`
+	Reason     string     `json:"reason"`
+	CreatedBy  string     `json:"createdBy"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ReleasedAt *time.Time `json:"releasedAt,omitempty"`
+	ReleasedBy string     `json:"releasedBy,omitempty"`
+	Active     bool       `json:"active"`
+}
+
+type EncryptionDataKey struct {
+	ID          string     `json:"id"`
+	ProjectID   string     `json:"projectId"`
+	MasterKeyID string     `json:"masterKeyId"`
+	WrappedKey  []byte     `json:"-"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	RewrappedAt *time.Time `json:"rewrappedAt,omitempty"`
+}
+
+type User struct {
+	ID              string     `json:"id"`
+	Email           string     `json:"email"`
+	DisplayName     string     `json:"displayName,omitempty"`
+	ExternalSubject string     `json:"-"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	LastSeenAt      *time.Time `json:"lastSeenAt,omitempty"`
+}
+
+type ProjectMembership struct {
+	ProjectID   string     `json:"projectId"`
+	UserID      string     `json:"userId"`
+	Email       string     `json:"email"`
+	DisplayName string     `json:"displayName,omitempty"`
+	Role        string     `json:"role"`
+	Status      string     `json:"status"`
+	InvitedBy   string     `json:"invitedBy,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	AcceptedAt  *time.Time `json:"acceptedAt,omitempty"`
+}
+
+type AuditEvent struct {
+	ID         int64     `json:"id"`
+	ProjectID  string    `json:"projectId"`
+	Actor      string    `json:"actor"`
+	Action     string    `json:"action"`
+	TargetType string    `json:"targetType,omitempty"`
+	TargetID   string    `json:"targetId,omitempty"`
+	RequestID  string    `json:"requestId,omitempty"`
+	Outcome    string    `json:"outcome"`
+	StatusCode int       `json:"statusCode"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	ClientAddr string    `json:"clientAddress,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type AuditEventFilter struct {
+	ProjectID string
+	Action    string
+	Actor     string
+	TargetID  string
+	Outcome   string
+	Since     *time.Time
+	Until     *time.Time
+	BeforeID  int64
+	Limit     int
+}
+
+type ArtifactTokenRevocation struct {
+	TokenID   string    `json:"tokenId"`
+	ProjectID string    `json:"projectId"`
+	Reason    string    `json:"reason,omitempty"`
+	RevokedBy string    `json:"revokedBy,omitempty"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	RevokedAt time.Time `json:"revokedAt"`
+}
+
+type SessionShareLink struct {
+	ID                string     `json:"id"`
+	ProjectID         string     `json:"projectId"`
+	SessionID         string     `json:"sessionId"`
+	PasswordHash      string     `json:"-"`
+	PasswordProtected bool       `json:"passwordProtected"`
+	CreatedBy         string     `json:"createdBy,omitempty"`
+	ExpiresAt         time.Time  `json:"expiresAt"`
+	RevokedAt         *time.Time `json:"revokedAt,omitempty"`
+	RevokedBy         string     `json:"revokedBy,omitempty"`
+	ViewCount         int64      `json:"viewCount"`
+	LastViewedAt      *time.Time `json:"lastViewedAt,omitempty"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	Active            bool       `json:"active"`
+}
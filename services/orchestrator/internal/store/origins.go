@@ -0,0 +1,150 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const projectColumns = `id, name, site, allowed_origins, rate_limits, tier, created_at`
+
+const maxAllowedOrigins = 50
+
+func scanProject(row pgx.Row) (Project, error) {
+	project := Project{}
+	if err := row.Scan(&project.ID, &project.Name, &project.Site, &project.AllowedOrigins, &project.RateLimits, &project.Tier, &project.CreatedAt); err != nil {
+		return Project{}, err
+	}
+	if project.AllowedOrigins == nil {
+		project.AllowedOrigins = []string{}
+	}
+	if project.RateLimits == nil {
+		project.RateLimits = map[string]RateLimit{}
+	}
+	return project, nil
+}
+
+func (p *Postgres) UpdateProjectAllowedOrigins(ctx context.Context, projectID string, origins []string) (Project, error) {
+	origins, err := NormalizeAllowedOrigins(origins)
+	if err != nil {
+		return Project{}, err
+	}
+
+	return scanProject(p.pool.QueryRow(
+		ctx,
+		`UPDATE projects
+		 SET allowed_origins = $2::text[]
+		 WHERE id = $1
+		 RETURNING `+projectColumns,
+		strings.TrimSpace(projectID),
+		origins,
+	))
+}
+
+// ListProjectAllowedOrigins returns the origin allowlist of every project that
+// restricts origins; projects with an empty list are omitted.
+func (p *Postgres) ListProjectAllowedOrigins(ctx context.Context) (map[string][]string, error) {
+	rows, err := p.pool.Query(
+		ctx,
+		`SELECT id, allowed_origins
+		 FROM projects
+		 WHERE cardinality(allowed_origins) > 0`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	origins := map[string][]string{}
+	for rows.Next() {
+		var projectID string
+		var allowed []string
+		if err := rows.Scan(&projectID, &allowed); err != nil {
+			return nil, err
+		}
+		origins[projectID] = allowed
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return origins, nil
+}
+
+// DefaultAllowedOrigins derives the initial allowlist from a project's site,
+// assuming https when the site has no scheme and dropping any path, query or
+// fragment so the result matches browser Origin headers.
+func DefaultAllowedOrigins(site string) []string {
+	site = strings.TrimRight(strings.ToLower(strings.TrimSpace(site)), "/")
+	if site == "" {
+		return nil
+	}
+	if !strings.HasPrefix(site, "http://") && !strings.HasPrefix(site, "https://") {
+		site = "https://" + site
+	}
+	parsed, err := url.Parse(site)
+	if err != nil || parsed.Host == "" {
+		return []string{site}
+	}
+	return []string{parsed.Scheme + "://" + parsed.Host}
+}
+
+// NormalizeAllowedOrigins validates scheme://host[:port] origins. A single
+// leading "*." label wildcard is allowed in the host, e.g. https://*.example.com.
+func NormalizeAllowedOrigins(origins []string) ([]string, error) {
+	if len(origins) > maxAllowedOrigins {
+		return nil, fmt.Errorf("at most %d allowed origins are supported", maxAllowedOrigins)
+	}
+
+	seen := map[string]struct{}{}
+	normalized := make([]string, 0, len(origins))
+	for _, origin := range origins {
+		origin = strings.TrimRight(strings.ToLower(strings.TrimSpace(origin)), "/")
+		if origin == "" {
+			continue
+		}
+
+		parsed, err := url.Parse(strings.Replace(origin, "://*.", "://wildcard.", 1))
+		if err != nil ||
+			(parsed.Scheme != "http" && parsed.Scheme != "https") ||
+			parsed.Host == "" ||
+			parsed.Path != "" ||
+			parsed.RawQuery != "" ||
+			parsed.Fragment != "" ||
+			parsed.User != nil ||
+			strings.Contains(strings.TrimPrefix(origin, parsed.Scheme+"://*."), "*") {
+			return nil, fmt.Errorf("invalid allowed origin %q", origin)
+		}
+
+		if _, exists := seen[origin]; exists {
+			continue
+		}
+		seen[origin] = struct{}{}
+		normalized = append(normalized, origin)
+	}
+	return normalized, nil
+}
+
+// OriginAllowed reports whether origin matches one of the normalized patterns.
+func OriginAllowed(patterns []string, origin string) bool {
+	origin = strings.TrimRight(strings.ToLower(strings.TrimSpace(origin)), "/")
+	if origin == "" {
+		return false
+	}
+	for _, pattern := range patterns {
+		pattern = strings.TrimRight(strings.ToLower(strings.TrimSpace(pattern)), "/")
+		if pattern == "*" || pattern == origin {
+			return true
+		}
+		prefix, suffix, wildcard := strings.Cut(pattern, "*")
+		if wildcard && len(origin) > len(prefix)+len(suffix) &&
+			strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) &&
+			!strings.Contains(origin[len(prefix):len(origin)-len(suffix)], "/") {
+			return true
+		}
+	}
+	return false
+}
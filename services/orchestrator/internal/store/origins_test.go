@@ -0,0 +1,62 @@
+package store
+
+import "testing"
+
+func TestNormalizeAllowedOriginsValidatesOrigins(t *testing.T) {
+	origins, err := NormalizeAllowedOrigins([]string{" https://Shop.Example.com/ ", "https://*.example.com", "https://shop.example.com", ""})
+	if err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	if len(origins) != 2 || origins[0] != "https://shop.example.com" || origins[1] != "https://*.example.com" {
+		t.Fatalf("unexpected origins: %v", origins)
+	}
+
+	for _, invalid := range []string{"*", "shop.example.com", "ftp://shop.example.com", "https://shop.example.com/path", "https://a.*.example.com"} {
+		if _, err := NormalizeAllowedOrigins([]string{invalid}); err == nil {
+			t.Fatalf("expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestDefaultAllowedOriginsReducesSiteToOrigin(t *testing.T) {
+	for site, expected := range map[string]string{
+		"https://Shop.Example.com/":             "https://shop.example.com",
+		"https://shop.example.com/app/?ref=ads": "https://shop.example.com",
+		"http://localhost:3000/checkout#cart":   "http://localhost:3000",
+		"shop.example.com/store":                "https://shop.example.com",
+	} {
+		origins := DefaultAllowedOrigins(site)
+		if len(origins) != 1 || origins[0] != expected {
+			t.Fatalf("site %q: expected %q, got %v", site, expected, origins)
+		}
+		if _, err := NormalizeAllowedOrigins(origins); err != nil {
+			t.Fatalf("site %q: expected seeded origin to validate: %v", site, err)
+		}
+	}
+	if origins := DefaultAllowedOrigins("  "); origins != nil {
+		t.Fatalf("expected empty site to seed nothing, got %v", origins)
+	}
+}
+
+func TestOriginAllowedMatchesExactAndWildcardOrigins(t *testing.T) {
+	patterns := []string{"https://shop.example.com", "https://*.example.org"}
+
+	for origin, expected := range map[string]bool{
+		"https://shop.example.com":      true,
+		"https://SHOP.example.com":      true,
+		"http://shop.example.com":       false,
+		"https://evil.example.com":      false,
+		"https://eu.app.example.org":    true,
+		"https://example.org":           false,
+		"https://evil.com/.example.org": false,
+		"":                              false,
+	} {
+		if OriginAllowed(patterns, origin) != expected {
+			t.Fatalf("origin %q: expected %v", origin, expected)
+		}
+	}
+
+	if DefaultAllowedOrigins("shop.example.com")[0] != "https://shop.example.com" {
+		t.Fatalf("unexpected default origins: %v", DefaultAllowedOrigins("shop.example.com"))
+	}
+}
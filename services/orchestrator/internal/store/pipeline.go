@@ -0,0 +1,197 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	PipelineStageIngested        = "ingested"
+	PipelineStageAnalysisEnqueue = "analysis_enqueued"
+	PipelineStageTextReported    = "text_reported"
+	PipelineStageReplayEnqueued  = "replay_enqueued"
+	PipelineStageReplayReported  = "replay_reported"
+	PipelineStageVerdict         = "verdict"
+)
+
+// PipelineStages lists the stages in pipeline order.
+var PipelineStages = []string{
+	PipelineStageIngested,
+	PipelineStageAnalysisEnqueue,
+	PipelineStageTextReported,
+	PipelineStageReplayEnqueued,
+	PipelineStageReplayReported,
+	PipelineStageVerdict,
+}
+
+var pipelineStageColumns = map[string]string{
+	PipelineStageIngested:        "ingested_at",
+	PipelineStageAnalysisEnqueue: "analysis_enqueued_at",
+	PipelineStageTextReported:    "text_reported_at",
+	PipelineStageReplayEnqueued:  "replay_enqueued_at",
+	PipelineStageReplayReported:  "replay_reported_at",
+	PipelineStageVerdict:         "verdict_at",
+}
+
+const pipelineTimingColumns = `ingested_at, analysis_enqueued_at, text_reported_at, replay_enqueued_at, replay_reported_at, verdict_at`
+
+func scanPipelineTimings(row pgx.Row) (SessionPipelineTimings, error) {
+	timings := SessionPipelineTimings{}
+	err := row.Scan(
+		&timings.IngestedAt,
+		&timings.AnalysisEnqueuedAt,
+		&timings.TextReportedAt,
+		&timings.ReplayEnqueuedAt,
+		&timings.ReplayReportedAt,
+		&timings.VerdictAt,
+	)
+	return timings, err
+}
+
+// RecordPipelineStage stores the first time a session reached stage. It
+// reports the elapsed time since ingest and whether this call set the
+// timestamp; repeated calls for the same stage leave it unchanged.
+func (p *Postgres) RecordPipelineStage(
+	ctx context.Context,
+	projectID,
+	sessionID,
+	stage string,
+	at time.Time,
+) (time.Duration, bool, error) {
+	column, ok := pipelineStageColumns[stage]
+	if !ok {
+		return 0, false, fmt.Errorf("unknown pipeline stage %q", stage)
+	}
+	projectID = normalizeProjectID(projectID)
+	sessionID = strings.TrimSpace(sessionID)
+
+	// Sessions ingested before timings existed start from their created_at.
+	if _, err := p.pool.Exec(
+		ctx,
+		`INSERT INTO session_pipeline_timings (project_id, session_id, ingested_at)
+		 SELECT s.project_id, s.id, s.created_at
+		 FROM sessions s
+		 WHERE s.project_id = $1
+		   AND s.id = $2
+		 ON CONFLICT (project_id, session_id) DO NOTHING`,
+		projectID,
+		sessionID,
+	); err != nil {
+		return 0, false, err
+	}
+	if stage == PipelineStageIngested {
+		return 0, false, nil
+	}
+
+	var ingestedAt time.Time
+	err := p.pool.QueryRow(
+		ctx,
+		`UPDATE session_pipeline_timings
+		 SET `+column+` = $3,
+		     updated_at = NOW()
+		 WHERE project_id = $1
+		   AND session_id = $2
+		   AND `+column+` IS NULL
+		 RETURNING ingested_at`,
+		projectID,
+		sessionID,
+		at.UTC(),
+	).Scan(&ingestedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return at.Sub(ingestedAt), true, nil
+}
+
+func (p *Postgres) GetSessionPipelineTimings(ctx context.Context, projectID, sessionID string) (SessionPipelineTimings, error) {
+	return scanPipelineTimings(p.pool.QueryRow(
+		ctx,
+		`SELECT `+pipelineTimingColumns+`
+		 FROM session_pipeline_timings
+		 WHERE project_id = $1
+		   AND session_id = $2`,
+		normalizeProjectID(projectID),
+		strings.TrimSpace(sessionID),
+	))
+}
+
+// PipelineLatency aggregates time from ingest to each later stage for
+// sessions ingested since the given time.
+func (p *Postgres) PipelineLatency(ctx context.Context, projectID string, since time.Time) (PipelineLatencyReport, error) {
+	projectID = normalizeProjectID(projectID)
+	report := PipelineLatencyReport{Since: since.UTC(), Stages: make([]PipelineStageLatency, 0, len(PipelineStages)-1)}
+
+	if err := p.pool.QueryRow(
+		ctx,
+		`SELECT COUNT(*), COUNT(*) FILTER (WHERE verdict_at IS NULL)
+		 FROM session_pipeline_timings
+		 WHERE project_id = $1
+		   AND ingested_at >= $2`,
+		projectID,
+		since,
+	).Scan(&report.Sessions, &report.AwaitingVerdict); err != nil {
+		return PipelineLatencyReport{}, err
+	}
+
+	rows, err := p.pool.Query(
+		ctx,
+		`SELECT v.stage,
+		        COUNT(*),
+		        percentile_cont(ARRAY[0.5, 0.9, 0.95, 0.99]) WITHIN GROUP (
+		          ORDER BY EXTRACT(EPOCH FROM v.reached_at - t.ingested_at) * 1000
+		        )
+		 FROM session_pipeline_timings t
+		 CROSS JOIN LATERAL (VALUES
+		   ('analysis_enqueued', t.analysis_enqueued_at),
+		   ('text_reported', t.text_reported_at),
+		   ('replay_enqueued', t.replay_enqueued_at),
+		   ('replay_reported', t.replay_reported_at),
+		   ('verdict', t.verdict_at)
+		 ) AS v(stage, reached_at)
+		 WHERE t.project_id = $1
+		   AND t.ingested_at >= $2
+		   AND v.reached_at IS NOT NULL
+		 GROUP BY v.stage`,
+		projectID,
+		since,
+	)
+	if err != nil {
+		return PipelineLatencyReport{}, err
+	}
+	defer rows.Close()
+
+	byStage := map[string]PipelineStageLatency{}
+	for rows.Next() {
+		var (
+			latency     PipelineStageLatency
+			percentiles []float64
+		)
+		if err := rows.Scan(&latency.Stage, &latency.Count, &percentiles); err != nil {
+			return PipelineLatencyReport{}, err
+		}
+		if len(percentiles) == 4 {
+			latency.P50Ms, latency.P90Ms, latency.P95Ms, latency.P99Ms = percentiles[0], percentiles[1], percentiles[2], percentiles[3]
+		}
+		byStage[latency.Stage] = latency
+	}
+	if rows.Err() != nil {
+		return PipelineLatencyReport{}, rows.Err()
+	}
+
+	for _, stage := range PipelineStages[1:] {
+		latency, ok := byStage[stage]
+		if !ok {
+			latency = PipelineStageLatency{Stage: stage}
+		}
+		report.Stages = append(report.Stages, latency)
+	}
+	return report, nil
+}
@@ -33,7 +33,13 @@ var (
 )
 
 func NewPostgres(ctx context.Context, databaseURL string) (*Postgres, error) {
-	pool, err := pgxpool.New(ctx, databaseURL)
+	poolConfig, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	poolConfig.ConnConfig.Tracer = queryMetricsTracer{}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -74,18 +80,20 @@ func (p *Postgres) Ingest(ctx context.Context, projectID string, payload IngestP
 	storedSession := Session{}
 	err = tx.QueryRow(
 		ctx,
-		`INSERT INTO sessions (id, project_id, site, route, started_at, duration_ms, events_object_key)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`INSERT INTO sessions (id, project_id, user_id, site, route, started_at, duration_ms, events_object_key)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		 ON CONFLICT (id) DO UPDATE
 		 SET project_id = EXCLUDED.project_id,
+		     user_id = COALESCE(NULLIF(EXCLUDED.user_id, ''), sessions.user_id),
 		     site = EXCLUDED.site,
 		     route = EXCLUDED.route,
 		     started_at = EXCLUDED.started_at,
 		     duration_ms = EXCLUDED.duration_ms,
 		     events_object_key = EXCLUDED.events_object_key
-		 RETURNING id, project_id, site, route, started_at, duration_ms, events_object_key, created_at`,
+		 RETURNING id, project_id, user_id, site, route, started_at, duration_ms, events_object_key, created_at`,
 		sessionID,
 		projectID,
+		strings.TrimSpace(payload.Session.UserID),
 		payload.Session.Site,
 		payload.Session.Route,
 		payload.Session.StartedAt,
@@ -94,6 +102,7 @@ func (p *Postgres) Ingest(ctx context.Context, projectID string, payload IngestP
 	).Scan(
 		&storedSession.ID,
 		&storedSession.ProjectID,
+		&storedSession.UserID,
 		&storedSession.Site,
 		&storedSession.Route,
 		&storedSession.StartedAt,
@@ -1096,7 +1105,7 @@ func (p *Postgres) GetSession(ctx context.Context, projectID, id string) (Sessio
 	session := Session{}
 	err := p.pool.QueryRow(
 		ctx,
-		`SELECT id, project_id, site, route, started_at, duration_ms, events_object_key, created_at
+		`SELECT id, project_id, user_id, site, route, started_at, duration_ms, events_object_key, created_at
 		 FROM sessions
 		 WHERE id = $1
 		   AND project_id = $2`,
@@ -1105,6 +1114,7 @@ func (p *Postgres) GetSession(ctx context.Context, projectID, id string) (Sessio
 	).Scan(
 		&session.ID,
 		&session.ProjectID,
+		&session.UserID,
 		&session.Site,
 		&session.Route,
 		&session.StartedAt,
@@ -1233,6 +1243,14 @@ func (p *Postgres) GetSession(ctx context.Context, projectID, id string) (Sessio
 		session.ReportCard = &report
 	}
 
+	timings, err := p.GetSessionPipelineTimings(ctx, projectID, id)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return Session{}, err
+	}
+	if err == nil {
+		session.Pipeline = &timings
+	}
+
 	return session, nil
 }
 
@@ -1252,14 +1270,30 @@ func (p *Postgres) CleanupExpiredData(ctx context.Context, projectID string, ret
 		deletedSessions     int
 		deletedEventObject  []string
 		deletedArtifactKeys []string
+		heldSessions        int
 	)
+	err = tx.QueryRow(
+		ctx,
+		`SELECT COUNT(*)
+		 FROM sessions s
+		 WHERE s.created_at < NOW() - ($1::text || ' days')::interval
+		   AND s.project_id = $2
+		   AND `+activeLegalHoldSessionFilter,
+		retentionDays,
+		projectID,
+	).Scan(&heldSessions)
+	if err != nil {
+		return CleanupResult{}, err
+	}
+
 	err = tx.QueryRow(
 		ctx,
 		`WITH target_sessions AS (
-		   SELECT id, events_object_key
-		   FROM sessions
-		   WHERE created_at < NOW() - ($1::text || ' days')::interval
-		     AND project_id = $2
+		   SELECT s.id, s.events_object_key
+		   FROM sessions s
+		   WHERE s.created_at < NOW() - ($1::text || ' days')::interval
+		     AND s.project_id = $2
+		     AND NOT `+activeLegalHoldSessionFilter+`
 		 ),
 		 target_artifacts AS (
 		   SELECT DISTINCT sa.artifact_key
@@ -1312,33 +1346,18 @@ func (p *Postgres) CleanupExpiredData(ctx context.Context, projectID string, ret
 		DeletedArtifactObjects:    len(deletedArtifactKeys),
 		DeletedEventObjectKeys:    deletedEventObject,
 		DeletedArtifactObjectKeys: deletedArtifactKeys,
+		HeldSessions:              heldSessions,
 		RetentionDays:             retentionDays,
 	}, nil
 }
 
 func (p *Postgres) ResolveProjectIDByAPIKey(ctx context.Context, rawKey string) (string, error) {
-	key := strings.TrimSpace(rawKey)
-	if key == "" {
-		return "", pgx.ErrNoRows
-	}
-
-	hash := hashAPIKey(key)
-	projectID := ""
-
-	err := p.pool.QueryRow(
-		ctx,
-		`UPDATE project_api_keys
-		 SET last_used_at = NOW()
-		 WHERE key_hash = $1
-		   AND status = 'active'
-		 RETURNING project_id`,
-		hash,
-	).Scan(&projectID)
+	resolved, err := p.ResolveAPIKey(ctx, rawKey)
 	if err != nil {
 		return "", err
 	}
 
-	return projectID, nil
+	return resolved.ProjectID, nil
 }
 
 func (p *Postgres) CreateProjectWithAPIKey(
@@ -1347,7 +1366,21 @@ func (p *Postgres) CreateProjectWithAPIKey(
 	site,
 	label,
 	rawKey string,
+	scopes []string,
+	allowedOrigins []string,
 ) (Project, error) {
+	scopes, err := NormalizeAPIKeyScopes(scopes)
+	if err != nil {
+		return Project{}, err
+	}
+	if len(allowedOrigins) == 0 {
+		allowedOrigins = DefaultAllowedOrigins(site)
+	}
+	allowedOrigins, err = NormalizeAllowedOrigins(allowedOrigins)
+	if err != nil {
+		return Project{}, err
+	}
+
 	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
 		return Project{}, err
@@ -1355,17 +1388,16 @@ func (p *Postgres) CreateProjectWithAPIKey(
 	defer tx.Rollback(ctx)
 
 	projectID := "proj_" + strings.ReplaceAll(uuid.NewString(), "-", "")
-	project := Project{}
-
-	err = tx.QueryRow(
+	project, err := scanProject(tx.QueryRow(
 		ctx,
-		`INSERT INTO projects (id, name, site)
-		 VALUES ($1, $2, $3)
-		 RETURNING id, name, site, created_at`,
+		`INSERT INTO projects (id, name, site, allowed_origins)
+		 VALUES ($1, $2, $3, $4::text[])
+		 RETURNING `+projectColumns,
 		projectID,
 		strings.TrimSpace(name),
 		strings.TrimSpace(site),
-	).Scan(&project.ID, &project.Name, &project.Site, &project.CreatedAt)
+		allowedOrigins,
+	))
 	if err != nil {
 		return Project{}, err
 	}
@@ -1373,12 +1405,13 @@ func (p *Postgres) CreateProjectWithAPIKey(
 	keyID := "key_" + uuid.NewString()
 	_, err = tx.Exec(
 		ctx,
-		`INSERT INTO project_api_keys (id, project_id, key_hash, label, status)
-		 VALUES ($1, $2, $3, $4, 'active')`,
+		`INSERT INTO project_api_keys (id, project_id, key_hash, label, status, scopes)
+		 VALUES ($1, $2, $3, $4, 'active', $5::text[])`,
 		keyID,
 		projectID,
 		hashAPIKey(strings.TrimSpace(rawKey)),
 		strings.TrimSpace(label),
+		scopes,
 	)
 	if err != nil {
 		return Project{}, err
@@ -1394,7 +1427,7 @@ func (p *Postgres) CreateProjectWithAPIKey(
 func (p *Postgres) ListProjects(ctx context.Context) ([]Project, error) {
 	rows, err := p.pool.Query(
 		ctx,
-		`SELECT id, name, site, created_at
+		`SELECT `+projectColumns+`
 		 FROM projects
 		 ORDER BY created_at DESC`,
 	)
@@ -1405,8 +1438,8 @@ func (p *Postgres) ListProjects(ctx context.Context) ([]Project, error) {
 
 	projects := make([]Project, 0)
 	for rows.Next() {
-		var project Project
-		if err := rows.Scan(&project.ID, &project.Name, &project.Site, &project.CreatedAt); err != nil {
+		project, err := scanProject(rows)
+		if err != nil {
 			return nil, err
 		}
 		projects = append(projects, project)
@@ -1424,40 +1457,36 @@ func (p *Postgres) CreateAPIKeyForProject(
 	projectID,
 	label,
 	rawKey string,
+	scopes []string,
+	expiresAt *time.Time,
 ) (ProjectAPIKey, error) {
 	projectID = normalizeProjectID(projectID)
+	scopes, err := NormalizeAPIKeyScopes(scopes)
+	if err != nil {
+		return ProjectAPIKey{}, err
+	}
 	keyID := "key_" + uuid.NewString()
-	stored := ProjectAPIKey{}
 
-	err := p.pool.QueryRow(
+	row := p.pool.QueryRow(
 		ctx,
-		`INSERT INTO project_api_keys (id, project_id, key_hash, label, status)
-		 VALUES ($1, $2, $3, $4, 'active')
-		 RETURNING id, project_id, label, status, created_at, last_used_at`,
+		`INSERT INTO project_api_keys (id, project_id, key_hash, label, status, scopes, expires_at)
+		 VALUES ($1, $2, $3, $4, 'active', $5::text[], $6)
+		 RETURNING `+projectAPIKeyColumns,
 		keyID,
 		projectID,
 		hashAPIKey(strings.TrimSpace(rawKey)),
 		strings.TrimSpace(label),
-	).Scan(
-		&stored.ID,
-		&stored.ProjectID,
-		&stored.Label,
-		&stored.Status,
-		&stored.CreatedAt,
-		&stored.LastUsedAt,
+		scopes,
+		expiresAt,
 	)
-	if err != nil {
-		return ProjectAPIKey{}, err
-	}
-
-	return stored, nil
+	return scanProjectAPIKey(row)
 }
 
 func (p *Postgres) ListProjectAPIKeys(ctx context.Context, projectID string) ([]ProjectAPIKey, error) {
 	projectID = normalizeProjectID(projectID)
 	rows, err := p.pool.Query(
 		ctx,
-		`SELECT id, project_id, label, status, created_at, last_used_at
+		`SELECT `+projectAPIKeyColumns+`
 		 FROM project_api_keys
 		 WHERE project_id = $1
 		 ORDER BY created_at DESC`,
@@ -1470,15 +1499,8 @@ func (p *Postgres) ListProjectAPIKeys(ctx context.Context, projectID string) ([]
 
 	keys := make([]ProjectAPIKey, 0)
 	for rows.Next() {
-		var apiKey ProjectAPIKey
-		if err := rows.Scan(
-			&apiKey.ID,
-			&apiKey.ProjectID,
-			&apiKey.Label,
-			&apiKey.Status,
-			&apiKey.CreatedAt,
-			&apiKey.LastUsedAt,
-		); err != nil {
+		apiKey, err := scanProjectAPIKey(rows)
+		if err != nil {
 			return nil, err
 		}
 		keys = append(keys, apiKey)
@@ -1491,6 +1513,30 @@ func (p *Postgres) ListProjectAPIKeys(ctx context.Context, projectID string) ([]
 	return keys, nil
 }
 
+func (p *Postgres) GetProject(ctx context.Context, projectID string) (Project, error) {
+	return scanProject(p.pool.QueryRow(
+		ctx,
+		`SELECT `+projectColumns+`
+		 FROM projects
+		 WHERE id = $1`,
+		strings.TrimSpace(projectID),
+	))
+}
+
+func (p *Postgres) RevokeProjectAPIKey(ctx context.Context, projectID, keyID string) (ProjectAPIKey, error) {
+	row := p.pool.QueryRow(
+		ctx,
+		`UPDATE project_api_keys
+		 SET status = 'revoked'
+		 WHERE project_id = $1
+		   AND id = $2
+		 RETURNING `+projectAPIKeyColumns,
+		strings.TrimSpace(projectID),
+		strings.TrimSpace(keyID),
+	)
+	return scanProjectAPIKey(row)
+}
+
 func (p *Postgres) UpsertSessionArtifact(
 	ctx context.Context,
 	projectID string,
@@ -0,0 +1,369 @@
+package store
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const privacyErasureJobColumns = `id, project_id, subject_user_id, requested_session_ids, status, requested_by,
+	erased_session_ids, deleted_sessions, deleted_markers, deleted_report_cards, deleted_artifacts,
+	deleted_feedback_events, deleted_event_object_keys, deleted_artifact_object_keys, failed_object_keys,
+	affected_cluster_keys, held_session_ids, error, receipt_digest, receipt_key_id, created_at, completed_at`
+
+func (p *Postgres) CreatePrivacyErasureJob(
+	ctx context.Context,
+	projectID string,
+	subjectUserID string,
+	sessionIDs []string,
+	requestedBy string,
+) (PrivacyErasureJob, error) {
+	projectID = normalizeProjectID(projectID)
+	subjectUserID = strings.TrimSpace(subjectUserID)
+	sessionIDs = uniqueTrimmedText(sessionIDs)
+	requestedBy = strings.TrimSpace(requestedBy)
+	if subjectUserID == "" && len(sessionIDs) == 0 {
+		return PrivacyErasureJob{}, fmt.Errorf("userId or sessionIds is required")
+	}
+	if requestedBy == "" {
+		requestedBy = "api"
+	}
+
+	row := p.pool.QueryRow(
+		ctx,
+		`INSERT INTO privacy_erasure_jobs (id, project_id, subject_user_id, requested_session_ids, status, requested_by)
+		 VALUES ($1, $2, $3, $4::text[], 'running', $5)
+		 RETURNING `+privacyErasureJobColumns,
+		"erasure_"+uuid.NewString(),
+		projectID,
+		subjectUserID,
+		sessionIDs,
+		requestedBy,
+	)
+	return scanPrivacyErasureJob(row)
+}
+
+// ErrClusterRefresh reports that the erasure committed but re-promoting or
+// pruning the affected clusters failed. The job is still complete; cluster
+// counts catch up on the next promotion.
+var ErrClusterRefresh = errors.New("cluster refresh after erasure failed")
+
+func (p *Postgres) EraseSessionData(
+	ctx context.Context,
+	job PrivacyErasureJob,
+	minSessions int,
+) (PrivacyErasureJob, error) {
+	projectID := normalizeProjectID(job.ProjectID)
+	if minSessions < 1 {
+		minSessions = 1
+	}
+
+	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return job, err
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		sessionIDs    []string
+		heldIDs       []string
+		eventKeys     []string
+		artifactKeys  []string
+		clusterKeys   []string
+		markerCount   int
+		reportCount   int
+		artifactCount int
+		feedbackCount int
+		sessionCount  int
+	)
+	err = tx.QueryRow(
+		ctx,
+		`WITH matched_sessions AS (
+		   SELECT s.id, s.events_object_key, `+activeLegalHoldSessionFilter+` AS held
+		   FROM sessions s
+		   WHERE s.project_id = $1
+		     AND (
+		       s.id = ANY($2::text[])
+		       OR ($3::text <> '' AND s.user_id = $3::text)
+		     )
+		   FOR UPDATE OF s
+		 ),
+		 target_sessions AS (
+		   SELECT id, events_object_key
+		   FROM matched_sessions
+		   WHERE NOT held
+		 )
+		 SELECT
+		   COALESCE((SELECT array_agg(id ORDER BY id) FROM target_sessions), '{}'::text[]),
+		   COALESCE((SELECT array_agg(id ORDER BY id) FROM matched_sessions WHERE held), '{}'::text[]),
+		   COALESCE((
+		     SELECT array_agg(DISTINCT events_object_key)
+		     FROM target_sessions
+		     WHERE events_object_key <> ''
+		   ), '{}'::text[]),
+		   COALESCE((
+		     SELECT array_agg(DISTINCT sa.artifact_key)
+		     FROM session_artifacts sa
+		     JOIN target_sessions ts ON ts.id = sa.session_id
+		     WHERE sa.project_id = $1
+		       AND sa.artifact_key <> ''
+		   ), '{}'::text[]),
+		   COALESCE((
+		     SELECT array_agg(DISTINCT em.cluster_key)
+		     FROM error_markers em
+		     JOIN target_sessions ts ON ts.id = em.session_id
+		     WHERE em.cluster_key <> ''
+		   ), '{}'::text[])`,
+		projectID,
+		job.RequestedSessionIDs,
+		job.SubjectUserID,
+	).Scan(&sessionIDs, &heldIDs, &eventKeys, &artifactKeys, &clusterKeys)
+	if err != nil {
+		return job, err
+	}
+
+	if len(sessionIDs) > 0 {
+		deletes := []struct {
+			target *int
+			query  string
+		}{
+			{&markerCount, `DELETE FROM error_markers em USING sessions s WHERE em.session_id = s.id AND s.project_id = $1 AND s.id = ANY($2::text[])`},
+			{&reportCount, `DELETE FROM session_report_cards WHERE project_id = $1 AND session_id = ANY($2::text[])`},
+			{&artifactCount, `DELETE FROM session_artifacts WHERE project_id = $1 AND session_id = ANY($2::text[])`},
+			{&feedbackCount, `DELETE FROM issue_feedback_events WHERE project_id = $1 AND session_id = ANY($2::text[])`},
+			{&sessionCount, `DELETE FROM sessions WHERE project_id = $1 AND id = ANY($2::text[])`},
+		}
+		for _, statement := range deletes {
+			commandTag, err := tx.Exec(ctx, statement.query, projectID, sessionIDs)
+			if err != nil {
+				return job, err
+			}
+			*statement.target = int(commandTag.RowsAffected())
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return job, err
+	}
+
+	job.ErasedSessionIDs = sessionIDs
+	job.HeldSessionIDs = heldIDs
+	job.DeletedSessions = sessionCount
+	job.DeletedMarkers = markerCount
+	job.DeletedReportCards = reportCount
+	job.DeletedArtifacts = artifactCount
+	job.DeletedFeedbackEvents = feedbackCount
+	job.DeletedEventObjectKeys = eventKeys
+	job.DeletedArtifactObjectKeys = artifactKeys
+	job.AffectedClusterKeys = clusterKeys
+
+	if len(clusterKeys) > 0 {
+		if _, err := p.PromoteClusters(ctx, projectID, minSessions); err != nil {
+			return job, fmt.Errorf("%w: %w", ErrClusterRefresh, err)
+		}
+		if _, err := p.pruneOrphanIssueClusters(ctx, projectID); err != nil {
+			return job, fmt.Errorf("%w: %w", ErrClusterRefresh, err)
+		}
+	}
+	return job, nil
+}
+
+// CompletePrivacyErasureJob records the outcome and signs the receipt with
+// receiptKey. Without a key the receipt is left unsigned.
+func (p *Postgres) CompletePrivacyErasureJob(
+	ctx context.Context,
+	job PrivacyErasureJob,
+	receiptKeyID string,
+	receiptKey []byte,
+) (PrivacyErasureJob, error) {
+	completedAt := time.Now().UTC().Truncate(time.Microsecond)
+	job.ProjectID = normalizeProjectID(job.ProjectID)
+	job.CompletedAt = &completedAt
+	job.Status = strings.TrimSpace(job.Status)
+	if job.Status == "" {
+		job.Status = "completed"
+	}
+	job.ReceiptDigest = ""
+	job.ReceiptKeyID = ""
+	if len(receiptKey) > 0 {
+		job.ReceiptDigest = SignPrivacyErasureReceipt(job, receiptKey)
+		job.ReceiptKeyID = strings.TrimSpace(receiptKeyID)
+	}
+
+	row := p.pool.QueryRow(
+		ctx,
+		`UPDATE privacy_erasure_jobs
+		 SET status = $3,
+		     erased_session_ids = $4::text[],
+		     deleted_sessions = $5,
+		     deleted_markers = $6,
+		     deleted_report_cards = $7,
+		     deleted_artifacts = $8,
+		     deleted_feedback_events = $9,
+		     deleted_event_object_keys = $10::text[],
+		     deleted_artifact_object_keys = $11::text[],
+		     failed_object_keys = $12::text[],
+		     affected_cluster_keys = $13::text[],
+		     held_session_ids = $14::text[],
+		     error = $15,
+		     receipt_digest = $16,
+		     receipt_key_id = $17,
+		     completed_at = $18
+		 WHERE project_id = $1
+		   AND id = $2
+		 RETURNING `+privacyErasureJobColumns,
+		job.ProjectID,
+		job.ID,
+		job.Status,
+		nonNilText(job.ErasedSessionIDs),
+		job.DeletedSessions,
+		job.DeletedMarkers,
+		job.DeletedReportCards,
+		job.DeletedArtifacts,
+		job.DeletedFeedbackEvents,
+		nonNilText(job.DeletedEventObjectKeys),
+		nonNilText(job.DeletedArtifactObjectKeys),
+		nonNilText(job.FailedObjectKeys),
+		nonNilText(job.AffectedClusterKeys),
+		nonNilText(job.HeldSessionIDs),
+		strings.TrimSpace(job.Error),
+		job.ReceiptDigest,
+		job.ReceiptKeyID,
+		completedAt,
+	)
+	return scanPrivacyErasureJob(row)
+}
+
+func (p *Postgres) GetPrivacyErasureJob(ctx context.Context, projectID, jobID string) (PrivacyErasureJob, error) {
+	row := p.pool.QueryRow(
+		ctx,
+		`SELECT `+privacyErasureJobColumns+`
+		 FROM privacy_erasure_jobs
+		 WHERE project_id = $1
+		   AND id = $2`,
+		normalizeProjectID(projectID),
+		strings.TrimSpace(jobID),
+	)
+	return scanPrivacyErasureJob(row)
+}
+
+// SignPrivacyErasureReceipt returns the hex HMAC-SHA256 of the job's
+// canonical receipt under key.
+func SignPrivacyErasureReceipt(job PrivacyErasureJob, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(privacyErasureReceiptPayload(job))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyPrivacyErasureReceipt reports whether the job's stored receipt was
+// signed with key over its current contents.
+func VerifyPrivacyErasureReceipt(job PrivacyErasureJob, key []byte) bool {
+	if job.ReceiptDigest == "" || len(key) == 0 {
+		return false
+	}
+	expected := SignPrivacyErasureReceipt(job, key)
+	return hmac.Equal([]byte(expected), []byte(job.ReceiptDigest))
+}
+
+func privacyErasureReceiptPayload(job PrivacyErasureJob) []byte {
+	completedAt := ""
+	if job.CompletedAt != nil {
+		completedAt = job.CompletedAt.UTC().Format(time.RFC3339Nano)
+	}
+
+	receipt := struct {
+		ID                        string   `json:"id"`
+		ProjectID                 string   `json:"projectId"`
+		SubjectUserID             string   `json:"subjectUserId"`
+		RequestedSessionIDs       []string `json:"requestedSessionIds"`
+		Status                    string   `json:"status"`
+		ErasedSessionIDs          []string `json:"erasedSessionIds"`
+		DeletedSessions           int      `json:"deletedSessions"`
+		DeletedMarkers            int      `json:"deletedMarkers"`
+		DeletedReportCards        int      `json:"deletedReportCards"`
+		DeletedArtifacts          int      `json:"deletedArtifacts"`
+		DeletedFeedbackEvents     int      `json:"deletedFeedbackEvents"`
+		DeletedEventObjectKeys    []string `json:"deletedEventObjectKeys"`
+		DeletedArtifactObjectKeys []string `json:"deletedArtifactObjectKeys"`
+		FailedObjectKeys          []string `json:"failedObjectKeys"`
+		AffectedClusterKeys       []string `json:"affectedClusterKeys"`
+		HeldSessionIDs            []string `json:"heldSessionIds"`
+		CompletedAt               string   `json:"completedAt"`
+	}{
+		ID:                        job.ID,
+		ProjectID:                 job.ProjectID,
+		SubjectUserID:             job.SubjectUserID,
+		RequestedSessionIDs:       sortedText(job.RequestedSessionIDs),
+		Status:                    job.Status,
+		ErasedSessionIDs:          sortedText(job.ErasedSessionIDs),
+		DeletedSessions:           job.DeletedSessions,
+		DeletedMarkers:            job.DeletedMarkers,
+		DeletedReportCards:        job.DeletedReportCards,
+		DeletedArtifacts:          job.DeletedArtifacts,
+		DeletedFeedbackEvents:     job.DeletedFeedbackEvents,
+		DeletedEventObjectKeys:    sortedText(job.DeletedEventObjectKeys),
+		DeletedArtifactObjectKeys: sortedText(job.DeletedArtifactObjectKeys),
+		FailedObjectKeys:          sortedText(job.FailedObjectKeys),
+		AffectedClusterKeys:       sortedText(job.AffectedClusterKeys),
+		HeldSessionIDs:            sortedText(job.HeldSessionIDs),
+		CompletedAt:               completedAt,
+	}
+
+	encoded, _ := json.Marshal(receipt)
+	return encoded
+}
+
+func scanPrivacyErasureJob(row pgx.Row) (PrivacyErasureJob, error) {
+	job := PrivacyErasureJob{}
+	err := row.Scan(
+		&job.ID,
+		&job.ProjectID,
+		&job.SubjectUserID,
+		&job.RequestedSessionIDs,
+		&job.Status,
+		&job.RequestedBy,
+		&job.ErasedSessionIDs,
+		&job.DeletedSessions,
+		&job.DeletedMarkers,
+		&job.DeletedReportCards,
+		&job.DeletedArtifacts,
+		&job.DeletedFeedbackEvents,
+		&job.DeletedEventObjectKeys,
+		&job.DeletedArtifactObjectKeys,
+		&job.FailedObjectKeys,
+		&job.AffectedClusterKeys,
+		&job.HeldSessionIDs,
+		&job.Error,
+		&job.ReceiptDigest,
+		&job.ReceiptKeyID,
+		&job.CreatedAt,
+		&job.CompletedAt,
+	)
+	if err != nil {
+		return PrivacyErasureJob{}, err
+	}
+	return job, nil
+}
+
+func sortedText(values []string) []string {
+	sorted := append([]string{}, values...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+func nonNilText(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
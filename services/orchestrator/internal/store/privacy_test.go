@@ -0,0 +1,70 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+var testReceiptKey = []byte("receipt-secret")
+
+func TestPrivacyErasureReceiptSignatureIgnoresKeyOrder(t *testing.T) {
+	completedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	jobA := PrivacyErasureJob{
+		ID:                     "erasure_1",
+		ProjectID:              "proj_test",
+		SubjectUserID:          "user-42",
+		Status:                 "completed",
+		ErasedSessionIDs:       []string{"session-a", "session-b"},
+		DeletedSessions:        2,
+		DeletedEventObjectKeys: []string{"session-events/a.json", "session-events/b.json"},
+		CompletedAt:            &completedAt,
+	}
+	jobB := jobA
+	jobB.ErasedSessionIDs = []string{"session-b", "session-a"}
+	jobB.DeletedEventObjectKeys = []string{"session-events/b.json", "session-events/a.json"}
+
+	if SignPrivacyErasureReceipt(jobA, testReceiptKey) != SignPrivacyErasureReceipt(jobB, testReceiptKey) {
+		t.Fatal("expected signature to be stable across key ordering")
+	}
+}
+
+func TestPrivacyErasureReceiptSignatureDetectsTampering(t *testing.T) {
+	completedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	job := PrivacyErasureJob{
+		ID:               "erasure_1",
+		ProjectID:        "proj_test",
+		Status:           "completed",
+		ErasedSessionIDs: []string{"session-a"},
+		DeletedSessions:  1,
+		CompletedAt:      &completedAt,
+	}
+	job.ReceiptDigest = SignPrivacyErasureReceipt(job, testReceiptKey)
+	if !VerifyPrivacyErasureReceipt(job, testReceiptKey) {
+		t.Fatal("expected signed receipt to verify")
+	}
+
+	job.DeletedSessions = 0
+	if VerifyPrivacyErasureReceipt(job, testReceiptKey) {
+		t.Fatal("expected verification to fail when receipt counts change")
+	}
+
+	job.DeletedSessions = 1
+	job.HeldSessionIDs = []string{"session-b"}
+	if VerifyPrivacyErasureReceipt(job, testReceiptKey) {
+		t.Fatal("expected verification to fail when held sessions change")
+	}
+}
+
+func TestPrivacyErasureReceiptRequiresServerKey(t *testing.T) {
+	completedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	job := PrivacyErasureJob{ID: "erasure_1", ProjectID: "proj_test", Status: "completed", CompletedAt: &completedAt}
+
+	job.ReceiptDigest = SignPrivacyErasureReceipt(job, []byte("forged-key"))
+	if VerifyPrivacyErasureReceipt(job, testReceiptKey) {
+		t.Fatal("expected receipt signed with another key to be rejected")
+	}
+	job.ReceiptDigest = ""
+	if VerifyPrivacyErasureReceipt(job, testReceiptKey) {
+		t.Fatal("expected unsigned receipt to be rejected")
+	}
+}
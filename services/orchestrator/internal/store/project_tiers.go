@@ -0,0 +1,94 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	ProjectTierPriority = "priority"
+	ProjectTierStandard = "standard"
+	ProjectTierBulk     = "bulk"
+)
+
+// NormalizeProjectTier validates tier; an empty value means standard.
+func NormalizeProjectTier(tier string) (string, error) {
+	tier = strings.ToLower(strings.TrimSpace(tier))
+	switch tier {
+	case "":
+		return ProjectTierStandard, nil
+	case ProjectTierPriority, ProjectTierStandard, ProjectTierBulk:
+		return tier, nil
+	default:
+		return "", fmt.Errorf("unknown project tier %q", tier)
+	}
+}
+
+func (p *Postgres) UpdateProjectTier(ctx context.Context, projectID, tier string) (Project, error) {
+	tier, err := NormalizeProjectTier(tier)
+	if err != nil {
+		return Project{}, err
+	}
+
+	return scanProject(p.pool.QueryRow(
+		ctx,
+		`UPDATE projects
+		 SET tier = $2
+		 WHERE id = $1
+		 RETURNING `+projectColumns,
+		strings.TrimSpace(projectID),
+		tier,
+	))
+}
+
+// JobPriorityInputs returns the project's tier and the triage state of each
+// of clusterKeys that has one. Mutes that have expired read as open.
+func (p *Postgres) JobPriorityInputs(ctx context.Context, projectID string, clusterKeys []string) (string, map[string]string, error) {
+	projectID = normalizeProjectID(projectID)
+
+	tier := ProjectTierStandard
+	err := p.pool.QueryRow(ctx, `SELECT tier FROM projects WHERE id = $1`, projectID).Scan(&tier)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return "", nil, err
+	}
+
+	states := map[string]string{}
+	if len(clusterKeys) == 0 {
+		return tier, states, nil
+	}
+
+	rows, err := p.pool.Query(
+		ctx,
+		`SELECT cluster_key,
+		        CASE
+		          WHEN state = 'muted' AND muted_until IS NOT NULL AND muted_until <= NOW() THEN 'open'
+		          ELSE state
+		        END
+		 FROM issue_cluster_states
+		 WHERE project_id = $1
+		   AND cluster_key = ANY($2::text[])`,
+		projectID,
+		clusterKeys,
+	)
+	if err != nil {
+		return "", nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var clusterKey, state string
+		if err := rows.Scan(&clusterKey, &state); err != nil {
+			return "", nil, err
+		}
+		states[clusterKey] = state
+	}
+	if rows.Err() != nil {
+		return "", nil, rows.Err()
+	}
+
+	return tier, states, nil
+}
@@ -0,0 +1,68 @@
+package store
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"retrospec/services/orchestrator/internal/metrics"
+	"retrospec/services/orchestrator/internal/tracing"
+)
+
+const postgresMethodPrefix = "store.(*Postgres)."
+
+type queryStartContextKey struct{}
+
+type queryStart struct {
+	method  string
+	started time.Time
+	span    trace.Span
+}
+
+// queryMetricsTracer times every query and wraps it in a span, both named
+// after the calling *Postgres method, which keeps the label set bounded unlike
+// the SQL text.
+type queryMetricsTracer struct{}
+
+func (queryMetricsTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryStartData) context.Context {
+	method := callingStoreMethod()
+	ctx, span := tracing.Tracer().Start(ctx, "postgres "+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation", method),
+		),
+	)
+	return context.WithValue(ctx, queryStartContextKey{}, queryStart{method: method, started: time.Now(), span: span})
+}
+
+func (queryMetricsTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	start, ok := ctx.Value(queryStartContextKey{}).(queryStart)
+	if !ok {
+		return
+	}
+	metrics.ObserveDBQuery(start.method, start.started, data.Err)
+	tracing.RecordError(start.span, data.Err)
+	start.span.End()
+}
+
+func callingStoreMethod() string {
+	pcs := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if _, method, found := strings.Cut(frame.Function, postgresMethodPrefix); found {
+			if name, _, _ := strings.Cut(method, "."); name != "" {
+				return name
+			}
+		}
+		if !more {
+			return "other"
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	RateLimitClassIngest = "ingest"
+	RateLimitClassRead   = "read"
+	RateLimitClassAdmin  = "admin"
+
+	// RateLimitClassAddress is checked per client address before any
+	// authentication, so it has no per-project override.
+	RateLimitClassAddress = "address"
+)
+
+var rateLimitClasses = map[string]struct{}{
+	RateLimitClassIngest: {},
+	RateLimitClassRead:   {},
+	RateLimitClassAdmin:  {},
+}
+
+func (p *Postgres) UpdateProjectRateLimits(ctx context.Context, projectID string, limits map[string]RateLimit) (Project, error) {
+	limits, err := NormalizeRateLimits(limits)
+	if err != nil {
+		return Project{}, err
+	}
+
+	return scanProject(p.pool.QueryRow(
+		ctx,
+		`UPDATE projects
+		 SET rate_limits = $2::jsonb
+		 WHERE id = $1
+		 RETURNING `+projectColumns,
+		strings.TrimSpace(projectID),
+		limits,
+	))
+}
+
+// ListProjectRateLimits returns the overrides of every project that has any;
+// projects using the defaults are omitted.
+func (p *Postgres) ListProjectRateLimits(ctx context.Context) (map[string]map[string]RateLimit, error) {
+	rows, err := p.pool.Query(
+		ctx,
+		`SELECT id, rate_limits
+		 FROM projects
+		 WHERE rate_limits <> '{}'::jsonb`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	overrides := map[string]map[string]RateLimit{}
+	for rows.Next() {
+		var projectID string
+		var limits map[string]RateLimit
+		if err := rows.Scan(&projectID, &limits); err != nil {
+			return nil, err
+		}
+		overrides[projectID] = limits
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return overrides, nil
+}
+
+// NormalizeRateLimits validates per-class overrides. Both values must be
+// positive; remove a class to fall back to the configured default.
+func NormalizeRateLimits(limits map[string]RateLimit) (map[string]RateLimit, error) {
+	normalized := make(map[string]RateLimit, len(limits))
+	for class, limit := range limits {
+		class = strings.ToLower(strings.TrimSpace(class))
+		if _, ok := rateLimitClasses[class]; !ok {
+			return nil, fmt.Errorf("unknown rate limit class %q", class)
+		}
+		if limit.RequestsPerSec <= 0 || limit.Burst <= 0 {
+			return nil, fmt.Errorf("rate limit for %s needs positive requestsPerSec and burst", class)
+		}
+		normalized[class] = limit
+	}
+	return normalized, nil
+}
@@ -0,0 +1,143 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const shareLinkColumns = `id, project_id, session_id, password_hash, created_by, expires_at,
+	revoked_at, revoked_by, view_count, last_viewed_at, created_at,
+	(revoked_at IS NULL AND expires_at > NOW()) AS active`
+
+func scanShareLink(row pgx.Row) (SessionShareLink, error) {
+	link := SessionShareLink{}
+	err := row.Scan(
+		&link.ID,
+		&link.ProjectID,
+		&link.SessionID,
+		&link.PasswordHash,
+		&link.CreatedBy,
+		&link.ExpiresAt,
+		&link.RevokedAt,
+		&link.RevokedBy,
+		&link.ViewCount,
+		&link.LastViewedAt,
+		&link.CreatedAt,
+		&link.Active,
+	)
+	if err != nil {
+		return SessionShareLink{}, err
+	}
+	link.PasswordProtected = link.PasswordHash != ""
+	return link, nil
+}
+
+// CreateSessionShareLink returns pgx.ErrNoRows when the session does not
+// belong to the project.
+func (p *Postgres) CreateSessionShareLink(
+	ctx context.Context,
+	projectID,
+	sessionID,
+	rawToken,
+	passwordHash string,
+	expiresAt time.Time,
+	createdBy string,
+) (SessionShareLink, error) {
+	return scanShareLink(p.pool.QueryRow(
+		ctx,
+		`INSERT INTO session_share_links (id, project_id, session_id, token_hash, password_hash, created_by, expires_at)
+		 SELECT $1, s.project_id, s.id, $4, $5, $6, $7
+		 FROM sessions s
+		 WHERE s.project_id = $2
+		   AND s.id = $3
+		 RETURNING `+shareLinkColumns,
+		"link_"+uuid.NewString(),
+		normalizeProjectID(projectID),
+		strings.TrimSpace(sessionID),
+		hashAPIKey(strings.TrimSpace(rawToken)),
+		passwordHash,
+		strings.TrimSpace(createdBy),
+		expiresAt,
+	))
+}
+
+func (p *Postgres) ListSessionShareLinks(ctx context.Context, projectID, sessionID string) ([]SessionShareLink, error) {
+	rows, err := p.pool.Query(
+		ctx,
+		`SELECT `+shareLinkColumns+`
+		 FROM session_share_links
+		 WHERE project_id = $1
+		   AND session_id = $2
+		 ORDER BY created_at DESC`,
+		normalizeProjectID(projectID),
+		strings.TrimSpace(sessionID),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := make([]SessionShareLink, 0)
+	for rows.Next() {
+		link, err := scanShareLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return links, nil
+}
+
+func (p *Postgres) RevokeSessionShareLink(ctx context.Context, projectID, linkID, revokedBy string) (SessionShareLink, error) {
+	return scanShareLink(p.pool.QueryRow(
+		ctx,
+		`UPDATE session_share_links
+		 SET revoked_at = COALESCE(revoked_at, NOW()),
+		     revoked_by = CASE WHEN revoked_at IS NULL THEN $3 ELSE revoked_by END
+		 WHERE project_id = $1
+		   AND id = $2
+		 RETURNING `+shareLinkColumns,
+		normalizeProjectID(projectID),
+		strings.TrimSpace(linkID),
+		strings.TrimSpace(revokedBy),
+	))
+}
+
+// ResolveSessionShareLink returns the active link for rawToken, or
+// pgx.ErrNoRows when it is unknown, revoked or expired.
+func (p *Postgres) ResolveSessionShareLink(ctx context.Context, rawToken string) (SessionShareLink, error) {
+	token := strings.TrimSpace(rawToken)
+	if token == "" {
+		return SessionShareLink{}, pgx.ErrNoRows
+	}
+
+	return scanShareLink(p.pool.QueryRow(
+		ctx,
+		`SELECT `+shareLinkColumns+`
+		 FROM session_share_links
+		 WHERE token_hash = $1
+		   AND revoked_at IS NULL
+		   AND expires_at > NOW()`,
+		hashAPIKey(token),
+	))
+}
+
+func (p *Postgres) RecordSessionShareLinkView(ctx context.Context, linkID string) (SessionShareLink, error) {
+	return scanShareLink(p.pool.QueryRow(
+		ctx,
+		`UPDATE session_share_links
+		 SET view_count = view_count + 1,
+		     last_viewed_at = NOW()
+		 WHERE id = $1
+		 RETURNING `+shareLinkColumns,
+		strings.TrimSpace(linkID),
+	))
+}
@@ -1,9 +1,13 @@
+export type QueueLane = "high" | "default" | "low";
+
 export interface AnalyzerWorkerConfig {
   redisHost: string;
   redisPort: number;
   queueName: string;
+  laneWeights: Record<QueueLane, number>;
   orchestratorBaseUrl: string;
-  internalApiKey: string;
+  internalSigningKeyId: string;
+  internalSigningKey: string;
   provider: "heuristic" | "remote_text";
   textModelEndpoint: string;
   modelApiKey: string;
@@ -27,8 +31,10 @@ export function loadConfig(): AnalyzerWorkerConfig {
     redisHost: process.env.REDIS_HOST ?? "localhost",
     redisPort: envOrDefaultNumber("REDIS_PORT", 6379),
     queueName: process.env.ANALYSIS_QUEUE_NAME ?? "analysis-jobs",
+    laneWeights: parseLaneWeights(process.env.ANALYZER_LANE_WEIGHTS),
     orchestratorBaseUrl: process.env.ORCHESTRATOR_BASE_URL ?? "http://localhost:8080",
-    internalApiKey: process.env.INTERNAL_API_KEY ?? "",
+    internalSigningKeyId: process.env.INTERNAL_SIGNING_KEY_ID ?? "default",
+    internalSigningKey: process.env.INTERNAL_SIGNING_KEY ?? process.env.INTERNAL_API_KEY ?? "",
     provider: parseProvider(process.env.ANALYZER_PROVIDER),
     textModelEndpoint: process.env.ANALYZER_TEXT_MODEL_ENDPOINT ?? "",
     modelApiKey: process.env.ANALYZER_MODEL_API_KEY ?? "",
@@ -48,6 +54,20 @@ export function loadConfig(): AnalyzerWorkerConfig {
   };
 }
 
+// parseLaneWeights reads "high:6,default:3,low:1"; lanes left out or given
+// an invalid weight keep their default.
+function parseLaneWeights(value: string | undefined): Record<QueueLane, number> {
+  const weights: Record<QueueLane, number> = { high: 6, default: 3, low: 1 };
+  for (const entry of (value ?? "").split(",")) {
+    const [lane, rawWeight] = entry.split(":").map((part) => part.trim());
+    const weight = rawWeight ? Number(rawWeight) : Number.NaN;
+    if ((lane === "high" || lane === "default" || lane === "low") && Number.isFinite(weight) && weight >= 0) {
+      weights[lane] = weight;
+    }
+  }
+  return weights;
+}
+
 function envOrDefaultNumber(key: string, fallback: number): number {
   const value = process.env[key];
   if (!value) {
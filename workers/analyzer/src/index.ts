@@ -2,12 +2,13 @@ import { createHash } from "node:crypto";
 import { hostname } from "node:os";
 import { Redis } from "ioredis";
 import { z } from "zod";
-import { loadConfig } from "./config.js";
+import { loadConfig, type QueueLane } from "./config.js";
 import { generateAnalysisReport } from "./model.js";
 import { reportAnalysisCard } from "./orchestrator.js";
 import { loadEventsBlob } from "./s3.js";
 
 interface StreamMessage {
+  stream: string;
   id: string;
   payload: string;
 }
@@ -22,15 +23,29 @@ const analysisJobSchema = z.object({
   route: z.string().default("/unknown"),
   site: z.string().default("unknown-site"),
   attempt: z.number().int().positive().default(1),
+  lane: z.enum(["high", "default", "low"]).default("default"),
+  traceparent: z.string().optional(),
 });
 
 const config = loadConfig();
 const queueName = config.queueName;
-const groupName = `${config.queueName}:group`;
+const queueLanes: QueueLane[] = ["high", "default", "low"];
 const consumerName = `${hostname()}-${process.pid}`;
 const failedQueueName = `${config.queueName}:failed`;
 const retryQueueName = `${config.queueName}:retry`;
 
+// Mirrors queue.LaneStream in the orchestrator: the default lane keeps the
+// base stream so jobs enqueued before lanes existed are still consumed.
+function laneStream(lane: QueueLane): string {
+  return lane === "default" ? queueName : `${queueName}:lane:${lane}`;
+}
+
+function streamGroup(stream: string): string {
+  return `${stream}:group`;
+}
+
+const laneStreams = queueLanes.map(laneStream);
+
 const redis = new Redis({
   host: config.redisHost,
   port: config.redisPort,
@@ -90,6 +105,7 @@ function parseStreamMessages(raw: unknown): StreamMessage[] {
     if (!Array.isArray(streamRows) || streamRows.length < 2) {
       continue;
     }
+    const stream = String(streamRows[0] ?? "");
     const rows = streamRows[1];
     if (!Array.isArray(rows)) {
       continue;
@@ -100,8 +116,8 @@ function parseStreamMessages(raw: unknown): StreamMessage[] {
       }
       const id = String(row[0] ?? "");
       const payload = extractPayload(row[1]);
-      if (id && payload) {
-        messages.push({ id, payload });
+      if (stream && id && payload) {
+        messages.push({ stream, id, payload });
       }
     }
   }
@@ -109,27 +125,27 @@ function parseStreamMessages(raw: unknown): StreamMessage[] {
   return messages;
 }
 
-async function ensureStreamQueue(): Promise<void> {
-  const queueType = await redis.type(queueName);
+async function ensureStreamQueue(stream: string): Promise<void> {
+  const queueType = await redis.type(stream);
   if (queueType === "none" || queueType === "stream") {
     return;
   }
 
   if (queueType !== "list") {
-    throw new Error(`unsupported redis key type for queue ${queueName}: ${queueType}`);
+    throw new Error(`unsupported redis key type for queue ${stream}: ${queueType}`);
   }
 
-  const legacyQueueName = `${queueName}:legacy:list:${Date.now()}`;
-  const renamed = await redis.renamenx(queueName, legacyQueueName);
+  const legacyQueueName = `${stream}:legacy:list:${Date.now()}`;
+  const renamed = await redis.renamenx(stream, legacyQueueName);
   if (renamed !== 1) {
-    const refreshedType = await redis.type(queueName);
+    const refreshedType = await redis.type(stream);
     if (refreshedType === "none" || refreshedType === "stream") {
       return;
     }
     if (refreshedType === "list") {
-      throw new Error(`legacy list queue migration contention for ${queueName}`);
+      throw new Error(`legacy list queue migration contention for ${stream}`);
     }
-    throw new Error(`unsupported redis key type for queue ${queueName}: ${refreshedType}`);
+    throw new Error(`unsupported redis key type for queue ${stream}: ${refreshedType}`);
   }
 
   let migrated = 0;
@@ -138,27 +154,38 @@ async function ensureStreamQueue(): Promise<void> {
     if (!payload) {
       break;
     }
-    await redis.xadd(queueName, "*", "payload", payload);
+    await redis.xadd(stream, "*", "payload", payload);
     migrated += 1;
   }
   await redis.del(legacyQueueName);
   if (migrated > 0) {
-    console.warn(`[analyzer-worker] migrated legacy list queue=${queueName} entries=${migrated}`);
+    console.warn(`[analyzer-worker] migrated legacy list queue=${stream} entries=${migrated}`);
   }
 }
 
-async function ensureConsumerGroup(): Promise<void> {
-  await ensureStreamQueue();
-  try {
-    await redis.xgroup("CREATE", queueName, groupName, "0", "MKSTREAM");
-  } catch (error) {
-    const details = error instanceof Error ? error.message : String(error);
-    if (!details.includes("BUSYGROUP")) {
-      throw error;
+async function ensureConsumerGroups(): Promise<void> {
+  for (const stream of laneStreams) {
+    await ensureStreamQueue(stream);
+    try {
+      await redis.xgroup("CREATE", stream, streamGroup(stream), "0", "MKSTREAM");
+    } catch (error) {
+      const details = error instanceof Error ? error.message : String(error);
+      if (!details.includes("BUSYGROUP")) {
+        throw error;
+      }
     }
   }
 }
 
+function payloadLane(payload: string): QueueLane {
+  try {
+    const lane = (JSON.parse(payload) as { lane?: unknown }).lane;
+    return queueLanes.find((candidate) => candidate === lane) ?? "default";
+  } catch {
+    return "default";
+  }
+}
+
 async function drainRetryQueue(): Promise<void> {
   const now = Date.now();
   const due = await redis.zrangebyscore(retryQueueName, 0, now, "LIMIT", 0, 20);
@@ -167,7 +194,7 @@ async function drainRetryQueue(): Promise<void> {
   }
 
   for (const item of due) {
-    await redis.xadd(queueName, "*", "payload", item);
+    await redis.xadd(laneStream(payloadLane(item)), "*", "payload", item);
     await redis.zrem(retryQueueName, item);
   }
 }
@@ -180,17 +207,55 @@ async function enqueueRetry(payload: unknown, attempt: number): Promise<void> {
   await redis.zadd(retryQueueName, runAtMs, JSON.stringify(payload));
 }
 
+// laneReadOrder draws the first lane in proportion to its weight and keeps
+// the rest in priority order, so lower lanes still get turns while higher
+// ones stay busy.
+function laneReadOrder(): QueueLane[] {
+  const total = queueLanes.reduce((sum, lane) => sum + config.laneWeights[lane], 0);
+  let draw = Math.random() * total;
+  let first: QueueLane = "high";
+  for (const lane of queueLanes) {
+    draw -= config.laneWeights[lane];
+    if (draw < 0) {
+      first = lane;
+      break;
+    }
+  }
+  return [first, ...queueLanes.filter((lane) => lane !== first)];
+}
+
 async function readNewMessages(): Promise<StreamMessage[]> {
+  for (const lane of laneReadOrder()) {
+    const stream = laneStream(lane);
+    const result = await redis.xreadgroup(
+      "GROUP",
+      streamGroup(stream),
+      consumerName,
+      "COUNT",
+      "5",
+      "STREAMS",
+      stream,
+      ">",
+    );
+    const messages = parseStreamMessages(result);
+    if (messages.length > 0) {
+      return messages;
+    }
+  }
+
+  // Every lane is empty. Each lane has its own group, so one XREADGROUP cannot
+  // block on all of them; wait on the high lane and poll the rest next loop.
+  const highStream = laneStream("high");
   const result = await redis.xreadgroup(
     "GROUP",
-    groupName,
+    streamGroup(highStream),
     consumerName,
     "COUNT",
     "5",
     "BLOCK",
-    "5000",
+    "1000",
     "STREAMS",
-    queueName,
+    highStream,
     ">",
   );
 
@@ -199,41 +264,47 @@ async function readNewMessages(): Promise<StreamMessage[]> {
 
 async function claimStalePendingMessages(): Promise<StreamMessage[]> {
   const minIdleMs = Math.max(60, Math.floor(config.processingStaleSec)) * 1_000;
-  let cursor = "0-0";
   const claimed: StreamMessage[] = [];
 
-  for (let attempt = 0; attempt < 5; attempt += 1) {
-    const raw = await redis.call(
-      "XAUTOCLAIM",
-      queueName,
-      groupName,
-      consumerName,
-      String(minIdleMs),
-      cursor,
-      "COUNT",
-      "20",
-    );
-    if (!Array.isArray(raw) || raw.length < 2) {
-      break;
-    }
+  for (const stream of laneStreams) {
+    let cursor = "0-0";
+    for (let attempt = 0; attempt < 5; attempt += 1) {
+      const raw = await redis.call(
+        "XAUTOCLAIM",
+        stream,
+        streamGroup(stream),
+        consumerName,
+        String(minIdleMs),
+        cursor,
+        "COUNT",
+        "20",
+      );
+      if (!Array.isArray(raw) || raw.length < 2) {
+        break;
+      }
 
-    cursor = String(raw[0] ?? "0-0");
-    const messages = parseStreamMessages([[queueName, raw[1]]]);
-    if (messages.length === 0) {
-      break;
-    }
+      cursor = String(raw[0] ?? "0-0");
+      const messages = parseStreamMessages([[stream, raw[1]]]);
+      if (messages.length === 0) {
+        break;
+      }
 
-    claimed.push(...messages);
-    if (messages.length < 20) {
-      break;
+      claimed.push(...messages);
+      if (messages.length < 20) {
+        break;
+      }
     }
   }
 
   return claimed;
 }
 
-async function acknowledgeMessage(messageID: string): Promise<void> {
-  await redis.multi().xack(queueName, groupName, messageID).xdel(queueName, messageID).exec();
+async function acknowledgeMessage(message: StreamMessage): Promise<void> {
+  await redis
+    .multi()
+    .xack(message.stream, streamGroup(message.stream), message.id)
+    .xdel(message.stream, message.id)
+    .exec();
 }
 
 async function reportFailure(
@@ -241,19 +312,23 @@ async function reportFailure(
   details: string,
 ): Promise<void> {
   try {
-    await reportAnalysisCard(config, {
-      projectId: parsed.projectId,
-      sessionId: parsed.sessionId,
-      status: "failed",
-      symptom: "Analysis pipeline failed before report generation.",
-      technicalRootCause: details.slice(0, 600),
-      suggestedFix:
-        "Inspect analyzer worker logs and payload shape for this session, then retry analysis.",
-      textSummary: "Analyzer job failed and was moved to dead-letter processing.",
-      visualSummary: `Route: ${parsed.route}.`,
-      confidence: 0.15,
-      generatedAt: new Date().toISOString(),
-    });
+    await reportAnalysisCard(
+      config,
+      {
+        projectId: parsed.projectId,
+        sessionId: parsed.sessionId,
+        status: "failed",
+        symptom: "Analysis pipeline failed before report generation.",
+        technicalRootCause: details.slice(0, 600),
+        suggestedFix:
+          "Inspect analyzer worker logs and payload shape for this session, then retry analysis.",
+        textSummary: "Analyzer job failed and was moved to dead-letter processing.",
+        visualSummary: `Route: ${parsed.route}.`,
+        confidence: 0.15,
+        generatedAt: new Date().toISOString(),
+      },
+      parsed.traceparent,
+    );
   } catch (callbackError) {
     const callbackDetails =
       callbackError instanceof Error ? callbackError.message : String(callbackError);
@@ -279,9 +354,9 @@ async function handleMessage(message: StreamMessage): Promise<void> {
     }
 
     const generatedAt = new Date();
-    const rawEvents = await loadEventsBlob(parsed.eventsObjectKey);
+    const rawEvents = await loadEventsBlob(parsed.eventsObjectKey, parsed.traceparent);
     const report = await generateAnalysisReport(parsed, rawEvents, generatedAt, config);
-    await reportAnalysisCard(config, report);
+    await reportAnalysisCard(config, report, parsed.traceparent);
     await redis.set(doneKey, report.generatedAt, "EX", Math.max(60, config.dedupeWindowSec));
     shouldAcknowledge = true;
 
@@ -318,7 +393,7 @@ async function handleMessage(message: StreamMessage): Promise<void> {
   } finally {
     if (shouldAcknowledge) {
       try {
-        await acknowledgeMessage(message.id);
+        await acknowledgeMessage(message);
       } catch (ackError) {
         const details = ackError instanceof Error ? ackError.message : String(ackError);
         console.error(`[analyzer-worker] message ack failed id=${message.id} err=${details}`);
@@ -332,8 +407,8 @@ async function handleMessage(message: StreamMessage): Promise<void> {
 }
 
 async function workerLoop() {
-  console.info(`[analyzer-worker] listening stream=${queueName} group=${groupName} consumer=${consumerName}`);
-  await ensureConsumerGroup();
+  console.info(`[analyzer-worker] listening streams=${laneStreams.join(",")} consumer=${consumerName}`);
+  await ensureConsumerGroups();
 
   while (!shuttingDown) {
     try {
@@ -353,10 +428,8 @@ async function workerLoop() {
       console.error(`[analyzer-worker] loop failed err=${details}`);
       if (details.includes("NOGROUP")) {
         try {
-          await ensureConsumerGroup();
-          console.warn(
-            `[analyzer-worker] recreated missing consumer group stream=${queueName} group=${groupName}`,
-          );
+          await ensureConsumerGroups();
+          console.warn(`[analyzer-worker] recreated missing consumer groups streams=${laneStreams.join(",")}`);
         } catch (recoveryError) {
           const recoveryDetails =
             recoveryError instanceof Error ? recoveryError.message : String(recoveryError);
@@ -1,3 +1,5 @@
+import { createHash, createHmac, randomBytes } from "node:crypto";
+
 import type { AnalyzerWorkerConfig } from "./config.js";
 import type { AnalysisReport } from "./types.js";
 
@@ -5,22 +7,52 @@ function normalizeBaseUrl(value: string): string {
   return value.replace(/\/+$/, "");
 }
 
-export async function reportAnalysisCard(
+// signedFetch signs internal callbacks with HMAC-SHA256 over the method, path,
+// timestamp, nonce and body digest; the orchestrator rejects replays and stale
+// timestamps. A W3C traceparent from the job continues the orchestrator trace.
+async function signedFetch(
   config: AnalyzerWorkerConfig,
-  payload: AnalysisReport,
-): Promise<void> {
-  if (!config.internalApiKey) {
-    throw new Error("INTERNAL_API_KEY is required for analysis callbacks");
+  method: "GET" | "POST",
+  pathWithQuery: string,
+  body?: string,
+  traceparent?: string,
+): Promise<Response> {
+  if (!config.internalSigningKey) {
+    throw new Error("INTERNAL_SIGNING_KEY (or INTERNAL_API_KEY) is required for orchestrator callbacks");
   }
 
-  const endpoint = `${normalizeBaseUrl(config.orchestratorBaseUrl)}/v1/internal/analysis-reports`;
-  const response = await fetch(endpoint, {
-    method: "POST",
+  const payload = body ?? "";
+  const timestamp = Math.floor(Date.now() / 1000).toString();
+  const nonce = randomBytes(16).toString("hex");
+  const contentSha256 = createHash("sha256").update(payload).digest("hex");
+  const canonical = ["v1", method, pathWithQuery, timestamp, nonce, contentSha256].join("\n");
+  const signature = createHmac("sha256", config.internalSigningKey).update(canonical).digest("hex");
+
+  return fetch(`${normalizeBaseUrl(config.orchestratorBaseUrl)}${pathWithQuery}`, {
+    method,
     headers: {
-      "Content-Type": "application/json",
-      "X-Retrospec-Internal": config.internalApiKey,
+      ...(body !== undefined ? { "Content-Type": "application/json" } : {}),
+      "X-Retrospec-Signature-Key": config.internalSigningKeyId,
+      "X-Retrospec-Timestamp": timestamp,
+      "X-Retrospec-Nonce": nonce,
+      "X-Retrospec-Content-SHA256": contentSha256,
+      "X-Retrospec-Signature": `v1=${signature}`,
+      ...(traceparent ? { traceparent } : {}),
     },
-    body: JSON.stringify({
+    ...(body !== undefined ? { body } : {}),
+  });
+}
+
+export async function reportAnalysisCard(
+  config: AnalyzerWorkerConfig,
+  payload: AnalysisReport,
+  traceparent?: string,
+): Promise<void> {
+  const response = await signedFetch(
+    config,
+    "POST",
+    "/v1/internal/analysis-reports",
+    JSON.stringify({
       projectId: payload.projectId,
       sessionId: payload.sessionId,
       status: payload.status,
@@ -32,10 +64,33 @@ export async function reportAnalysisCard(
       confidence: payload.confidence,
       generatedAt: payload.generatedAt,
     }),
-  });
+    traceparent,
+  );
 
   if (!response.ok) {
     const body = await response.text().catch(() => "");
     throw new Error(`orchestrator callback failed status=${response.status} body=${body}`);
   }
 }
+
+export async function loadDecryptedEvents(
+  config: AnalyzerWorkerConfig,
+  objectKey: string,
+  traceparent?: string,
+): Promise<unknown> {
+  const response = await signedFetch(
+    config,
+    "GET",
+    `/v1/internal/session-events?key=${encodeURIComponent(objectKey)}`,
+    undefined,
+    traceparent,
+  );
+
+  if (!response.ok) {
+    const body = await response.text().catch(() => "");
+    throw new Error(`orchestrator events load failed status=${response.status} body=${body}`);
+  }
+
+  const payload = (await response.json()) as { events?: unknown };
+  return payload.events;
+}
@@ -1,5 +1,6 @@
-import { GetObjectCommand, S3Client } from "@aws-sdk/client-s3";
+import { GetObjectCommand, HeadObjectCommand, S3Client } from "@aws-sdk/client-s3";
 import { loadConfig } from "./config.js";
+import { loadDecryptedEvents } from "./orchestrator.js";
 
 const config = loadConfig();
 
@@ -23,7 +24,19 @@ async function streamToString(stream: NodeJS.ReadableStream): Promise<string> {
   return Buffer.concat(chunks).toString("utf-8");
 }
 
-export async function loadEventsBlob(objectKey: string): Promise<unknown> {
+export async function loadEventsBlob(objectKey: string, traceparent?: string): Promise<unknown> {
+  const head = await s3Client.send(
+    new HeadObjectCommand({
+      Bucket: config.s3Bucket,
+      Key: objectKey,
+    }),
+  );
+
+  // Encrypted blobs can only be opened by the orchestrator, which holds the data keys.
+  if (head.Metadata?.["retrospec-encryption"]) {
+    return loadDecryptedEvents(config, objectKey, traceparent);
+  }
+
   const response = await s3Client.send(
     new GetObjectCommand({
       Bucket: config.s3Bucket,
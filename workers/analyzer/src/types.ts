@@ -14,6 +14,7 @@ export interface AnalysisJobData {
   route: string;
   site: string;
   attempt?: number;
+  traceparent?: string;
 }
 
 export interface AnalysisReport {
@@ -1,9 +1,13 @@
+export type QueueLane = "high" | "default" | "low";
+
 export interface ReplayWorkerConfig {
   redisHost: string;
   redisPort: number;
   queueName: string;
+  laneWeights: Record<QueueLane, number>;
   orchestratorBaseUrl: string;
-  internalApiKey: string;
+  internalSigningKeyId: string;
+  internalSigningKey: string;
   renderEnabled: boolean;
   renderSpeed: number;
   renderMaxDurationMs: number;
@@ -26,6 +30,7 @@ export interface ReplayWorkerConfig {
   s3SecretKey: string;
   s3Bucket: string;
   artifactPrefix: string;
+  artifactEncryptionEnabled: boolean;
 }
 
 export function loadConfig(): ReplayWorkerConfig {
@@ -33,8 +38,10 @@ export function loadConfig(): ReplayWorkerConfig {
     redisHost: process.env.REDIS_HOST ?? "localhost",
     redisPort: envOrDefaultNumber("REDIS_PORT", 6379),
     queueName: process.env.REPLAY_QUEUE_NAME ?? "replay-jobs",
+    laneWeights: parseLaneWeights(process.env.REPLAY_LANE_WEIGHTS),
     orchestratorBaseUrl: process.env.ORCHESTRATOR_BASE_URL ?? "http://localhost:8080",
-    internalApiKey: process.env.INTERNAL_API_KEY ?? "",
+    internalSigningKeyId: process.env.INTERNAL_SIGNING_KEY_ID ?? "default",
+    internalSigningKey: process.env.INTERNAL_SIGNING_KEY ?? process.env.INTERNAL_API_KEY ?? "",
     renderEnabled: (process.env.REPLAY_RENDER_ENABLED ?? "").toLowerCase() === "true",
     renderSpeed: envOrDefaultNumber("REPLAY_RENDER_SPEED", 4),
     renderMaxDurationMs: envOrDefaultNumber("REPLAY_RENDER_MAX_DURATION_MS", 120_000),
@@ -59,6 +66,7 @@ export function loadConfig(): ReplayWorkerConfig {
     s3SecretKey: process.env.S3_SECRET_KEY ?? "minioadmin",
     s3Bucket: process.env.S3_BUCKET ?? "retrospec-artifacts",
     artifactPrefix: process.env.REPLAY_ARTIFACT_PREFIX ?? "replay-artifacts/",
+    artifactEncryptionEnabled: (process.env.ARTIFACT_ENCRYPTION_ENABLED ?? "").toLowerCase() === "true",
   };
 }
 
@@ -75,6 +83,20 @@ function clamp01(value: number): number {
   return value;
 }
 
+// parseLaneWeights reads "high:6,default:3,low:1"; lanes left out or given
+// an invalid weight keep their default.
+function parseLaneWeights(value: string | undefined): Record<QueueLane, number> {
+  const weights: Record<QueueLane, number> = { high: 6, default: 3, low: 1 };
+  for (const entry of (value ?? "").split(",")) {
+    const [lane, rawWeight] = entry.split(":").map((part) => part.trim());
+    const weight = rawWeight ? Number(rawWeight) : Number.NaN;
+    if ((lane === "high" || lane === "default" || lane === "low") && Number.isFinite(weight) && weight >= 0) {
+      weights[lane] = weight;
+    }
+  }
+  return weights;
+}
+
 function envOrDefaultNumber(key: string, fallback: number): number {
   const value = process.env[key];
   if (!value) {
@@ -2,13 +2,14 @@ import { createHash } from "node:crypto";
 import { hostname } from "node:os";
 import { Redis } from "ioredis";
 import { z } from "zod";
-import { loadConfig } from "./config.js";
+import { loadConfig, type QueueLane } from "./config.js";
 import { reportAnalysisUpdate, reportReplayArtifact } from "./orchestrator.js";
 import { processReplayJob } from "./reconstruct.js";
 import type { AnalysisReportUpdate } from "./types.js";
 import { runVisualVerification } from "./visual.js";
 
 interface StreamMessage {
+  stream: string;
   id: string;
   payload: string;
 }
@@ -22,15 +23,29 @@ const replayJobSchema = z.object({
   route: z.string().default("/unknown"),
   site: z.string().default("unknown-site"),
   attempt: z.number().int().positive().default(1),
+  lane: z.enum(["high", "default", "low"]).default("default"),
+  traceparent: z.string().optional(),
 });
 
 const config = loadConfig();
 const queueName = config.queueName;
-const groupName = `${config.queueName}:group`;
+const queueLanes: QueueLane[] = ["high", "default", "low"];
 const consumerName = `${hostname()}-${process.pid}`;
 const failedQueueName = `${config.queueName}:failed`;
 const retryQueueName = `${config.queueName}:retry`;
 
+// Mirrors queue.LaneStream in the orchestrator: the default lane keeps the
+// base stream so jobs enqueued before lanes existed are still consumed.
+function laneStream(lane: QueueLane): string {
+  return lane === "default" ? queueName : `${queueName}:lane:${lane}`;
+}
+
+function streamGroup(stream: string): string {
+  return `${stream}:group`;
+}
+
+const laneStreams = queueLanes.map(laneStream);
+
 const redis = new Redis({
   host: config.redisHost,
   port: config.redisPort,
@@ -116,6 +131,7 @@ function parseStreamMessages(raw: unknown): StreamMessage[] {
     if (!Array.isArray(streamRows) || streamRows.length < 2) {
       continue;
     }
+    const stream = String(streamRows[0] ?? "");
     const rows = streamRows[1];
     if (!Array.isArray(rows)) {
       continue;
@@ -126,8 +142,8 @@ function parseStreamMessages(raw: unknown): StreamMessage[] {
       }
       const id = String(row[0] ?? "");
       const payload = extractPayload(row[1]);
-      if (id && payload) {
-        messages.push({ id, payload });
+      if (stream && id && payload) {
+        messages.push({ stream, id, payload });
       }
     }
   }
@@ -135,27 +151,27 @@ function parseStreamMessages(raw: unknown): StreamMessage[] {
   return messages;
 }
 
-async function ensureStreamQueue(): Promise<void> {
-  const queueType = await redis.type(queueName);
+async function ensureStreamQueue(stream: string): Promise<void> {
+  const queueType = await redis.type(stream);
   if (queueType === "none" || queueType === "stream") {
     return;
   }
 
   if (queueType !== "list") {
-    throw new Error(`unsupported redis key type for queue ${queueName}: ${queueType}`);
+    throw new Error(`unsupported redis key type for queue ${stream}: ${queueType}`);
   }
 
-  const legacyQueueName = `${queueName}:legacy:list:${Date.now()}`;
-  const renamed = await redis.renamenx(queueName, legacyQueueName);
+  const legacyQueueName = `${stream}:legacy:list:${Date.now()}`;
+  const renamed = await redis.renamenx(stream, legacyQueueName);
   if (renamed !== 1) {
-    const refreshedType = await redis.type(queueName);
+    const refreshedType = await redis.type(stream);
     if (refreshedType === "none" || refreshedType === "stream") {
       return;
     }
     if (refreshedType === "list") {
-      throw new Error(`legacy list queue migration contention for ${queueName}`);
+      throw new Error(`legacy list queue migration contention for ${stream}`);
     }
-    throw new Error(`unsupported redis key type for queue ${queueName}: ${refreshedType}`);
+    throw new Error(`unsupported redis key type for queue ${stream}: ${refreshedType}`);
   }
 
   let migrated = 0;
@@ -164,27 +180,38 @@ async function ensureStreamQueue(): Promise<void> {
     if (!payload) {
       break;
     }
-    await redis.xadd(queueName, "*", "payload", payload);
+    await redis.xadd(stream, "*", "payload", payload);
     migrated += 1;
   }
   await redis.del(legacyQueueName);
   if (migrated > 0) {
-    console.warn(`[replay-worker] migrated legacy list queue=${queueName} entries=${migrated}`);
+    console.warn(`[replay-worker] migrated legacy list queue=${stream} entries=${migrated}`);
   }
 }
 
-async function ensureConsumerGroup(): Promise<void> {
-  await ensureStreamQueue();
-  try {
-    await redis.xgroup("CREATE", queueName, groupName, "0", "MKSTREAM");
-  } catch (error) {
-    const details = error instanceof Error ? error.message : String(error);
-    if (!details.includes("BUSYGROUP")) {
-      throw error;
+async function ensureConsumerGroups(): Promise<void> {
+  for (const stream of laneStreams) {
+    await ensureStreamQueue(stream);
+    try {
+      await redis.xgroup("CREATE", stream, streamGroup(stream), "0", "MKSTREAM");
+    } catch (error) {
+      const details = error instanceof Error ? error.message : String(error);
+      if (!details.includes("BUSYGROUP")) {
+        throw error;
+      }
     }
   }
 }
 
+function payloadLane(payload: string): QueueLane {
+  try {
+    const lane = (JSON.parse(payload) as { lane?: unknown }).lane;
+    return queueLanes.find((candidate) => candidate === lane) ?? "default";
+  } catch {
+    return "default";
+  }
+}
+
 async function drainRetryQueue(): Promise<void> {
   const now = Date.now();
   const due = await redis.zrangebyscore(retryQueueName, 0, now, "LIMIT", 0, 20);
@@ -193,7 +220,7 @@ async function drainRetryQueue(): Promise<void> {
   }
 
   for (const item of due) {
-    await redis.xadd(queueName, "*", "payload", item);
+    await redis.xadd(laneStream(payloadLane(item)), "*", "payload", item);
     await redis.zrem(retryQueueName, item);
   }
 }
@@ -206,17 +233,55 @@ async function enqueueRetry(payload: unknown, attempt: number): Promise<void> {
   await redis.zadd(retryQueueName, runAtMs, JSON.stringify(payload));
 }
 
+// laneReadOrder draws the first lane in proportion to its weight and keeps
+// the rest in priority order, so lower lanes still get turns while higher
+// ones stay busy.
+function laneReadOrder(): QueueLane[] {
+  const total = queueLanes.reduce((sum, lane) => sum + config.laneWeights[lane], 0);
+  let draw = Math.random() * total;
+  let first: QueueLane = "high";
+  for (const lane of queueLanes) {
+    draw -= config.laneWeights[lane];
+    if (draw < 0) {
+      first = lane;
+      break;
+    }
+  }
+  return [first, ...queueLanes.filter((lane) => lane !== first)];
+}
+
 async function readNewMessages(): Promise<StreamMessage[]> {
+  for (const lane of laneReadOrder()) {
+    const stream = laneStream(lane);
+    const result = await redis.xreadgroup(
+      "GROUP",
+      streamGroup(stream),
+      consumerName,
+      "COUNT",
+      "5",
+      "STREAMS",
+      stream,
+      ">",
+    );
+    const messages = parseStreamMessages(result);
+    if (messages.length > 0) {
+      return messages;
+    }
+  }
+
+  // Every lane is empty. Each lane has its own group, so one XREADGROUP cannot
+  // block on all of them; wait on the high lane and poll the rest next loop.
+  const highStream = laneStream("high");
   const result = await redis.xreadgroup(
     "GROUP",
-    groupName,
+    streamGroup(highStream),
     consumerName,
     "COUNT",
     "5",
     "BLOCK",
-    "5000",
+    "1000",
     "STREAMS",
-    queueName,
+    highStream,
     ">",
   );
 
@@ -225,41 +290,47 @@ async function readNewMessages(): Promise<StreamMessage[]> {
 
 async function claimStalePendingMessages(): Promise<StreamMessage[]> {
   const minIdleMs = Math.max(60, Math.floor(config.processingStaleSec)) * 1_000;
-  let cursor = "0-0";
   const claimed: StreamMessage[] = [];
 
-  for (let attempt = 0; attempt < 5; attempt += 1) {
-    const raw = await redis.call(
-      "XAUTOCLAIM",
-      queueName,
-      groupName,
-      consumerName,
-      String(minIdleMs),
-      cursor,
-      "COUNT",
-      "20",
-    );
-    if (!Array.isArray(raw) || raw.length < 2) {
-      break;
-    }
+  for (const stream of laneStreams) {
+    let cursor = "0-0";
+    for (let attempt = 0; attempt < 5; attempt += 1) {
+      const raw = await redis.call(
+        "XAUTOCLAIM",
+        stream,
+        streamGroup(stream),
+        consumerName,
+        String(minIdleMs),
+        cursor,
+        "COUNT",
+        "20",
+      );
+      if (!Array.isArray(raw) || raw.length < 2) {
+        break;
+      }
 
-    cursor = String(raw[0] ?? "0-0");
-    const messages = parseStreamMessages([[queueName, raw[1]]]);
-    if (messages.length === 0) {
-      break;
-    }
+      cursor = String(raw[0] ?? "0-0");
+      const messages = parseStreamMessages([[stream, raw[1]]]);
+      if (messages.length === 0) {
+        break;
+      }
 
-    claimed.push(...messages);
-    if (messages.length < 20) {
-      break;
+      claimed.push(...messages);
+      if (messages.length < 20) {
+        break;
+      }
     }
   }
 
   return claimed;
 }
 
-async function acknowledgeMessage(messageID: string): Promise<void> {
-  await redis.multi().xack(queueName, groupName, messageID).xdel(queueName, messageID).exec();
+async function acknowledgeMessage(message: StreamMessage): Promise<void> {
+  await redis
+    .multi()
+    .xack(message.stream, streamGroup(message.stream), message.id)
+    .xdel(message.stream, message.id)
+    .exec();
 }
 
 async function reserveDailyRenderBudget(counterKey: string, limit: number, ttlSec: number): Promise<boolean> {
@@ -401,49 +472,65 @@ async function handleMessage(message: StreamMessage): Promise<void> {
       renderEnabled: renderPolicy.renderEnabled,
       renderSkipReason: renderPolicy.skipReason,
     });
-    await reportReplayArtifact(config, {
-      projectId: parsed.projectId,
-      sessionId: result.sessionId,
-      triggerKind: parsed.triggerKind,
-      artifactType: "analysis_json",
-      artifactKey: result.artifactKey,
-      status: result.videoStatus === "failed" ? "failed" : "ready",
-      generatedAt: result.generatedAt,
-      windows: result.markerWindows,
-    });
-    if (result.videoStatus === "ready" && result.videoArtifactKey) {
-      await reportReplayArtifact(config, {
+    await reportReplayArtifact(
+      config,
+      {
         projectId: parsed.projectId,
         sessionId: result.sessionId,
         triggerKind: parsed.triggerKind,
-        artifactType: "replay_video",
-        artifactKey: result.videoArtifactKey,
-        status: "ready",
+        artifactType: "analysis_json",
+        artifactKey: result.artifactKey,
+        status: result.videoStatus === "failed" ? "failed" : "ready",
         generatedAt: result.generatedAt,
         windows: result.markerWindows,
-      });
+      },
+      parsed.traceparent,
+    );
+    if (result.videoStatus === "ready" && result.videoArtifactKey) {
+      await reportReplayArtifact(
+        config,
+        {
+          projectId: parsed.projectId,
+          sessionId: result.sessionId,
+          triggerKind: parsed.triggerKind,
+          artifactType: "replay_video",
+          artifactKey: result.videoArtifactKey,
+          status: "ready",
+          generatedAt: result.generatedAt,
+          windows: result.markerWindows,
+        },
+        parsed.traceparent,
+      );
     } else if (result.videoStatus === "failed") {
-      await reportReplayArtifact(config, {
-        projectId: parsed.projectId,
-        sessionId: result.sessionId,
-        triggerKind: parsed.triggerKind,
-        artifactType: "replay_video",
-        artifactKey: "",
-        status: "failed",
-        generatedAt: result.generatedAt,
-        windows: result.markerWindows,
-      });
+      await reportReplayArtifact(
+        config,
+        {
+          projectId: parsed.projectId,
+          sessionId: result.sessionId,
+          triggerKind: parsed.triggerKind,
+          artifactType: "replay_video",
+          artifactKey: "",
+          status: "failed",
+          generatedAt: result.generatedAt,
+          windows: result.markerWindows,
+        },
+        parsed.traceparent,
+      );
     } else if (result.videoStatus === "skipped") {
-      await reportReplayArtifact(config, {
-        projectId: parsed.projectId,
-        sessionId: result.sessionId,
-        triggerKind: parsed.triggerKind,
-        artifactType: "replay_video",
-        artifactKey: "",
-        status: "skipped",
-        generatedAt: result.generatedAt,
-        windows: result.markerWindows,
-      });
+      await reportReplayArtifact(
+        config,
+        {
+          projectId: parsed.projectId,
+          sessionId: result.sessionId,
+          triggerKind: parsed.triggerKind,
+          artifactType: "replay_video",
+          artifactKey: "",
+          status: "skipped",
+          generatedAt: result.generatedAt,
+          windows: result.markerWindows,
+        },
+        parsed.traceparent,
+      );
     }
     if (result.videoStatus !== "ready" || !result.videoArtifactKey) {
       const fallbackStatus: AnalysisReportUpdate["status"] =
@@ -461,6 +548,7 @@ async function handleMessage(message: StreamMessage): Promise<void> {
           fallbackSummary,
           0,
         ),
+        parsed.traceparent,
       );
     } else {
       try {
@@ -484,6 +572,7 @@ async function handleMessage(message: StreamMessage): Promise<void> {
             visualVerdict.technicalRootCause,
             visualVerdict.suggestedFix,
           ),
+          parsed.traceparent,
         );
       } catch (visualError) {
         const details = visualError instanceof Error ? visualError.message : String(visualError);
@@ -498,6 +587,7 @@ async function handleMessage(message: StreamMessage): Promise<void> {
             `Visual model verification failed: ${details}`,
             0,
           ),
+          parsed.traceparent,
         );
       }
     }
@@ -534,7 +624,7 @@ async function handleMessage(message: StreamMessage): Promise<void> {
   } finally {
     if (shouldAcknowledge) {
       try {
-        await acknowledgeMessage(message.id);
+        await acknowledgeMessage(message);
       } catch (ackError) {
         const details = ackError instanceof Error ? ackError.message : String(ackError);
         console.error(`[replay-worker] message ack failed id=${message.id} err=${details}`);
@@ -548,8 +638,8 @@ async function handleMessage(message: StreamMessage): Promise<void> {
 }
 
 async function workerLoop() {
-  console.info(`[replay-worker] listening stream=${queueName} group=${groupName} consumer=${consumerName}`);
-  await ensureConsumerGroup();
+  console.info(`[replay-worker] listening streams=${laneStreams.join(",")} consumer=${consumerName}`);
+  await ensureConsumerGroups();
 
   while (!shuttingDown) {
     try {
@@ -569,10 +659,8 @@ async function workerLoop() {
       console.error(`[replay-worker] loop failed err=${details}`);
       if (details.includes("NOGROUP")) {
         try {
-          await ensureConsumerGroup();
-          console.warn(
-            `[replay-worker] recreated missing consumer group stream=${queueName} group=${groupName}`,
-          );
+          await ensureConsumerGroups();
+          console.warn(`[replay-worker] recreated missing consumer groups streams=${laneStreams.join(",")}`);
         } catch (recoveryError) {
           const recoveryDetails =
             recoveryError instanceof Error ? recoveryError.message : String(recoveryError);
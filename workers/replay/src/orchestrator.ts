@@ -1,3 +1,5 @@
+import { createHash, createHmac, randomBytes } from "node:crypto";
+
 import type { ReplayWorkerConfig } from "./config.js";
 import type { AnalysisReportUpdate, ReplayArtifactReport } from "./types.js";
 
@@ -5,22 +7,53 @@ function normalizeBaseUrl(value: string): string {
   return value.replace(/\/+$/, "");
 }
 
-export async function reportReplayArtifact(
+// signedFetch signs internal callbacks with HMAC-SHA256 over the method, path,
+// timestamp, nonce and body digest; the orchestrator rejects replays and stale
+// timestamps. A W3C traceparent from the job continues the orchestrator trace.
+async function signedFetch(
   config: ReplayWorkerConfig,
-  payload: ReplayArtifactReport,
-): Promise<void> {
-  if (!config.internalApiKey) {
-    throw new Error("INTERNAL_API_KEY is required for replay callbacks");
+  method: "GET" | "POST" | "PUT",
+  pathWithQuery: string,
+  body?: string | Uint8Array,
+  traceparent?: string,
+  contentType = "application/json",
+): Promise<Response> {
+  if (!config.internalSigningKey) {
+    throw new Error("INTERNAL_SIGNING_KEY (or INTERNAL_API_KEY) is required for orchestrator callbacks");
   }
 
-  const endpoint = `${normalizeBaseUrl(config.orchestratorBaseUrl)}/v1/internal/replay-results`;
-  const response = await fetch(endpoint, {
-    method: "POST",
+  const payload = body ?? "";
+  const timestamp = Math.floor(Date.now() / 1000).toString();
+  const nonce = randomBytes(16).toString("hex");
+  const contentSha256 = createHash("sha256").update(payload).digest("hex");
+  const canonical = ["v1", method, pathWithQuery, timestamp, nonce, contentSha256].join("\n");
+  const signature = createHmac("sha256", config.internalSigningKey).update(canonical).digest("hex");
+
+  return fetch(`${normalizeBaseUrl(config.orchestratorBaseUrl)}${pathWithQuery}`, {
+    method,
     headers: {
-      "Content-Type": "application/json",
-      "X-Retrospec-Internal": config.internalApiKey,
+      ...(body !== undefined ? { "Content-Type": contentType } : {}),
+      "X-Retrospec-Signature-Key": config.internalSigningKeyId,
+      "X-Retrospec-Timestamp": timestamp,
+      "X-Retrospec-Nonce": nonce,
+      "X-Retrospec-Content-SHA256": contentSha256,
+      "X-Retrospec-Signature": `v1=${signature}`,
+      ...(traceparent ? { traceparent } : {}),
     },
-    body: JSON.stringify({
+    ...(body !== undefined ? { body } : {}),
+  });
+}
+
+export async function reportReplayArtifact(
+  config: ReplayWorkerConfig,
+  payload: ReplayArtifactReport,
+  traceparent?: string,
+): Promise<void> {
+  const response = await signedFetch(
+    config,
+    "POST",
+    "/v1/internal/replay-results",
+    JSON.stringify({
       projectId: payload.projectId,
       sessionId: payload.sessionId,
       artifactType: payload.artifactType,
@@ -30,7 +63,8 @@ export async function reportReplayArtifact(
       generatedAt: payload.generatedAt,
       windows: payload.windows,
     }),
-  });
+    traceparent,
+  );
 
   if (!response.ok) {
     const body = await response.text().catch(() => "");
@@ -41,19 +75,13 @@ export async function reportReplayArtifact(
 export async function reportAnalysisUpdate(
   config: ReplayWorkerConfig,
   payload: AnalysisReportUpdate,
+  traceparent?: string,
 ): Promise<void> {
-  if (!config.internalApiKey) {
-    throw new Error("INTERNAL_API_KEY is required for replay callbacks");
-  }
-
-  const endpoint = `${normalizeBaseUrl(config.orchestratorBaseUrl)}/v1/internal/analysis-reports`;
-  const response = await fetch(endpoint, {
-    method: "POST",
-    headers: {
-      "Content-Type": "application/json",
-      "X-Retrospec-Internal": config.internalApiKey,
-    },
-    body: JSON.stringify({
+  const response = await signedFetch(
+    config,
+    "POST",
+    "/v1/internal/analysis-reports",
+    JSON.stringify({
       projectId: payload.projectId,
       sessionId: payload.sessionId,
       status: payload.status,
@@ -65,10 +93,59 @@ export async function reportAnalysisUpdate(
       ...(typeof payload.confidence === "number" ? { confidence: payload.confidence } : {}),
       generatedAt: payload.generatedAt,
     }),
-  });
+    traceparent,
+  );
 
   if (!response.ok) {
     const body = await response.text().catch(() => "");
     throw new Error(`analysis callback failed status=${response.status} body=${body}`);
   }
 }
+
+export async function loadDecryptedEvents(
+  config: ReplayWorkerConfig,
+  objectKey: string,
+  traceparent?: string,
+): Promise<unknown> {
+  const response = await signedFetch(
+    config,
+    "GET",
+    `/v1/internal/session-events?key=${encodeURIComponent(objectKey)}`,
+    undefined,
+    traceparent,
+  );
+
+  if (!response.ok) {
+    const body = await response.text().catch(() => "");
+    throw new Error(`orchestrator events load failed status=${response.status} body=${body}`);
+  }
+
+  const payload = (await response.json()) as { events?: unknown };
+  return payload.events;
+}
+
+// storeEncryptedArtifact uploads an artifact through the orchestrator, which
+// encrypts it with the project's data key before writing it to S3.
+export async function storeEncryptedArtifact(
+  config: ReplayWorkerConfig,
+  projectId: string,
+  objectKey: string,
+  payload: string | Uint8Array,
+  contentType: string,
+  traceparent?: string,
+): Promise<void> {
+  const query = `projectId=${encodeURIComponent(projectId)}&key=${encodeURIComponent(objectKey)}`;
+  const response = await signedFetch(
+    config,
+    "PUT",
+    `/v1/internal/artifacts?${query}`,
+    payload,
+    traceparent,
+    contentType,
+  );
+
+  if (!response.ok) {
+    const body = await response.text().catch(() => "");
+    throw new Error(`orchestrator artifact upload failed status=${response.status} body=${body}`);
+  }
+}
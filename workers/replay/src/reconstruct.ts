@@ -28,7 +28,7 @@ export async function processReplayJob(
   options: ReplayProcessingOptions = {},
 ): Promise<ReplayResult> {
   const config = loadConfig();
-  const eventsBlob = await loadEventsBlob(data.eventsObjectKey);
+  const eventsBlob = await loadEventsBlob(data.eventsObjectKey, data.traceparent);
 
   // Schema check catches corrupted uploads before expensive rendering starts.
   const replayEvents = rrwebPayloadSchema.parse(eventsBlob);
@@ -41,8 +41,14 @@ export async function processReplayJob(
   if (shouldRender) {
     try {
       const videoBuffer = await renderReplayWebm(replayEvents, config);
-      videoArtifactKey = `${config.artifactPrefix}${data.sessionId}/full-replay.webm`;
-      await storeBinaryArtifact(videoArtifactKey, videoBuffer, "video/webm");
+      videoArtifactKey = `${config.artifactPrefix}${data.projectId}/${data.sessionId}/full-replay.webm`;
+      await storeBinaryArtifact(
+        data.projectId,
+        videoArtifactKey,
+        videoBuffer,
+        "video/webm",
+        data.traceparent,
+      );
       renderStatus = "ready";
     } catch (error) {
       renderStatus = "failed";
@@ -69,8 +75,8 @@ export async function processReplayJob(
     generatedAt,
   };
 
-  const artifactKey = `${config.artifactPrefix}${data.sessionId}/analysis.json`;
-  await storeArtifact(artifactKey, artifact);
+  const artifactKey = `${config.artifactPrefix}${data.projectId}/${data.sessionId}/analysis.json`;
+  await storeArtifact(data.projectId, artifactKey, artifact, data.traceparent);
 
   return {
     sessionId: data.sessionId,
@@ -1,12 +1,17 @@
 import {
   GetObjectCommand,
+  HeadObjectCommand,
   PutObjectCommand,
   S3Client,
 } from "@aws-sdk/client-s3";
 import { loadConfig } from "./config.js";
+import { loadDecryptedEvents, storeEncryptedArtifact } from "./orchestrator.js";
 
 const config = loadConfig();
 
+// Matches the orchestrator lifecycle rule filter; legal holds re-tag objects to opt them out.
+const RETENTION_EXPIRE_TAGGING = "retrospec-retention=expire";
+
 export const s3Client = new S3Client({
   region: config.s3Region,
   endpoint: config.s3Endpoint,
@@ -27,7 +32,19 @@ async function streamToString(stream: NodeJS.ReadableStream): Promise<string> {
   return Buffer.concat(chunks).toString("utf-8");
 }
 
-export async function loadEventsBlob(objectKey: string): Promise<unknown> {
+export async function loadEventsBlob(objectKey: string, traceparent?: string): Promise<unknown> {
+  const head = await s3Client.send(
+    new HeadObjectCommand({
+      Bucket: config.s3Bucket,
+      Key: objectKey,
+    }),
+  );
+
+  // Encrypted blobs can only be opened by the orchestrator, which holds the data keys.
+  if (head.Metadata?.["retrospec-encryption"]) {
+    return loadDecryptedEvents(config, objectKey, traceparent);
+  }
+
   const response = await s3Client.send(
     new GetObjectCommand({
       Bucket: config.s3Bucket,
@@ -43,13 +60,33 @@ export async function loadEventsBlob(objectKey: string): Promise<unknown> {
   return JSON.parse(raw);
 }
 
-export async function storeArtifact(objectKey: string, payload: unknown): Promise<string> {
+// With artifact encryption on, uploads go through the orchestrator so replay
+// artifacts are encrypted like the event blobs they are rendered from.
+export async function storeArtifact(
+  projectId: string,
+  objectKey: string,
+  payload: unknown,
+  traceparent?: string,
+): Promise<string> {
+  if (config.artifactEncryptionEnabled) {
+    await storeEncryptedArtifact(
+      config,
+      projectId,
+      objectKey,
+      JSON.stringify(payload),
+      "application/json",
+      traceparent,
+    );
+    return objectKey;
+  }
+
   await s3Client.send(
     new PutObjectCommand({
       Bucket: config.s3Bucket,
       Key: objectKey,
       Body: JSON.stringify(payload),
       ContentType: "application/json",
+      Tagging: RETENTION_EXPIRE_TAGGING,
     }),
   );
 
@@ -57,16 +94,24 @@ export async function storeArtifact(objectKey: string, payload: unknown): Promis
 }
 
 export async function storeBinaryArtifact(
+  projectId: string,
   objectKey: string,
   payload: Uint8Array,
   contentType: string,
+  traceparent?: string,
 ): Promise<string> {
+  if (config.artifactEncryptionEnabled) {
+    await storeEncryptedArtifact(config, projectId, objectKey, payload, contentType, traceparent);
+    return objectKey;
+  }
+
   await s3Client.send(
     new PutObjectCommand({
       Bucket: config.s3Bucket,
       Key: objectKey,
       Body: payload,
       ContentType: contentType,
+      Tagging: RETENTION_EXPIRE_TAGGING,
     }),
   );
 
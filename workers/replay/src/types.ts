@@ -7,6 +7,7 @@ export interface ReplayJobData {
   route: string;
   site: string;
   attempt?: number;
+  traceparent?: string;
 }
 
 export interface MarkerWindow {